@@ -0,0 +1,81 @@
+-- Sample question bank fixtures, drawn from by quiz-3.
+-- Import with: psql "$DATABASE_URL" -f fixtures/question_banks.sql
+
+INSERT INTO question_banks (id, data)
+VALUES
+  (
+    'english',
+    '{
+      "id": "english",
+      "name": "English",
+      "questions": [
+        {
+          "id": "v1",
+          "prompt": "Synonym for quick?",
+          "options": [
+            {"id": "o1", "text": "fast", "correct": true},
+            {"id": "o2", "text": "slow", "correct": false}
+          ],
+          "points": 1,
+          "tags": ["vocab"],
+          "difficulty": "easy"
+        },
+        {
+          "id": "v2",
+          "prompt": "Opposite of hot?",
+          "options": [
+            {"id": "o1", "text": "warm", "correct": false},
+            {"id": "o2", "text": "cold", "correct": true}
+          ],
+          "points": 1,
+          "tags": ["vocab"],
+          "difficulty": "easy"
+        },
+        {
+          "id": "v3",
+          "prompt": "Synonym for big?",
+          "options": [
+            {"id": "o1", "text": "large", "correct": true},
+            {"id": "o2", "text": "tiny", "correct": false}
+          ],
+          "points": 1,
+          "tags": ["vocab"],
+          "difficulty": "easy"
+        },
+        {
+          "id": "v4",
+          "prompt": "Synonym for ubiquitous?",
+          "options": [
+            {"id": "o1", "text": "rare", "correct": false},
+            {"id": "o2", "text": "everywhere", "correct": true}
+          ],
+          "points": 2,
+          "tags": ["vocab"],
+          "difficulty": "hard"
+        },
+        {
+          "id": "g1",
+          "prompt": "She ___ to school every day.",
+          "options": [
+            {"id": "o1", "text": "go", "correct": false},
+            {"id": "o2", "text": "goes", "correct": true}
+          ],
+          "points": 1,
+          "tags": ["grammar"],
+          "difficulty": "easy"
+        },
+        {
+          "id": "g2",
+          "prompt": "If I ___ you, I would apologise.",
+          "options": [
+            {"id": "o1", "text": "were", "correct": true},
+            {"id": "o2", "text": "was", "correct": false}
+          ],
+          "points": 2,
+          "tags": ["grammar"],
+          "difficulty": "hard"
+        }
+      ]
+    }'::jsonb
+  )
+ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW();
@@ -1,8 +1,9 @@
--- Sample quiz fixtures.
+-- Sample quiz fixtures, published as version 1 of each quiz. Quizzes that already have versions
+-- are left alone: publish changes with the import command instead.
 -- Import with: psql "$DATABASE_URL" -f fixtures/quizzes.sql
 
-INSERT INTO quizzes (id, data)
-VALUES
+WITH fixtures (id, data) AS (
+  VALUES
   (
     'quiz-1',
     '{
@@ -48,5 +49,24 @@ VALUES
         }
       ]
     }'::jsonb
+  ),
+  (
+    'quiz-3',
+    '{
+      "id": "quiz-3",
+      "questions": [],
+      "draws": [
+        {"bank": "english", "tags": ["vocab"], "difficulty": "easy", "count": 2},
+        {"bank": "english", "tags": ["grammar"], "count": 1}
+      ]
+    }'::jsonb
   )
+), seeded AS (
+  INSERT INTO quiz_versions (quiz_id, version, status, data, published_at)
+  SELECT id, 1, 'published', data, NOW() FROM fixtures
+  WHERE NOT EXISTS (SELECT 1 FROM quiz_versions v WHERE v.quiz_id = fixtures.id)
+  RETURNING quiz_id, data
+)
+INSERT INTO quizzes (id, data)
+SELECT quiz_id, data FROM seeded
 ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW();
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"math"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// AnswerRepository keeps the answers given in sessions after they end.
+type AnswerRepository interface {
+	// Record stores an answer, replacing the participant's earlier answer to the question in the same session.
+	Record(ctx context.Context, record domain.AnswerRecord) error
+	// Answers returns every answer recorded against one version of a quiz.
+	Answers(ctx context.Context, quizID string, version int) ([]domain.AnswerRecord, error)
+}
+
+// ItemAnalytics reports how each of a quiz version's graded questions performed across every
+// session recorded against that version; version 0 means the published one. Answers to other
+// versions are left out, since the same question ID may have changed between them. Quizzes that
+// draw from question banks also list the bank questions that were answered.
+func (s *QuizService) ItemAnalytics(ctx context.Context, quizID string, version int) (domain.QuizAnalytics, error) {
+	var quiz domain.Quiz
+	var err error
+	if version > 0 {
+		quiz, err = s.quizzes.GetQuizVersion(ctx, quizID, version)
+	} else {
+		quiz, err = s.quizzes.GetQuiz(ctx, quizID)
+	}
+	if err != nil {
+		return domain.QuizAnalytics{}, err
+	}
+	var records []domain.AnswerRecord
+	if s.answerLog != nil {
+		// Answers given on this instance are still being written; wait for them.
+		if err := s.answers.flush(ctx); err != nil {
+			return domain.QuizAnalytics{}, err
+		}
+		if records, err = s.answerLog.Answers(ctx, quizID, quiz.Version); err != nil {
+			return domain.QuizAnalytics{}, err
+		}
+	}
+	var drawn []domain.Question
+	if s.banks != nil {
+		loaded := make(map[string]bool)
+		for _, draw := range quiz.Draws {
+			if loaded[draw.Bank] {
+				continue
+			}
+			loaded[draw.Bank] = true
+			bank, err := s.banks.GetBank(ctx, draw.Bank)
+			if err != nil {
+				return domain.QuizAnalytics{}, err
+			}
+			drawn = append(drawn, bank.Questions...)
+		}
+	}
+	analytics := itemAnalytics(quizID, quiz.Questions, drawn, records)
+	analytics.Version = quiz.Version
+	return analytics, nil
+}
+
+// respondent is one participant in one session.
+type respondent struct {
+	sessionID, userID string
+}
+
+// itemAnalytics computes classical item statistics for the quiz's own questions and for the
+// drawn ones that were answered. A respondent's total is how many questions they got right.
+func itemAnalytics(quizID string, own, drawn []domain.Question, records []domain.AnswerRecord) domain.QuizAnalytics {
+	totals := make(map[respondent]int)
+	byQuestion := make(map[string][]domain.AnswerRecord)
+	for _, r := range records {
+		totals[respondent{r.SessionID, r.UserID}] += score(r.Correct)
+		byQuestion[r.QuestionID] = append(byQuestion[r.QuestionID], r)
+	}
+
+	analytics := domain.QuizAnalytics{QuizID: quizID, Respondents: len(totals), Items: []domain.ItemAnalytics{}}
+	listed := make(map[string]bool)
+	add := func(q domain.Question, always bool) {
+		answers := byQuestion[q.ID]
+		if !q.Graded() || listed[q.ID] || (!always && len(answers) == 0) {
+			return
+		}
+		listed[q.ID] = true
+		analytics.Items = append(analytics.Items, itemStats(q, answers, totals))
+	}
+	for _, q := range own {
+		add(q, true)
+	}
+	for _, q := range drawn {
+		add(q, false)
+	}
+	return analytics
+}
+
+// itemStats computes one question's p-value, point-biserial, response time and option rates.
+// The point-biserial correlates the question's score with the rest of each respondent's total,
+// so the question isn't correlated with itself.
+func itemStats(q domain.Question, answers []domain.AnswerRecord, totals map[respondent]int) domain.ItemAnalytics {
+	item := domain.ItemAnalytics{QuestionID: q.ID, Prompt: q.Prompt, Responses: len(answers), Options: []domain.DistractorStats{}}
+	counts := make(map[string]int)
+	var correct, timed int
+	var elapsed int64
+	itemScores := make([]float64, 0, len(answers))
+	restScores := make([]float64, 0, len(answers))
+	for _, a := range answers {
+		counts[a.OptionID]++
+		correct += score(a.Correct)
+		if a.ResponseMs > 0 {
+			timed++
+			elapsed += a.ResponseMs
+		}
+		itemScores = append(itemScores, float64(score(a.Correct)))
+		restScores = append(restScores, float64(totals[respondent{a.SessionID, a.UserID}]-score(a.Correct)))
+	}
+	if len(answers) > 0 {
+		item.PValue = round3(float64(correct) / float64(len(answers)))
+	}
+	if timed > 0 {
+		item.AvgResponseMs = math.Round(float64(elapsed) / float64(timed))
+	}
+	item.PointBiserial = round3(correlation(itemScores, restScores))
+	for _, option := range q.Options {
+		stats := domain.DistractorStats{OptionID: option.ID, Text: option.Text, Correct: option.Correct, Count: counts[option.ID]}
+		if len(answers) > 0 {
+			stats.Rate = round3(float64(stats.Count) / float64(len(answers)))
+		}
+		item.Options = append(item.Options, stats)
+	}
+	return item
+}
+
+func score(correct bool) int {
+	if correct {
+		return 1
+	}
+	return 0
+}
+
+// correlation is Pearson's r, or zero when either side doesn't vary.
+func correlation(x, y []float64) float64 {
+	n := float64(len(x))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
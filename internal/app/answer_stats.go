@@ -0,0 +1,185 @@
+package app
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// EventAnswerStats is the broadcast type carrying a domain.AnswerStats histogram, or a poll's results.
+const EventAnswerStats = "answerStats"
+
+// questionStats aggregates the latest answers to one question as they arrive, so a histogram
+// costs the same however many players have answered.
+type questionStats struct {
+	question  domain.Question
+	counts    map[string]int // optionID -> answers
+	responses int
+	correct   int
+	timed     int // answers with a response time, i.e. given after the host opened the question
+	elapsed   time.Duration
+	closed    bool
+
+	// Rating and word cloud polls.
+	ratings   map[int]int
+	ratingSum int
+	words     map[string]int
+}
+
+// statsForLocked returns the aggregate for question, creating it on first use.
+func (s *Session) statsForLocked(question domain.Question) *questionStats {
+	qs, ok := s.stats[question.ID]
+	if !ok {
+		qs = &questionStats{question: question, counts: make(map[string]int), ratings: make(map[int]int), words: make(map[string]int)}
+		s.stats[question.ID] = qs
+	}
+	return qs
+}
+
+// countAnswerLocked adds a (or, with sign -1, takes it back out of) the question's aggregate and
+// marks the histogram for the next broadcast.
+func (s *Session) countAnswerLocked(questionID string, a answer, sign int) {
+	qs, ok := s.stats[questionID]
+	if !ok {
+		return
+	}
+	if a.optionID != "" {
+		qs.counts[a.optionID] += sign
+	}
+	for _, id := range a.poll.optionIDs {
+		qs.counts[id] += sign
+	}
+	if qs.question.Type == domain.QuestionRating {
+		qs.ratings[a.poll.rating] += sign
+		qs.ratingSum += sign * a.poll.rating
+	}
+	for _, word := range a.poll.words {
+		if qs.words[word] += sign; qs.words[word] == 0 {
+			delete(qs.words, word)
+		}
+	}
+	qs.responses += sign
+	if a.correct {
+		qs.correct += sign
+	}
+	if a.timed {
+		qs.timed += sign
+		qs.elapsed += time.Duration(sign) * a.elapsed
+	}
+	s.statsDirty[questionID] = struct{}{}
+}
+
+// closeQuestion stops accepting answers to a question, reveals the answer and explanation, and
+// publishes the final histogram, which players see too when the session reveals stats.
+func (s *Session) closeQuestion(question domain.Question) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	qs := s.statsForLocked(question)
+	if qs.closed {
+		return
+	}
+	qs.closed = true
+	delete(s.statsDirty, question.ID)
+	s.revealLocked(question)
+	s.publishStatsLocked(qs)
+}
+
+// answerStats returns the current histogram for question.
+func (s *Session) answerStats(question domain.Question) domain.AnswerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statsViewLocked(s.statsForLocked(question))
+}
+
+// broadcastStatsLocked publishes the histograms that changed since the last broadcast. It rides
+// on the leaderboard broadcast, so stats are coalesced by the same window.
+func (s *Session) broadcastStatsLocked() {
+	for questionID := range s.statsDirty {
+		s.publishStatsLocked(s.stats[questionID])
+	}
+	clear(s.statsDirty)
+}
+
+// publishStatsLocked sends a histogram to host and spectator subscriptions, the ones without a
+// userID, and to players as well once the question is closed and the session reveals stats.
+func (s *Session) publishStatsLocked(qs *questionStats) {
+	reveal := qs.closed && s.settings.RevealStats
+	var b *Broadcast
+	for sub := range s.subscribers {
+		if sub.userID != "" && !reveal {
+			continue
+		}
+		if b == nil {
+			b = newBroadcast(EventAnswerStats, s.statsViewLocked(qs))
+		}
+		s.deliverLocked(sub, b)
+	}
+}
+
+func (s *Session) statsViewLocked(qs *questionStats) domain.AnswerStats {
+	stats := domain.AnswerStats{
+		SessionID:    s.id,
+		QuestionID:   qs.question.ID,
+		Options:      make([]domain.OptionStats, 0, len(qs.question.Options)),
+		Responses:    qs.responses,
+		Participants: len(s.participants),
+		Closed:       qs.closed,
+	}
+	for _, option := range qs.question.Options {
+		count := qs.counts[option.ID]
+		stats.Options = append(stats.Options, domain.OptionStats{
+			OptionID: option.ID,
+			Count:    count,
+			Percent:  percent(count, qs.responses),
+			Correct:  option.Correct,
+		})
+	}
+	stats.PercentCorrect = percent(qs.correct, qs.responses)
+	if qs.timed > 0 {
+		stats.AvgResponseMs = (qs.elapsed / time.Duration(qs.timed)).Milliseconds()
+	}
+	if !qs.question.Graded() {
+		stats.Type = qs.question.Type
+		pollResultsLocked(qs, &stats)
+	}
+	return stats
+}
+
+// pollResultsLocked fills in the rating distribution or the most used words of a poll. Multi
+// choice percentages are of respondents, so they can add up to more than 100.
+func pollResultsLocked(qs *questionStats, stats *domain.AnswerStats) {
+	switch qs.question.Type {
+	case domain.QuestionRating:
+		low, high := qs.question.RatingBounds()
+		for value := low; value <= high; value++ {
+			stats.Ratings = append(stats.Ratings, domain.RatingCount{Value: value, Count: qs.ratings[value]})
+		}
+		if qs.responses > 0 {
+			stats.AverageRating = math.Round(float64(qs.ratingSum)*100/float64(qs.responses)) / 100
+		}
+	case domain.QuestionWordCloud:
+		for word, count := range qs.words {
+			stats.Words = append(stats.Words, domain.WordCount{Word: word, Count: count})
+		}
+		slices.SortFunc(stats.Words, func(a, b domain.WordCount) int {
+			if a.Count != b.Count {
+				return b.Count - a.Count
+			}
+			return strings.Compare(a.Word, b.Word)
+		})
+		if len(stats.Words) > maxWordCloudWords {
+			stats.Words = stats.Words[:maxWordCloudWords]
+		}
+	}
+}
+
+// percent is part/whole as a percentage rounded to one decimal place.
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+const (
+	// answerQueueSize is how many answers may wait for the answer log before new ones are dropped.
+	answerQueueSize = 1024
+	// answerWriteTimeout bounds a single write to the answer log.
+	answerWriteTimeout = 5 * time.Second
+)
+
+// answerWriter records answers in the background, so a slow answer log doesn't hold up
+// SubmitAnswer. Answers are written one at a time in the order they were given, which keeps a
+// changed answer replacing the earlier one. When the queue is full new answers are dropped and
+// logged: they already count in their session, so only analytics lose them.
+type answerWriter struct {
+	answers AnswerRepository
+	queue   chan answerJob
+	done    chan struct{}
+
+	mu     sync.RWMutex // guards closed, so nothing is queued once the queue is closed
+	closed bool
+}
+
+// answerJob is an answer to write, or a flush marker closed once every earlier answer is written.
+type answerJob struct {
+	record  domain.AnswerRecord
+	flushed chan struct{}
+}
+
+func newAnswerWriter(answers AnswerRepository, size int) *answerWriter {
+	w := &answerWriter{answers: answers, queue: make(chan answerJob, size), done: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+func (w *answerWriter) run() {
+	defer close(w.done)
+	for job := range w.queue {
+		if job.flushed != nil {
+			close(job.flushed)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), answerWriteTimeout)
+		if err := w.answers.Record(ctx, job.record); err != nil {
+			log.Printf("record answer of %s to %s in session %s: %v", job.record.UserID, job.record.QuestionID, job.record.SessionID, err)
+		}
+		cancel()
+	}
+}
+
+// record queues an answer without waiting for it to be written.
+func (w *answerWriter) record(record domain.AnswerRecord) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		log.Printf("answer log closed, dropping answer of %s to %s in session %s", record.UserID, record.QuestionID, record.SessionID)
+		return
+	}
+	select {
+	case w.queue <- answerJob{record: record}:
+	default:
+		log.Printf("answer log queue full, dropping answer of %s to %s in session %s", record.UserID, record.QuestionID, record.SessionID)
+	}
+}
+
+// flush waits until every answer queued before it has been written.
+func (w *answerWriter) flush(ctx context.Context) error {
+	job := answerJob{flushed: make(chan struct{})}
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		<-w.done
+		return nil
+	}
+	select {
+	case w.queue <- job:
+		w.mu.RUnlock()
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-job.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops taking answers and waits for the queued ones to be written.
+func (w *answerWriter) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"crypto/subtle"
+	"math"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// answer is a participant's latest answer to one question.
+type answer struct {
+	optionID string
+	correct  bool
+	awarded  int
+	poll     pollResponse  // ungraded questions only
+	elapsed  time.Duration // since the host opened the question, when timed is set
+	timed    bool
+}
+
+// authorizeHost checks token against the host token issued when the session was created.
+func (s *Session) authorizeHost(token string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.hostToken == "" || subtle.ConstantTimeCompare([]byte(s.hostToken), []byte(token)) != 1 {
+		return domain.ErrHostOnly
+	}
+	return nil
+}
+
+// openQuestion starts the clock on a question. Reopening keeps the original start time. With a
+// time limit, the question closes by itself once the limit has passed.
+func (s *Session) openQuestion(question domain.Question) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statsForLocked(question)
+	if _, ok := s.opened[question.ID]; !ok {
+		s.opened[question.ID] = s.now()
+		if limit := time.Duration(s.settings.QuestionTimeLimitMs) * time.Millisecond; limit > 0 {
+			time.AfterFunc(limit, func() { s.closeQuestion(question) })
+		}
+	}
+	s.startLocked()
+}
+
+// startLocked marks the session as started, which closes the door on late joiners if the host chose so.
+func (s *Session) startLocked() {
+	if !s.started {
+		s.started = true
+		s.admitWaitingLocked()
+	}
+}
+
+// recordAnswer applies the session's time limit, answer-change and scoring policies to a graded
+// submission. Lifelines used on the question come off the award, which can take it below zero.
+// The returned record is what the answer log keeps for analytics.
+func (s *Session) recordAnswer(userID string, question domain.Question, optionID string, correct bool, points int) (domain.AnswerResult, domain.AnswerRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, err := s.attemptLocked(userID, question)
+	if err != nil {
+		return domain.AnswerResult{}, domain.AnswerRecord{}, err
+	}
+	earned := 0
+	if correct {
+		earned = points
+		if at.timed && s.settings.Scoring == domain.ScoringSpeed {
+			earned = speedPoints(points, at.elapsed, at.limit)
+		}
+	}
+	penalty := s.lifelineCostLocked(userID, question.ID)
+	awarded := earned - penalty
+	s.storeAnswerLocked(userID, question.ID, at, answer{optionID: optionID, correct: correct, awarded: awarded})
+	// A changed answer replaces the previous one, including whatever it scored.
+	s.addScoreLocked(at.participant, awarded-at.prev.awarded)
+	result := domain.AnswerResult{
+		QuestionID: question.ID,
+		Correct:    correct,
+		Awarded:    awarded,
+		Penalty:    penalty,
+		TotalScore: at.participant.Score,
+	}
+	if p := s.paces[userID]; p != nil && s.adaptiveLocked() {
+		ability := s.abilityLocked(userID, p)
+		result.Ability = &ability
+	}
+	record := domain.AnswerRecord{
+		SessionID:   s.id,
+		QuizID:      s.quizID,
+		QuizVersion: s.quizVersion,
+		QuestionID:  question.ID,
+		UserID:      userID,
+		OptionID:    optionID,
+		Correct:     correct,
+		Awarded:     awarded,
+		AnsweredAt:  s.now(),
+	}
+	if at.opened {
+		record.ResponseMs = at.elapsed.Milliseconds()
+	}
+	return result, record, nil
+}
+
+// attempt is what the session knows about a submission that passed its checks.
+type attempt struct {
+	participant *domain.Participant
+	prev        answer // the answer being replaced, if answered
+	answered    bool
+	elapsed     time.Duration // since the host opened the question, if opened
+	opened      bool
+	limit       time.Duration
+	timed       bool // opened with a time limit
+}
+
+// attemptLocked runs the checks every submission goes through, graded or not: the participant is
+// in the session, the time limit hasn't passed, the question is still open and, unless answers may
+// change, it hasn't been answered yet. In self-paced sessions the clock starts when the participant
+// is given the question, and the deadline closes every question.
+func (s *Session) attemptLocked(userID string, question domain.Question) (attempt, error) {
+	participant, ok := s.participants[userID]
+	if !ok {
+		return attempt{}, domain.ErrParticipantNotFound
+	}
+	at := attempt{participant: participant, limit: time.Duration(s.settings.QuestionTimeLimitMs) * time.Millisecond}
+	var openedAt time.Time
+	if s.selfPacedLocked() {
+		if s.closedLocked() {
+			return attempt{}, domain.ErrAssignmentClosed
+		}
+		if openedAt, at.opened = s.servedLocked(userID, question.ID); !at.opened {
+			return attempt{}, domain.ErrQuestionNotReached
+		}
+	} else {
+		openedAt, at.opened = s.opened[question.ID]
+	}
+	at.timed = at.opened && at.limit > 0
+	at.elapsed = s.now().Sub(openedAt)
+	if at.timed && at.elapsed > at.limit {
+		return attempt{}, domain.ErrTimeUp
+	}
+	if s.statsForLocked(question).closed {
+		return attempt{}, domain.ErrQuestionClosed
+	}
+	at.prev, at.answered = s.answers[userID][question.ID]
+	if at.answered && s.settings.AnswerChange != domain.AnswerChangeAllow {
+		return attempt{}, domain.ErrAlreadyAnswered
+	}
+	return at, nil
+}
+
+// storeAnswerLocked records next as the participant's answer, swapping it for the previous one in
+// the question's stats, and starts the session. Self-paced participants move on to their next question.
+func (s *Session) storeAnswerLocked(userID, questionID string, at attempt, next answer) {
+	answers := s.answers[userID]
+	if answers == nil {
+		answers = make(map[string]answer)
+		s.answers[userID] = answers
+	}
+	if at.answered {
+		s.countAnswerLocked(questionID, at.prev, -1)
+	}
+	next.elapsed, next.timed = at.elapsed, at.opened
+	answers[questionID] = next
+	s.countAnswerLocked(questionID, next, 1)
+	s.startLocked()
+	if p := s.paces[userID]; p != nil {
+		s.advanceLocked(at.participant, p)
+	}
+}
+
+// speedPoints scales points linearly from 100% for an instant answer down to 50% at the limit.
+func speedPoints(points int, elapsed, limit time.Duration) int {
+	left := 1 - float64(elapsed)/float64(limit)
+	return int(math.Round(float64(points) * (0.5 + 0.5*max(left, 0))))
+}
@@ -0,0 +1,35 @@
+package app
+
+import "sync"
+
+const (
+	// EventLeaderboard is the broadcast type carrying a domain.Leaderboard snapshot.
+	EventLeaderboard = "leaderboard"
+	// EventLeaderboardPatch is the broadcast type carrying a domain.LeaderboardPatch.
+	EventLeaderboardPatch = "leaderboardPatch"
+	// EventRemoved carries a domain.Removal and is the last broadcast a removed participant receives.
+	EventRemoved = "removed"
+)
+
+// Broadcast is a single session update fanned out to every subscriber.
+// All subscribers receive the same pointer, so transports can serialize it once via Prepare.
+type Broadcast struct {
+	Type    string
+	Payload any
+
+	once     sync.Once
+	prepared any
+	err      error
+}
+
+func newBroadcast(eventType string, payload any) *Broadcast {
+	return &Broadcast{Type: eventType, Payload: payload}
+}
+
+// Prepare runs encode at most once per broadcast and hands the cached result to every caller.
+func (b *Broadcast) Prepare(encode func(*Broadcast) (any, error)) (any, error) {
+	b.once.Do(func() {
+		b.prepared, b.err = encode(b)
+	})
+	return b.prepared, b.err
+}
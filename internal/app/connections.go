@@ -0,0 +1,74 @@
+package app
+
+import (
+	"slices"
+	"sync"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// connection is one socket a participant holds open in a session.
+type connection struct {
+	takenOver chan struct{}
+}
+
+// Connection is a participant's claim on a session from one device. Close it when the socket
+// goes away: the participant leaves once their last connection has closed.
+type Connection struct {
+	// TakenOver is closed when a newer connection replaces this one under the takeover policy.
+	TakenOver <-chan struct{}
+	close     func()
+}
+
+// Close releases the connection. It is safe to call more than once.
+func (c *Connection) Close() {
+	c.close()
+}
+
+// connect registers a connection for userID. Under the takeover policy the user's older
+// connections are told to close and stop counting towards their presence.
+func (s *Session) connect(userID string) *connection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := &connection{takenOver: make(chan struct{})}
+	if s.settings.Devices == domain.DevicesTakeover {
+		for _, old := range s.conns[userID] {
+			close(old.takenOver)
+		}
+		delete(s.conns, userID)
+	}
+	s.conns[userID] = append(s.conns[userID], c)
+	return c
+}
+
+// disconnect drops c; when it was the user's last connection they leave the session and the
+// freed slot goes to the waiting room. A connection that was taken over no longer counts.
+func (s *Session) disconnect(userID string, c *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := s.conns[userID]
+	i := slices.Index(conns, c)
+	if i < 0 {
+		return
+	}
+	if conns = slices.Delete(conns, i, i+1); len(conns) > 0 {
+		s.conns[userID] = conns
+		return
+	}
+	delete(s.conns, userID)
+	s.departLocked(userID)
+}
+
+func newConnection(session *Session, userID string, release func()) *Connection {
+	c := session.connect(userID)
+	var once sync.Once
+	return &Connection{
+		TakenOver: c.takenOver,
+		close: func() {
+			once.Do(func() {
+				session.disconnect(userID, c)
+				release()
+			})
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"elsa-quiz-service/internal/domain"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultMinNameLength and DefaultMaxNameLength bound display names, in characters.
+	DefaultMinNameLength = 1
+	DefaultMaxNameLength = 32
+)
+
+// NamePolicy vets display names before they reach the leaderboard: it normalizes them, strips
+// invisible characters, enforces length limits and rejects words from per-locale lists.
+type NamePolicy struct {
+	MinLength int
+	MaxLength int
+	// DefaultLocale's list applies in every session, on top of the session's own locale.
+	DefaultLocale string
+	words         map[string]map[string]struct{} // locale -> folded words
+}
+
+func NewNamePolicy(minLength, maxLength int) *NamePolicy {
+	return &NamePolicy{MinLength: minLength, MaxLength: maxLength, words: make(map[string]map[string]struct{})}
+}
+
+// AddWords extends the banned word list for locale.
+func (p *NamePolicy) AddWords(locale string, words []string) {
+	set := p.words[locale]
+	if set == nil {
+		set = make(map[string]struct{})
+		p.words[locale] = set
+	}
+	for _, word := range words {
+		if folded := strings.Join(wordTokens(word), ""); folded != "" {
+			set[folded] = struct{}{}
+		}
+	}
+}
+
+// Clean returns the normalized form of name, or a *domain.NameError saying why it can't be used.
+func (p *NamePolicy) Clean(name, locale string) (string, error) {
+	clean := normalizeName(name)
+	n := utf8.RuneCountInString(clean)
+	switch {
+	case n == 0:
+		return "", &domain.NameError{Reason: domain.NameEmpty}
+	case n < p.MinLength:
+		return "", &domain.NameError{Reason: domain.NameTooShort, Min: p.MinLength}
+	case p.MaxLength > 0 && n > p.MaxLength:
+		return "", &domain.NameError{Reason: domain.NameTooLong, Max: p.MaxLength}
+	case p.profane(clean, locale):
+		return "", &domain.NameError{Reason: domain.NameProfanity}
+	}
+	return clean, nil
+}
+
+// profane checks every word of the name, and the name with its separators squeezed out, so
+// "b a d" and "b.a.d" are caught as well as "bad".
+func (p *NamePolicy) profane(name, locale string) bool {
+	tokens := wordTokens(name)
+	candidates := append(tokens, strings.Join(tokens, ""))
+	for _, l := range []string{locale, p.DefaultLocale} {
+		words := p.words[l]
+		for _, candidate := range candidates {
+			if _, ok := words[candidate]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeName applies NFKC (so full-width and stylized letters become plain ones), drops
+// zero-width, control and blank-looking characters, and collapses runs of whitespace.
+func normalizeName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFKC.String(name) {
+		switch {
+		case invisible(r):
+			continue
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func invisible(r rune) bool {
+	switch r {
+	case '\u034f', '\u115f', '\u1160', '\u2800', '\u3164', '\uffa0':
+		// Combining grapheme joiner and the Hangul/Braille blanks that render as nothing.
+		return true
+	}
+	return unicode.Is(unicode.Cf, r) || unicode.IsControl(r)
+}
+
+// nameKey folds a cleaned name into the form used to tell names apart: lower case, without
+// accents, and with look-alike Cyrillic and Greek letters mapped onto Latin, so "Аlice"
+// (Cyrillic А) and "alice" count as the same name.
+func nameKey(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// wordTokens splits a name into folded words, also undoing common digit and symbol swaps.
+func wordTokens(name string) []string {
+	var b strings.Builder
+	for _, r := range nameKey(name) {
+		if latin, ok := leet[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return strings.FieldsFunc(b.String(), func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'ӏ': 'l', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x',
+	// Latin look-alikes
+	'ı': 'i', 'ȷ': 'j', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ħ': 'h',
+}
+
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// uniqueNameLocked returns name, or name with a " 2", " 3"... suffix when another participant
+// already uses a name that looks the same. Suffixed names are trimmed to stay within maxLength.
+func (s *Session) uniqueNameLocked(userID, name string) string {
+	if owner, taken := s.nameOwners[nameKey(name)]; !taken || owner == userID {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := " " + strconv.Itoa(i)
+		base := name
+		if limit := s.maxNameLength - len(suffix); s.maxNameLength > 0 && utf8.RuneCountInString(base) > limit {
+			base = strings.TrimSpace(string([]rune(base)[:max(limit, 1)]))
+		}
+		candidate := base + suffix
+		if owner, taken := s.nameOwners[nameKey(candidate)]; !taken || owner == userID {
+			return candidate
+		}
+	}
+}
+
+// setNameLocked renames a participant and keeps the name index in step.
+func (s *Session) setNameLocked(participant *domain.Participant, name string) {
+	if key := nameKey(participant.DisplayName); s.nameOwners[key] == participant.UserID {
+		delete(s.nameOwners, key)
+	}
+	participant.DisplayName = name
+	s.nameOwners[nameKey(name)] = participant.UserID
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"math"
+	"slices"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// labelDifficulty places uncalibrated questions on the ability scale by their difficulty label.
+var labelDifficulty = map[string]float64{
+	domain.DifficultyEasy:   -1,
+	domain.DifficultyMedium: 0,
+	domain.DifficultyHard:   1,
+}
+
+// itemParams returns a question's discrimination and difficulty, falling back to the Rasch
+// model and the difficulty label for questions without IRT parameters.
+func itemParams(q domain.Question) (a, b float64) {
+	a, b = 1, labelDifficulty[q.Difficulty]
+	if q.IRT != nil {
+		b = q.IRT.Difficulty
+		if q.IRT.Discrimination > 0 {
+			a = q.IRT.Discrimination
+		}
+	}
+	return a, b
+}
+
+// probability is the chance a learner of ability theta answers the item correctly.
+func probability(theta, a, b float64) float64 {
+	return 1 / (1 + math.Exp(-a*(theta-b)))
+}
+
+// information is how much the item tells about a learner of ability theta.
+func information(theta, a, b float64) float64 {
+	p := probability(theta, a, b)
+	return a * a * p * (1 - p)
+}
+
+// itemResponse is one scored item in an ability estimate.
+type itemResponse struct {
+	a, b    float64
+	correct bool
+}
+
+// Quadrature grid for the ability estimate.
+const (
+	thetaMin  = -4.0
+	thetaMax  = 4.0
+	thetaStep = 0.05
+)
+
+// estimateAbility returns the expected a posteriori ability under a standard normal prior and
+// its posterior standard deviation as the standard error. The prior keeps the estimate finite
+// when every answer so far is right, or wrong.
+func estimateAbility(responses []itemResponse) domain.Ability {
+	var thetas, logs []float64
+	for theta := thetaMin; theta <= thetaMax+thetaStep/2; theta += thetaStep {
+		l := -theta * theta / 2
+		for _, r := range responses {
+			p := probability(theta, r.a, r.b)
+			if r.correct {
+				l += math.Log(p)
+			} else {
+				l += math.Log(1 - p)
+			}
+		}
+		thetas = append(thetas, theta)
+		logs = append(logs, l)
+	}
+	peak := slices.Max(logs)
+	var sum, mean, square float64
+	for i, theta := range thetas {
+		w := math.Exp(logs[i] - peak)
+		sum += w
+		mean += w * theta
+		square += w * theta * theta
+	}
+	mean /= sum
+	return domain.Ability{
+		Theta:         round3(mean),
+		StandardError: round3(math.Sqrt(max(square/sum-mean*mean, 0))),
+		Answered:      len(responses),
+	}
+}
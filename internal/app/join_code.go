@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// joinCodeAlphabet leaves out characters that are easy to misread aloud or on a projector
+	// (0/O, 1/I/L) so students can type codes reliably.
+	joinCodeAlphabet   = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeLength     = 6
+	joinCodeAttempts   = 10
+	defaultJoinCodeTTL = 4 * time.Hour
+)
+
+var errJoinCodeExhausted = errors.New("could not reserve a unique join code")
+
+// reserveJoinCode draws random codes until one is free, so two live sessions never share a code.
+func reserveJoinCode(ctx context.Context, codes JoinCodeRepository, sessionID string, ttl time.Duration) (string, error) {
+	for i := 0; i < joinCodeAttempts; i++ {
+		code, err := newJoinCode()
+		if err != nil {
+			return "", err
+		}
+		ok, err := codes.Reserve(ctx, code, sessionID, ttl)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return code, nil
+		}
+	}
+	return "", errJoinCodeExhausted
+}
+
+func newJoinCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := 0; i < joinCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(joinCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeJoinCode accepts codes typed in lower case or with separators ("abc-234").
+func normalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
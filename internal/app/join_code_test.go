@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type takenCodes struct {
+	taken int // reservations to refuse before accepting
+	calls int
+}
+
+func (c *takenCodes) Reserve(context.Context, string, string, time.Duration) (bool, error) {
+	c.calls++
+	return c.calls > c.taken, nil
+}
+
+func (c *takenCodes) Resolve(context.Context, string) (string, error) {
+	return "", nil
+}
+
+func TestReserveJoinCodeRetriesCollisions(t *testing.T) {
+	codes := &takenCodes{taken: 3}
+	if _, err := reserveJoinCode(context.Background(), codes, "s1", time.Minute); err != nil {
+		t.Fatalf("expected a code after collisions, got %v", err)
+	}
+	if codes.calls != 4 {
+		t.Fatalf("expected 4 attempts, got %d", codes.calls)
+	}
+
+	if _, err := reserveJoinCode(context.Background(), &takenCodes{taken: joinCodeAttempts}, "s1", time.Minute); err != errJoinCodeExhausted {
+		t.Fatalf("expected exhaustion error, got %v", err)
+	}
+}
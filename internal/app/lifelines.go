@@ -0,0 +1,122 @@
+package app
+
+import (
+	"slices"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// EventReveal is the broadcast type carrying a domain.Reveal when a question closes.
+const EventReveal = "reveal"
+
+// lifelineUse is what one participant used on one question.
+type lifelineUse struct {
+	removed []string // options taken away by a 50:50
+	hints   int      // hints handed out so far
+	cost    int      // points the next answer to the question loses
+}
+
+// useLifeline hands a participant a 50:50 or their next hint for a question they can still
+// answer. The cost is charged when they answer, by recordAnswer.
+func (s *Session) useLifeline(userID string, question domain.Question, lifeline string) (domain.LifelineResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.attemptLocked(userID, question); err != nil {
+		return domain.LifelineResult{}, err
+	}
+	uses := s.lifelines[userID]
+	if uses == nil {
+		uses = make(map[string]*lifelineUse)
+		s.lifelines[userID] = uses
+	}
+	use := uses[question.ID]
+	if use == nil {
+		use = &lifelineUse{}
+	}
+
+	result := domain.LifelineResult{QuestionID: question.ID, Lifeline: lifeline}
+	switch lifeline {
+	case domain.LifelineFiftyFifty:
+		if use.removed != nil {
+			return domain.LifelineResult{}, domain.ErrLifelineUsed
+		}
+		used := 0
+		for _, u := range uses {
+			if u.removed != nil {
+				used++
+			}
+		}
+		removed := fiftyFiftyRemoval(s.id, userID, question)
+		if !question.Graded() || used >= s.settings.FiftyFifty || removed == nil {
+			return domain.LifelineResult{}, domain.ErrLifelineUnavailable
+		}
+		use.removed = removed
+		if !slices.Contains(s.fiftyFifties[userID], question.ID) {
+			s.fiftyFifties[userID] = append(s.fiftyFifties[userID], question.ID)
+		}
+		result.RemovedOptionIDs = use.removed
+		result.Cost = s.settings.FiftyFiftyCost
+		result.Remaining = s.settings.FiftyFifty - used - 1
+	case domain.LifelineHint:
+		if use.hints >= len(question.Hints) {
+			return domain.LifelineResult{}, domain.ErrLifelineUnavailable
+		}
+		hint := question.Hints[use.hints]
+		use.hints++
+		result.Hint = hint.Text
+		result.Cost = hint.Cost
+		result.Remaining = len(question.Hints) - use.hints
+	default:
+		return domain.LifelineResult{}, domain.ErrLifelineUnavailable
+	}
+	use.cost += result.Cost
+	uses[question.ID] = use
+	return result, nil
+}
+
+// fiftyFiftyRemoval picks the two wrong options a 50:50 takes away, or nil if the question has
+// fewer. It is seeded like the option order, so a participant always loses the same options and
+// Layout can rebuild them from the questions alone.
+func fiftyFiftyRemoval(sessionID, userID string, question domain.Question) []string {
+	var wrong []string
+	for _, option := range question.Options {
+		if !option.Correct {
+			wrong = append(wrong, option.ID)
+		}
+	}
+	if len(wrong) < 2 {
+		return nil
+	}
+	return shuffled(wrong, shuffleSeed(sessionID, userID, question.ID))[:2]
+}
+
+// fiftyFiftyQuestions returns the questions a participant used a 50:50 on, even after they left.
+func (s *Session) fiftyFiftyQuestions(userID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.fiftyFifties[userID])
+}
+
+// lifelineCostLocked is what the participant's lifelines on a question cost.
+func (s *Session) lifelineCostLocked(userID, questionID string) int {
+	if use := s.lifelines[userID][questionID]; use != nil {
+		return use.cost
+	}
+	return 0
+}
+
+// revealLocked tells everyone in the session the right answer and the explanation.
+func (s *Session) revealLocked(question domain.Question) {
+	reveal := domain.Reveal{QuestionID: question.ID, Explanation: question.Explanation}
+	if question.Graded() {
+		for _, option := range question.Options {
+			if option.Correct {
+				reveal.CorrectOptionIDs = append(reveal.CorrectOptionIDs, option.ID)
+			}
+		}
+	}
+	b := newBroadcast(EventReveal, reveal)
+	for sub := range s.subscribers {
+		s.deliverLocked(sub, b)
+	}
+}
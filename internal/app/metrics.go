@@ -0,0 +1,25 @@
+package app
+
+import "sync/atomic"
+
+// BroadcastStats is a point-in-time copy of the broadcast counters.
+type BroadcastStats struct {
+	Broadcasts int64 `json:"broadcasts"`
+	Coalesced  int64 `json:"coalesced"`
+	Dropped    int64 `json:"dropped"`
+}
+
+// broadcastMetrics counts fan-out work across all sessions of a service.
+type broadcastMetrics struct {
+	broadcasts atomic.Int64 // snapshots fanned out to subscribers
+	coalesced  atomic.Int64 // updates folded into an already scheduled broadcast
+	dropped    atomic.Int64 // stale broadcasts evicted from a slow subscriber's buffer
+}
+
+func (m *broadcastMetrics) snapshot() BroadcastStats {
+	return BroadcastStats{
+		Broadcasts: m.broadcasts.Load(),
+		Coalesced:  m.coalesced.Load(),
+		Dropped:    m.dropped.Load(),
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// remove kicks a participant (or a player in the waiting room), optionally banning them from
+// rejoining. Bans may also be issued up front for users who aren't in the session.
+func (s *Session) remove(userID, reason string, ban bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, present := s.participants[userID]
+	waiting := s.waitingIndexLocked(userID)
+	if !present && waiting < 0 && !ban {
+		return domain.ErrParticipantNotFound
+	}
+	action := domain.ModerationKick
+	if ban {
+		s.bans[userID] = reason
+		action = domain.ModerationBan
+	}
+	if waiting >= 0 {
+		w := s.waiting[waiting]
+		s.removeWaiterLocked(waiting)
+		s.sendWaiterLocked(w, domain.WaitingStatus{SessionID: s.id, Waiting: len(s.waiting), Denied: true})
+		s.closeWaiterLocked(w)
+		s.notifyWaitingLocked(waiting)
+	}
+	s.disconnectLocked(userID, domain.Removal{Reason: reason, Banned: ban})
+	s.leaveLocked(userID)
+	s.admitWaitingLocked()
+	s.auditLocked(domain.ModerationAction{Action: action, UserID: userID, Note: reason})
+	return nil
+}
+
+// disconnectLocked sends userID's subscriptions a final removal broadcast and closes them.
+func (s *Session) disconnectLocked(userID string, removal domain.Removal) {
+	for sub := range s.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		s.deliverLocked(sub, newBroadcast(EventRemoved, removal))
+		delete(s.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// rename forces a display name on a participant; it sticks when they rejoin. The name has
+// already been cleaned by the service's NamePolicy.
+func (s *Session) rename(userID, displayName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant, ok := s.participants[userID]
+	if !ok {
+		return domain.ErrParticipantNotFound
+	}
+	displayName = s.uniqueNameLocked(userID, displayName)
+	s.forcedNames[userID] = displayName
+	s.setNameLocked(participant, displayName)
+	s.ranking.upsert(participant)
+	s.dirty[userID] = struct{}{}
+	s.scheduleBroadcastLocked()
+	s.auditLocked(domain.ModerationAction{Action: domain.ModerationRename, UserID: userID, DisplayName: displayName})
+	return nil
+}
+
+// adjustScore adds delta to a participant's score, or resets it to zero, recording why.
+func (s *Session) adjustScore(userID string, delta int, reset bool, note string) (int, error) {
+	if strings.TrimSpace(note) == "" {
+		return 0, domain.ErrAuditNoteRequired
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant, ok := s.participants[userID]
+	if !ok {
+		return 0, domain.ErrParticipantNotFound
+	}
+	action := domain.ModerationAdjustScore
+	if reset {
+		delta = -participant.Score
+		action = domain.ModerationResetScore
+	}
+	s.addScoreLocked(participant, delta)
+	s.auditLocked(domain.ModerationAction{Action: action, UserID: userID, Note: note, Delta: delta, Score: participant.Score})
+	return participant.Score, nil
+}
+
+func (s *Session) auditLocked(action domain.ModerationAction) {
+	action.At = s.now()
+	s.audit = append(s.audit, action)
+}
+
+// auditLog returns the host actions taken so far, oldest first.
+func (s *Session) auditLog() []domain.ModerationAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]domain.ModerationAction(nil), s.audit...)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+const (
+	// maxWordCloudText bounds a word cloud response, in characters.
+	maxWordCloudText = 80
+	// maxWordCloudWords caps the words listed in a word cloud's results.
+	maxWordCloudWords = 50
+)
+
+// pollResponse is an answer to an ungraded question, reduced to what its results count.
+type pollResponse struct {
+	optionIDs []string
+	rating    int
+	words     []string
+}
+
+// newPollResponse checks a submission against an ungraded question's type.
+func newPollResponse(question domain.Question, submission domain.AnswerSubmission) (pollResponse, error) {
+	hasOption := func(id string) bool {
+		return slices.ContainsFunc(question.Options, func(o domain.Option) bool { return o.ID == id })
+	}
+	switch question.Type {
+	case domain.QuestionPoll:
+		if !hasOption(submission.OptionID) {
+			return pollResponse{}, domain.ErrOptionNotFound
+		}
+		return pollResponse{optionIDs: []string{submission.OptionID}}, nil
+	case domain.QuestionMultiPoll:
+		if len(submission.OptionIDs) == 0 {
+			return pollResponse{}, domain.ErrInvalidResponse
+		}
+		var picked []string
+		for _, id := range submission.OptionIDs {
+			if !hasOption(id) {
+				return pollResponse{}, domain.ErrOptionNotFound
+			}
+			if !slices.Contains(picked, id) {
+				picked = append(picked, id)
+			}
+		}
+		return pollResponse{optionIDs: picked}, nil
+	case domain.QuestionRating:
+		low, high := question.RatingBounds()
+		if submission.Rating == nil || *submission.Rating < low || *submission.Rating > high {
+			return pollResponse{}, domain.ErrInvalidResponse
+		}
+		return pollResponse{rating: *submission.Rating}, nil
+	case domain.QuestionWordCloud:
+		words := cloudWords(submission.Text)
+		if len(words) == 0 || utf8.RuneCountInString(submission.Text) > maxWordCloudText {
+			return pollResponse{}, domain.ErrInvalidResponse
+		}
+		return pollResponse{words: words}, nil
+	}
+	return pollResponse{}, domain.ErrInvalidResponse
+}
+
+// cloudWords lower-cases text and splits it into distinct words, so one response counts each word once.
+func cloudWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	}) {
+		if word = strings.Trim(word, "'-"); word != "" && !slices.Contains(words, word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// recordResponse stores an answer to an ungraded question. It goes through the same time limit
+// and answer-change rules as graded answers but never changes a score.
+func (s *Session) recordResponse(userID string, question domain.Question, response pollResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, err := s.attemptLocked(userID, question)
+	if err != nil {
+		return err
+	}
+	s.storeAnswerLocked(userID, question.ID, at, answer{poll: response})
+	s.scheduleStatsLocked()
+	return nil
+}
+
+// scheduleStatsLocked publishes changed results without a leaderboard broadcast, since responses
+// don't move anyone. It respects the broadcast window, and a pending leaderboard flush carries
+// the results anyway.
+func (s *Session) scheduleStatsLocked() {
+	if s.flushPending || s.statsPending {
+		return
+	}
+	if s.window <= 0 {
+		s.broadcastStatsLocked()
+		return
+	}
+	s.statsPending = true
+	time.AfterFunc(s.window, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.statsPending = false
+		s.broadcastStatsLocked()
+	})
+}
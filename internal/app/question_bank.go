@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// QuestionBankRepository loads the question banks quizzes draw from.
+type QuestionBankRepository interface {
+	// GetBank returns a bank or domain.ErrBankNotFound.
+	GetBank(ctx context.Context, bankID string) (domain.QuestionBank, error)
+}
+
+// newSeed picks a non-zero seed, since zero asks for a random one.
+func newSeed() int64 {
+	for {
+		if seed := rand.Int64(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// drawQuestions resolves a quiz's draws into its questions for one session: the quiz's own
+// questions first, then each draw in order. The same seed and banks always give the same list.
+func drawQuestions(ctx context.Context, banks QuestionBankRepository, quiz domain.Quiz, seed int64) ([]domain.Question, error) {
+	if banks == nil {
+		return nil, domain.ErrBankNotFound
+	}
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+	questions := slices.Clone(quiz.Questions)
+	taken := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		taken[q.ID] = true
+	}
+	loaded := make(map[string]domain.QuestionBank)
+	for _, draw := range quiz.Draws {
+		bank, ok := loaded[draw.Bank]
+		if !ok {
+			var err error
+			if bank, err = banks.GetBank(ctx, draw.Bank); err != nil {
+				return nil, err
+			}
+			loaded[draw.Bank] = bank
+		}
+		var pool []domain.Question
+		for _, q := range bank.Questions {
+			if !taken[q.ID] && matchesDraw(q, draw) {
+				pool = append(pool, q)
+			}
+		}
+		if len(pool) < draw.Count {
+			return nil, fmt.Errorf("%w: %q has %d matching questions, %d wanted", domain.ErrNotEnoughQuestions, draw.Bank, len(pool), draw.Count)
+		}
+		rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		for _, q := range pool[:draw.Count] {
+			taken[q.ID] = true
+			questions = append(questions, q)
+		}
+	}
+	return questions, nil
+}
+
+func matchesDraw(q domain.Question, draw domain.QuestionDraw) bool {
+	if draw.Difficulty != "" && q.Difficulty != draw.Difficulty {
+		return false
+	}
+	for _, tag := range draw.Tags {
+		if !slices.Contains(q.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
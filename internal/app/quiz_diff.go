@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// DiffQuiz lists what changed in a quiz between two of its versions: questions added and
+// removed, each changed question field, and changes to the quiz's draws, settings and question order.
+func (s *QuizService) DiffQuiz(ctx context.Context, quizID string, from, to int) (domain.QuizDiff, error) {
+	before, err := s.quizzes.GetQuizVersion(ctx, quizID, from)
+	if err != nil {
+		return domain.QuizDiff{}, err
+	}
+	after, err := s.quizzes.GetQuizVersion(ctx, quizID, to)
+	if err != nil {
+		return domain.QuizDiff{}, err
+	}
+	return domain.QuizDiff{QuizID: quizID, From: from, To: to, Changes: diffQuizzes(before, after)}, nil
+}
+
+// diffQuizzes compares two versions of a quiz. Quiz-wide changes come first, then questions in
+// their new order, then the removed ones in their old order.
+func diffQuizzes(before, after domain.Quiz) []domain.QuizChange {
+	changes := []domain.QuizChange{}
+	if !reflect.DeepEqual(before.Draws, after.Draws) {
+		changes = append(changes, changed("", "draws", before.Draws, after.Draws))
+	}
+	if !reflect.DeepEqual(before.Settings, after.Settings) {
+		changes = append(changes, changed("", "settings", before.Settings, after.Settings))
+	}
+
+	old := make(map[string]domain.Question, len(before.Questions))
+	for _, q := range before.Questions {
+		old[q.ID] = q
+	}
+	kept := make(map[string]bool, len(after.Questions))
+	var questions []domain.QuizChange
+	var newOrder []string
+	for _, q := range after.Questions {
+		prev, ok := old[q.ID]
+		if !ok {
+			questions = append(questions, domain.QuizChange{Kind: domain.ChangeAdded, Question: q.ID, After: q})
+			continue
+		}
+		kept[q.ID] = true
+		newOrder = append(newOrder, q.ID)
+		questions = append(questions, fieldChanges(prev, q)...)
+	}
+	// Only the questions in both versions count towards the order, so additions and removals
+	// don't show up twice.
+	var oldOrder, removed []string
+	for _, q := range before.Questions {
+		if kept[q.ID] {
+			oldOrder = append(oldOrder, q.ID)
+		} else {
+			removed = append(removed, q.ID)
+		}
+	}
+	if !slices.Equal(oldOrder, newOrder) {
+		changes = append(changes, changed("", "order", oldOrder, newOrder))
+	}
+	changes = append(changes, questions...)
+	for _, id := range removed {
+		changes = append(changes, domain.QuizChange{Kind: domain.ChangeRemoved, Question: id, Before: old[id]})
+	}
+	return changes
+}
+
+// fieldChanges compares two versions of a question field by field, by their JSON names.
+func fieldChanges(before, after domain.Question) []domain.QuizChange {
+	was, now := fields(before), fields(after)
+	names := make([]string, 0, len(was)+len(now))
+	for name := range was {
+		names = append(names, name)
+	}
+	for name := range now {
+		if _, ok := was[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var changes []domain.QuizChange
+	for _, name := range names {
+		if !reflect.DeepEqual(was[name], now[name]) {
+			changes = append(changes, changed(after.ID, name, was[name], now[name]))
+		}
+	}
+	return changes
+}
+
+// fields maps a question's JSON field names to their values; empty fields are left out.
+func fields(q domain.Question) map[string]any {
+	raw, _ := json.Marshal(q)
+	var m map[string]any
+	_ = json.Unmarshal(raw, &m)
+	return m
+}
+
+func changed(question, field string, before, after any) domain.QuizChange {
+	return domain.QuizChange{Kind: domain.ChangeChanged, Question: question, Field: field, Before: before, After: after}
+}
@@ -2,270 +2,698 @@ package app
 
 import (
 	"context"
-	"sort"
-	"sync"
+	"fmt"
+	"slices"
 	"time"
 
 	"elsa-quiz-service/internal/domain"
 )
 
 // SessionRepository abstracts how quiz sessions are stored (in-memory, Redis, etc).
+// Sessions are keyed by session ID, which is independent of the quiz they run.
 type SessionRepository interface {
-	GetOrCreate(quizID string) *Session
-	Get(quizID string) (*Session, bool)
-	DeleteIfEmpty(quizID string)
+	// Create registers a host-created session that lives until expiresAt.
+	Create(sessionID, quizID string, expiresAt time.Time) *Session
+	Get(sessionID string) (*Session, bool)
+	// DeleteIfEmpty drops the session once Session.Idle reports true.
+	DeleteIfEmpty(sessionID string)
+}
+
+// JoinCodeRepository maps short join codes to session IDs.
+type JoinCodeRepository interface {
+	// Reserve claims code for sessionID until ttl elapses; it reports false if the code is taken.
+	Reserve(ctx context.Context, code, sessionID string, ttl time.Duration) (bool, error)
+	// Resolve returns the session behind code, or domain.ErrJoinCodeNotFound.
+	Resolve(ctx context.Context, code string) (string, error)
 }
 
 // QuizRepository loads quiz content (from cache/backing store).
 type QuizRepository interface {
+	// GetQuiz returns the published version of a quiz.
 	GetQuiz(ctx context.Context, quizID string) (domain.Quiz, error)
+	// GetQuizVersion returns one version of a quiz, or domain.ErrQuizVersionNotFound.
+	GetQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error)
 }
 
 // QuizService contains the core quiz use cases.
 type QuizService struct {
-	sessions SessionRepository
-	quizzes  QuizRepository
+	sessions        SessionRepository
+	quizzes         QuizRepository
+	banks           QuestionBankRepository
+	answerLog       AnswerRepository
+	answers         *answerWriter // writes to answerLog in the background
+	joinCodes       JoinCodeRepository
+	joinCodeTTL     time.Duration
+	broadcastWindow time.Duration
+	maxParticipants int
+	names           *NamePolicy
+	metrics         *broadcastMetrics
+}
+
+// Option customizes a QuizService.
+type Option func(*QuizService)
+
+// WithBroadcastWindow sets the default leaderboard coalescing window for quizzes that don't set their own.
+func WithBroadcastWindow(window time.Duration) Option {
+	return func(s *QuizService) {
+		s.broadcastWindow = window
+	}
+}
+
+// WithNamePolicy replaces the default display name rules, e.g. to add profanity lists.
+func WithNamePolicy(policy *NamePolicy) Option {
+	return func(s *QuizService) {
+		s.names = policy
+	}
+}
+
+// WithMaxParticipants caps every session at n participants; hosts may only pick a lower limit.
+func WithMaxParticipants(n int) Option {
+	return func(s *QuizService) {
+		s.maxParticipants = n
+	}
+}
+
+// WithJoinCodes gives created sessions join codes that expire after ttl; sessions also expire then.
+func WithJoinCodes(codes JoinCodeRepository, ttl time.Duration) Option {
+	return func(s *QuizService) {
+		s.joinCodes = codes
+		s.joinCodeTTL = ttl
+	}
+}
+
+// WithQuestionBanks lets quizzes draw their questions from question banks.
+func WithQuestionBanks(banks QuestionBankRepository) Option {
+	return func(s *QuizService) {
+		s.banks = banks
+	}
+}
+
+// WithAnswerLog keeps every session's graded answers for item analytics. Answers are written in
+// the background; Close waits for the pending ones.
+func WithAnswerLog(answers AnswerRepository) Option {
+	return func(s *QuizService) {
+		s.answerLog = answers
+	}
+}
+
+func NewQuizService(store SessionRepository, quizzes QuizRepository, opts ...Option) *QuizService {
+	s := &QuizService{sessions: store, quizzes: quizzes, joinCodeTTL: defaultJoinCodeTTL, metrics: &broadcastMetrics{},
+		names: NewNamePolicy(DefaultMinNameLength, DefaultMaxNameLength)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.answerLog != nil {
+		s.answers = newAnswerWriter(s.answerLog, answerQueueSize)
+	}
+	return s
+}
+
+// Close waits for answers still being written to the answer log. The service takes no answers after it.
+func (s *QuizService) Close() {
+	if s.answers != nil {
+		s.answers.close()
+	}
 }
 
-func NewQuizService(store SessionRepository, quizzes QuizRepository) *QuizService {
-	return &QuizService{sessions: store, quizzes: quizzes}
+// BroadcastStats reports how many leaderboard broadcasts were sent, coalesced or dropped.
+func (s *QuizService) BroadcastStats() BroadcastStats {
+	return s.metrics.snapshot()
 }
 
 // NewSession is exported for infrastructure layers that need to seed sessions.
-func NewSession(id string) *Session {
-	return newSession(id)
+func NewSession(id, quizID string) *Session {
+	return newSession(id, quizID)
 }
 
 // NewSessionWithClock is test-only for deterministic timestamps.
-func NewSessionWithClock(id string, now func() time.Time) *Session {
-	return newSessionWithClock(id, now)
+func NewSessionWithClock(id, quizID string, now func() time.Time) *Session {
+	return newSessionWithClock(id, quizID, now)
 }
 
-// Join registers or refreshes a participant in a quiz session.
-func (s *QuizService) Join(ctx context.Context, quizID, userID, displayName string) (domain.Leaderboard, error) {
-	// Preload quiz into cache; users cannot join unknown quizzes.
-	if _, err := s.quizzes.GetQuiz(ctx, quizID); err != nil {
-		return domain.Leaderboard{}, err
+// CreateSession starts a live session of a quiz with the host's settings; unset settings fall
+// back to the quiz's own. The returned host token authorizes host-only actions on the session.
+// A short join code is reserved when join codes are enabled. Quizzes that use question banks get
+// their questions drawn here, with the seed recorded in the returned settings. The session is
+// pinned to the quiz version published now, so publishing a new one mid-session changes nothing.
+func (s *QuizService) CreateSession(ctx context.Context, quizID string, settings domain.SessionSettings) (domain.SessionInfo, error) {
+	if err := settings.Validate(); err != nil {
+		return domain.SessionInfo{}, err
+	}
+	quiz, err := s.quizzes.GetQuiz(ctx, quizID)
+	if err != nil {
+		return domain.SessionInfo{}, err
+	}
+	settings = settings.WithDefaults(quiz.Settings)
+	if s.maxParticipants > 0 && (settings.MaxParticipants == 0 || settings.MaxParticipants > s.maxParticipants) {
+		settings.MaxParticipants = s.maxParticipants
+	}
+	var drawn []domain.Question
+	if len(quiz.Draws) > 0 {
+		if settings.Seed == 0 {
+			settings.Seed = newSeed()
+		}
+		if drawn, err = drawQuestions(ctx, s.banks, quiz, settings.Seed); err != nil {
+			return domain.SessionInfo{}, err
+		}
 	}
 
-	session := s.sessions.GetOrCreate(quizID)
-	return session.join(userID, displayName), nil
+	sessionID, err := newSessionID()
+	if err != nil {
+		return domain.SessionInfo{}, err
+	}
+	hostToken, err := newSessionID()
+	if err != nil {
+		return domain.SessionInfo{}, err
+	}
+	// Self-paced join codes last until the deadline; the session stays another join code TTL
+	// after it, so the host can still read the final leaderboard.
+	ttl := s.joinCodeTTL
+	if settings.SelfPaced() {
+		if settings.Deadline == nil {
+			deadline := time.Now().Add(ttl)
+			settings.Deadline = &deadline
+		}
+		if ttl = time.Until(*settings.Deadline); ttl <= 0 {
+			return domain.SessionInfo{}, fmt.Errorf("%w: deadline has passed", domain.ErrInvalidSettings)
+		}
+	}
+	var code string
+	if s.joinCodes != nil {
+		if code, err = reserveJoinCode(ctx, s.joinCodes, sessionID, ttl); err != nil {
+			return domain.SessionInfo{}, err
+		}
+	}
+	expiresAt := time.Now().Add(ttl)
+	if settings.Deadline != nil {
+		expiresAt = settings.Deadline.Add(s.joinCodeTTL)
+	}
+	session := s.sessions.Create(sessionID, quizID, expiresAt)
+	session.configure(settings, hostToken, s.broadcastWindow, s.metrics, s.names.MaxLength)
+	session.pinVersion(quiz.Version)
+	info := domain.SessionInfo{
+		SessionID:   sessionID,
+		QuizID:      quizID,
+		JoinCode:    code,
+		HostToken:   hostToken,
+		ExpiresAt:   expiresAt,
+		Settings:    settings,
+		QuizVersion: quiz.Version,
+	}
+	if drawn != nil {
+		session.useQuestions(drawn)
+		for _, q := range drawn {
+			info.QuestionIDs = append(info.QuestionIDs, q.ID)
+		}
+	}
+	return info, nil
 }
 
-// SubmitAnswer records an answer for a participant and updates the leaderboard.
-func (s *QuizService) SubmitAnswer(ctx context.Context, quizID, userID string, submission domain.AnswerSubmission) (domain.Leaderboard, int, int, bool, error) {
-	session, ok := s.sessions.Get(quizID)
-	if !ok {
-		return domain.Leaderboard{}, 0, 0, false, domain.ErrSessionNotFound
+// OpenQuestion starts the question's time limit and marks the session as started.
+func (s *QuizService) OpenQuestion(ctx context.Context, sessionID, hostToken, questionID string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
 	}
+	question, err := s.question(ctx, session, questionID)
+	if err != nil {
+		return err
+	}
+	session.openQuestion(question)
+	return nil
+}
 
-	quiz, err := s.quizzes.GetQuiz(ctx, quizID)
+// CloseQuestion stops accepting answers to a question and publishes its final answer stats.
+func (s *QuizService) CloseQuestion(ctx context.Context, sessionID, hostToken, questionID string) error {
+	session, err := s.hostSession(sessionID, hostToken)
 	if err != nil {
-		return domain.Leaderboard{}, 0, 0, false, err
+		return err
 	}
+	question, err := s.question(ctx, session, questionID)
+	if err != nil {
+		return err
+	}
+	session.closeQuestion(question)
+	return nil
+}
 
-	correct, points, err := scoreSubmission(quiz, submission)
+// AnswerStats returns the live answer distribution for a question.
+func (s *QuizService) AnswerStats(ctx context.Context, sessionID, hostToken, questionID string) (domain.AnswerStats, error) {
+	session, err := s.hostSession(sessionID, hostToken)
 	if err != nil {
-		return domain.Leaderboard{}, 0, 0, false, err
+		return domain.AnswerStats{}, err
 	}
+	question, err := s.question(ctx, session, questionID)
+	if err != nil {
+		return domain.AnswerStats{}, err
+	}
+	return session.answerStats(question), nil
+}
 
-	lb, total, err := session.applyScore(userID, correct, points)
-	awarded := 0
-	if correct {
-		if points > 0 {
-			awarded = points
-		} else {
-			awarded = 1
-		}
+// quiz loads the quiz version a session runs, with the questions drawn for the session if it
+// has any. Sessions of unversioned quizzes run whatever the source serves.
+func (s *QuizService) quiz(ctx context.Context, session *Session) (domain.Quiz, error) {
+	var quiz domain.Quiz
+	var err error
+	if version := session.QuizVersion(); version > 0 {
+		quiz, err = s.quizzes.GetQuizVersion(ctx, session.QuizID(), version)
+	} else {
+		quiz, err = s.quizzes.GetQuiz(ctx, session.QuizID())
+	}
+	if err != nil {
+		return domain.Quiz{}, err
+	}
+	if drawn := session.drawnQuestions(); drawn != nil {
+		quiz.Questions = drawn
 	}
-	return lb, total, awarded, correct, err
+	return quiz, nil
 }
 
-// Subscribe returns a channel that receives leaderboard updates for a quiz.
-// The caller must invoke the returned cancel function to avoid leaks.
-func (s *QuizService) Subscribe(_ context.Context, quizID string) (<-chan domain.Leaderboard, func(), error) {
-	session, ok := s.sessions.Get(quizID)
-	if !ok {
-		return nil, nil, domain.ErrSessionNotFound
+// question looks up one of the session's quiz questions.
+func (s *QuizService) question(ctx context.Context, session *Session, questionID string) (domain.Question, error) {
+	quiz, err := s.quiz(ctx, session)
+	if err != nil {
+		return domain.Question{}, err
+	}
+	i := slices.IndexFunc(quiz.Questions, func(q domain.Question) bool { return q.ID == questionID })
+	if i < 0 {
+		return domain.Question{}, domain.ErrQuestionNotFound
 	}
-	ch, cancel := session.subscribe()
-	return ch, cancel, nil
+	return quiz.Questions[i], nil
 }
 
-// Leave removes a participant from the session and drops the session if empty.
-func (s *QuizService) Leave(_ context.Context, quizID, userID string) {
-	session, ok := s.sessions.Get(quizID)
-	if !ok {
-		return
+// HostLeaderboard returns the full leaderboard regardless of the session's visibility setting.
+func (s *QuizService) HostLeaderboard(_ context.Context, sessionID, hostToken string) (domain.Leaderboard, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return domain.Leaderboard{}, err
 	}
-	session.leave(userID)
-	if session.isEmpty() {
-		s.sessions.DeleteIfEmpty(quizID)
+	return session.leaderboard(), nil
+}
+
+// AuthorizeHost checks hostToken against the session's, for transports that open host connections.
+func (s *QuizService) AuthorizeHost(_ context.Context, sessionID, hostToken string) error {
+	_, err := s.hostSession(sessionID, hostToken)
+	return err
+}
+
+// Kick removes a participant (or a waiting player) and closes their connections with reason.
+// They may rejoin.
+func (s *QuizService) Kick(_ context.Context, sessionID, hostToken, userID, reason string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
 	}
+	return session.remove(userID, reason, false)
 }
 
-// Session is an in-memory representation of a quiz.
-type Session struct {
-	id           string
-	createdAt    time.Time
-	now          func() time.Time
-	mu           sync.RWMutex
-	participants map[string]*domain.Participant
-	subscribers  map[chan domain.Leaderboard]struct{}
+// Ban is Kick that also keeps userID out of the session for the rest of its life.
+func (s *QuizService) Ban(_ context.Context, sessionID, hostToken, userID, reason string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	return session.remove(userID, reason, true)
 }
 
-func newSession(id string) *Session {
-	return newSessionWithClock(id, time.Now)
+// Rename replaces a participant's display name; the new name sticks if they rejoin.
+func (s *QuizService) Rename(_ context.Context, sessionID, hostToken, userID, displayName string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	displayName, err = s.names.Clean(displayName, session.Settings().Locale)
+	if err != nil {
+		return err
+	}
+	return session.rename(userID, displayName)
 }
 
-// newSessionWithClock allows deterministic timestamps in tests.
-func newSessionWithClock(id string, now func() time.Time) *Session {
-	return &Session{
-		id:           id,
-		createdAt:    now(),
-		now:          now,
-		participants: make(map[string]*domain.Participant),
-		subscribers:  make(map[chan domain.Leaderboard]struct{}),
+// AdjustScore adds delta (which may be negative) to a participant's score and returns the new total.
+// note is required and kept in the audit log.
+func (s *QuizService) AdjustScore(_ context.Context, sessionID, hostToken, userID string, delta int, note string) (int, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return 0, err
 	}
+	return session.adjustScore(userID, delta, false, note)
 }
 
-func (s *Session) join(userID, displayName string) domain.Leaderboard {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// ResetScore sets a participant's score back to zero; note is required and kept in the audit log.
+func (s *QuizService) ResetScore(_ context.Context, sessionID, hostToken, userID, note string) (int, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return 0, err
+	}
+	return session.adjustScore(userID, 0, true, note)
+}
 
-	now := s.now()
-	if participant, ok := s.participants[userID]; ok {
-		participant.DisplayName = displayName
-		participant.LastUpdated = now
-	} else {
-		s.participants[userID] = &domain.Participant{
-			UserID:      userID,
-			DisplayName: displayName,
-			Score:       0,
-			LastUpdated: now,
-		}
+// AuditLog returns the moderation actions taken in a session, oldest first.
+func (s *QuizService) AuditLog(_ context.Context, sessionID, hostToken string) ([]domain.ModerationAction, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return nil, err
+	}
+	return session.auditLog(), nil
+}
+
+// hostSession finds a session and checks the caller holds its host token.
+func (s *QuizService) hostSession(sessionID, hostToken string) (*Session, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	if err := session.authorizeHost(hostToken); err != nil {
+		return nil, err
 	}
-	return s.broadcastLocked()
+	return session, nil
 }
 
-func (s *Session) applyScore(userID string, correct bool, points int) (domain.Leaderboard, int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// ResolveJoinCode returns the session ID a join code points at.
+func (s *QuizService) ResolveJoinCode(ctx context.Context, code string) (string, error) {
+	if s.joinCodes == nil {
+		return "", domain.ErrJoinCodeNotFound
+	}
+	return s.joinCodes.Resolve(ctx, normalizeJoinCode(code))
+}
 
-	now := s.now()
-	participant, ok := s.participants[userID]
+// Join registers or refreshes a participant in a quiz session.
+func (s *QuizService) Join(ctx context.Context, sessionID, userID, displayName string) (domain.Leaderboard, error) {
+	return s.JoinTeam(ctx, sessionID, userID, displayName, "")
+}
+
+// Enter is JoinTeam for sessions with a waiting room: when the session is full, or moderated,
+// the player is queued and the returned Waiter reports their position until they are admitted
+// or denied. A nil Waiter means the player joined straight away.
+func (s *QuizService) Enter(_ context.Context, sessionID, userID, displayName, team string) (*Waiter, error) {
+	session, ok := s.sessions.Get(sessionID)
 	if !ok {
-		return domain.Leaderboard{}, 0, domain.ErrParticipantNotFound
+		return nil, domain.ErrSessionNotFound
+	}
+	displayName, err := s.names.Clean(displayName, session.Settings().Locale)
+	if err != nil {
+		return nil, err
 	}
+	return session.enter(userID, displayName, team)
+}
 
-	if correct && points > 0 {
-		participant.Score += points
-	} else if correct && points == 0 {
-		participant.Score++
+// WaitingRoom lists the players waiting to get into a session, in order.
+func (s *QuizService) WaitingRoom(_ context.Context, sessionID, hostToken string) ([]domain.WaitingEntry, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return nil, err
 	}
-	participant.LastUpdated = now
+	return session.waitingRoom(), nil
+}
 
-	return s.broadcastLocked(), participant.Score, nil
+// Admit lets a waiting player into the session, if there is room for them.
+func (s *QuizService) Admit(_ context.Context, sessionID, hostToken, userID string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	return session.admit(userID)
 }
 
-func (s *Session) leave(userID string) domain.Leaderboard {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.participants, userID)
-	return s.broadcastLocked()
+// Deny turns a waiting player away.
+func (s *QuizService) Deny(_ context.Context, sessionID, hostToken, userID string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	return session.deny(userID)
 }
 
-func (s *Session) isEmpty() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.participants) == 0
+// JoinTeam is Join for team quizzes: team picks one of the session's teams, or is left empty
+// to keep a returning player's team or auto-assign a new one.
+// Only sessions created with CreateSession can be joined, and JoinTeam never waits: a full or
+// moderated session returns domain.ErrSessionFull or domain.ErrAdmissionRequired.
+func (s *QuizService) JoinTeam(_ context.Context, sessionID, userID, displayName, team string) (domain.Leaderboard, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.Leaderboard{}, domain.ErrSessionNotFound
+	}
+	displayName, err := s.names.Clean(displayName, session.Settings().Locale)
+	if err != nil {
+		return domain.Leaderboard{}, err
+	}
+	if err := session.join(userID, displayName, team); err != nil {
+		return domain.Leaderboard{}, err
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.playerViewLocked(userID, domain.LeaderboardView{}), nil
 }
 
-// IsEmpty reports whether the session has no participants.
-func (s *Session) IsEmpty() bool {
-	return s.isEmpty()
+// AssignTeam lets a host move a participant onto a team.
+func (s *QuizService) AssignTeam(_ context.Context, sessionID, hostToken, userID, team string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	return session.assignTeam(userID, team)
 }
 
-func (s *Session) subscribe() (<-chan domain.Leaderboard, func()) {
-	ch := make(chan domain.Leaderboard, 8)
+// BalanceTeams evens out team sizes for hosts who don't want to pick teams by hand.
+func (s *QuizService) BalanceTeams(_ context.Context, sessionID, hostToken string) error {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return err
+	}
+	return session.balanceTeams()
+}
 
-	s.mu.Lock()
-	s.subscribers[ch] = struct{}{}
-	initial := s.snapshotLocked()
-	s.mu.Unlock()
+// SubmitAnswer records an answer to a graded question and updates the leaderboard, applying the
+// session's time limit, answer-change and scoring settings. Polls go through SubmitResponse.
+func (s *QuizService) SubmitAnswer(ctx context.Context, sessionID, userID string, submission domain.AnswerSubmission) (domain.Leaderboard, domain.AnswerResult, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.Leaderboard{}, domain.AnswerResult{}, domain.ErrSessionNotFound
+	}
 
-	ch <- initial
+	question, err := s.question(ctx, session, submission.QuestionID)
+	if err != nil {
+		return domain.Leaderboard{}, domain.AnswerResult{}, err
+	}
+	submission, err = canonicalSubmission(sessionID, userID, session.Settings(), question, submission)
+	if err != nil {
+		return domain.Leaderboard{}, domain.AnswerResult{}, err
+	}
 
-	cancel := func() {
-		s.mu.Lock()
-		if _, ok := s.subscribers[ch]; ok {
-			delete(s.subscribers, ch)
-			close(ch)
-		}
-		s.mu.Unlock()
-	}
-	return ch, cancel
-}
-
-func (s *Session) broadcastLocked() domain.Leaderboard {
-	lb := s.snapshotLocked()
-	for ch := range s.subscribers {
-		select {
-		case ch <- lb:
-		default:
-			// AI-assisted: dropping stale updates prevents slow clients from blocking broadcast; verified via subscription tests.
-			select {
-			case <-ch:
-			default:
-			}
-			ch <- lb
-		}
+	correct, points, err := scoreSubmission(question, submission)
+	if err != nil {
+		return domain.Leaderboard{}, domain.AnswerResult{}, err
 	}
-	return lb
+
+	result, record, err := session.recordAnswer(userID, question, submission.OptionID, correct, points)
+	if err != nil {
+		return domain.Leaderboard{}, domain.AnswerResult{}, err
+	}
+	if s.answers != nil {
+		s.answers.record(record)
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.playerViewLocked(userID, domain.LeaderboardView{}), result, nil
 }
 
-func (s *Session) snapshotLocked() domain.Leaderboard {
-	entries := make([]domain.LeaderboardEntry, 0, len(s.participants))
-	for _, participant := range s.participants {
-		entries = append(entries, domain.LeaderboardEntry{
-			UserID:      participant.UserID,
-			DisplayName: participant.DisplayName,
-			Score:       participant.Score,
-		})
+// NextQuestion returns where a participant stands in a self-paced session, giving them their
+// next question and starting its clock. Answers go through SubmitAnswer and SubmitResponse as in
+// live sessions; a participant who leaves picks up where they were when they come back.
+func (s *QuizService) NextQuestion(ctx context.Context, sessionID, userID string) (domain.SelfPacedStep, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.SelfPacedStep{}, domain.ErrSessionNotFound
+	}
+	quiz, err := s.quiz(ctx, session)
+	if err != nil {
+		return domain.SelfPacedStep{}, err
 	}
+	question, step, err := session.next(userID, quiz.Questions)
+	if err != nil || step.Finished {
+		return step, err
+	}
+	view := playerQuestion(sessionID, userID, session.Settings(), question)
+	step.Question = &view
+	return step, nil
+}
+
+// PlayerQuestion returns a question as the player sees it, with the options in their order
+// when the session shuffles them. Self-paced participants only see questions they have reached.
+func (s *QuizService) PlayerQuestion(ctx context.Context, sessionID, userID, questionID string) (domain.PlayerQuestion, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.PlayerQuestion{}, domain.ErrSessionNotFound
+	}
+	if !session.reached(userID, questionID) {
+		return domain.PlayerQuestion{}, domain.ErrQuestionNotReached
+	}
+	question, err := s.question(ctx, session, questionID)
+	if err != nil {
+		return domain.PlayerQuestion{}, err
+	}
+	return playerQuestion(sessionID, userID, session.Settings(), question), nil
+}
 
-	// AI-assisted per your guidance: tie-breaker logic (score desc, then earliest completion, then name) drafted with ChatGPT to prioritize faster finishers.
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].Score != entries[j].Score {
-			return entries[i].Score > entries[j].Score
+// Layout rebuilds the question and option order a participant was shown, and the options their
+// 50:50s removed, for replays and exports.
+func (s *QuizService) Layout(ctx context.Context, sessionID, hostToken, userID string) (domain.Layout, error) {
+	session, err := s.hostSession(sessionID, hostToken)
+	if err != nil {
+		return domain.Layout{}, err
+	}
+	quiz, err := s.quiz(ctx, session)
+	if err != nil {
+		return domain.Layout{}, err
+	}
+	l := layout(sessionID, userID, session.Settings(), quiz.Questions)
+	// Only the questions are kept once a player leaves; the removed options follow from the seed.
+	for _, questionID := range session.fiftyFiftyQuestions(userID) {
+		question, err := s.question(ctx, session, questionID)
+		if err != nil {
+			return domain.Layout{}, err
 		}
-		// Tie-break by who reached the score earlier (lower LastUpdated), then name.
-		pi := s.participants[entries[i].UserID]
-		pj := s.participants[entries[j].UserID]
-		if pi != nil && pj != nil && !pi.LastUpdated.Equal(pj.LastUpdated) {
-			return pi.LastUpdated.Before(pj.LastUpdated)
+		if l.Removed == nil {
+			l.Removed = make(map[string][]string)
 		}
-		return entries[i].DisplayName < entries[j].DisplayName
-	})
+		l.Removed[questionID] = fiftyFiftyRemoval(sessionID, userID, question)
+	}
+	return l, nil
+}
 
-	return domain.Leaderboard{
-		QuizID:    s.id,
-		Entries:   entries,
-		UpdatedAt: s.now(),
+// UseLifeline gives a player a 50:50 or a hint on a question they haven't answered yet. Its cost
+// comes off their points for the question when they answer it.
+func (s *QuizService) UseLifeline(ctx context.Context, sessionID, userID, questionID, lifeline string) (domain.LifelineResult, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.LifelineResult{}, domain.ErrSessionNotFound
 	}
+	question, err := s.question(ctx, session, questionID)
+	if err != nil {
+		return domain.LifelineResult{}, err
+	}
+	return session.useLifeline(userID, question, lifeline)
 }
 
-// scoreSubmission validates the answer against quiz content and returns (correct, points).
-func scoreSubmission(quiz domain.Quiz, submission domain.AnswerSubmission) (bool, int, error) {
-	var question *domain.Question
-	for i := range quiz.Questions {
-		if quiz.Questions[i].ID == submission.QuestionID {
-			question = &quiz.Questions[i]
-			break
+// SubmitResponse records a response to an ungraded poll question. Responses never change scores;
+// hosts see them aggregated in answerStats broadcasts.
+func (s *QuizService) SubmitResponse(ctx context.Context, sessionID, userID string, submission domain.AnswerSubmission) error {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	question, err := s.question(ctx, session, submission.QuestionID)
+	if err != nil {
+		return err
+	}
+	if question.Graded() {
+		return domain.ErrInvalidResponse
+	}
+	submission, err = canonicalSubmission(sessionID, userID, session.Settings(), question, submission)
+	if err != nil {
+		return err
+	}
+	response, err := newPollResponse(question, submission)
+	if err != nil {
+		return err
+	}
+	return session.recordResponse(userID, question, response)
+}
+
+// Subscribe returns a channel that receives full leaderboard broadcasts for a session.
+// The caller must invoke the returned cancel function to avoid leaks.
+func (s *QuizService) Subscribe(ctx context.Context, sessionID string) (<-chan *Broadcast, func(), error) {
+	return s.SubscribeView(ctx, sessionID, "", domain.LeaderboardView{})
+}
+
+// SubscribeView is like Subscribe, but every broadcast is cut down to the requested view
+// around userID's own rank. Views are capped at MaxViewTop and MaxViewAround entries.
+// When the session hides its leaderboard, players only receive their own entry. A userID that
+// is no longer a participant, because they were kicked or banned after joining, is refused.
+func (s *QuizService) SubscribeView(_ context.Context, sessionID, userID string, view domain.LeaderboardView) (<-chan *Broadcast, func(), error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return nil, nil, domain.ErrSessionNotFound
+	}
+	view = clampView(view)
+	if view.Patch && !view.Full() {
+		return nil, nil, domain.ErrInvalidView
+	}
+	if view.Patch && userID != "" && session.Settings().LeaderboardVisibility == domain.LeaderboardHidden {
+		return nil, nil, domain.ErrLeaderboardHidden
+	}
+	return session.subscribe(userID, view)
+}
+
+// LeaderboardSince returns the ordered patches that bring a client holding version up to date.
+// When that version is too old to patch from, it returns a full snapshot instead.
+// Both results are empty when the client is already current.
+func (s *QuizService) LeaderboardSince(_ context.Context, sessionID string, version uint64) ([]domain.LeaderboardPatch, *domain.Leaderboard, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return nil, nil, domain.ErrSessionNotFound
+	}
+	patches, snapshot := session.since(version)
+	return patches, snapshot, nil
+}
+
+// LeaderboardView returns the current leaderboard cut down to a view for userID.
+func (s *QuizService) LeaderboardView(_ context.Context, sessionID, userID string, view domain.LeaderboardView) (domain.Leaderboard, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return domain.Leaderboard{}, domain.ErrSessionNotFound
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return session.playerViewLocked(userID, clampView(view)), nil
+}
+
+const (
+	// MaxViewTop caps the leading entries a personalized view may request.
+	MaxViewTop = 100
+	// MaxViewAround caps the neighbours on each side of a subscriber's own rank.
+	MaxViewAround = 25
+)
+
+func clampView(view domain.LeaderboardView) domain.LeaderboardView {
+	view.Top = min(max(view.Top, 0), MaxViewTop)
+	view.Around = min(max(view.Around, 0), MaxViewAround)
+	return view
+}
+
+// Connect registers a socket for userID; call it before the player enters the session. Under the
+// session's takeover policy the user's older connection is told to close. Otherwise the player
+// stays in the session until the last of their connections is closed.
+func (s *QuizService) Connect(_ context.Context, sessionID, userID string) (*Connection, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	return newConnection(session, userID, func() {
+		if session.Idle() {
+			s.sessions.DeleteIfEmpty(sessionID)
 		}
+	}), nil
+}
+
+// Leave removes a participant from the session, whatever connections they hold, and drops the
+// session if empty.
+func (s *QuizService) Leave(_ context.Context, sessionID, userID string) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return
+	}
+	session.leave(userID)
+	if session.Idle() {
+		s.sessions.DeleteIfEmpty(sessionID)
 	}
-	if question == nil {
-		return false, 0, domain.ErrQuestionNotFound
+}
+
+// scoreSubmission validates the answer against the question and returns whether it is correct
+// and the points it is worth.
+func scoreSubmission(question domain.Question, submission domain.AnswerSubmission) (bool, int, error) {
+	if !question.Graded() {
+		return false, 0, domain.ErrInvalidResponse
 	}
 
 	var selected *domain.Option
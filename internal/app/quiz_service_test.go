@@ -2,6 +2,11 @@ package app_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,15 +18,16 @@ import (
 func TestJoinAndScoring(t *testing.T) {
 	ctx := context.Background()
 	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
 
-	if _, err := service.Join(ctx, "quiz-1", "u1", "Alice"); err != nil {
+	if _, err := service.Join(ctx, sessionID, "u1", "Alice"); err != nil {
 		t.Fatalf("join failed: %v", err)
 	}
-	if _, err := service.Join(ctx, "quiz-1", "u2", "Bob"); err != nil {
+	if _, err := service.Join(ctx, sessionID, "u2", "Bob"); err != nil {
 		t.Fatalf("join failed: %v", err)
 	}
 
-	lb, _, _, _, err := service.SubmitAnswer(ctx, "quiz-1", "u2", domain.AnswerSubmission{
+	lb, _, err := service.SubmitAnswer(ctx, sessionID, "u2", domain.AnswerSubmission{
 		QuestionID: "q1",
 		OptionID:   "o2", // correct
 	})
@@ -39,11 +45,12 @@ func TestJoinAndScoring(t *testing.T) {
 func TestSubscribeReceivesUpdates(t *testing.T) {
 	ctx := context.Background()
 	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
 
-	if _, err := service.Join(ctx, "quiz-1", "u1", "Alice"); err != nil {
+	if _, err := service.Join(ctx, sessionID, "u1", "Alice"); err != nil {
 		t.Fatalf("join failed: %v", err)
 	}
-	ch, cancel, err := service.Subscribe(ctx, "quiz-1")
+	ch, cancel, err := service.Subscribe(ctx, sessionID)
 	if err != nil {
 		t.Fatalf("subscribe failed: %v", err)
 	}
@@ -51,7 +58,7 @@ func TestSubscribeReceivesUpdates(t *testing.T) {
 
 	<-ch // initial snapshot
 
-	_, _, _, _, err = service.SubmitAnswer(ctx, "quiz-1", "u1", domain.AnswerSubmission{
+	_, _, err = service.SubmitAnswer(ctx, sessionID, "u1", domain.AnswerSubmission{
 		QuestionID: "q1",
 		OptionID:   "o2",
 	})
@@ -60,44 +67,1470 @@ func TestSubscribeReceivesUpdates(t *testing.T) {
 	}
 
 	update := <-ch
-	if len(update.Entries) != 1 || update.Entries[0].Score != 1 {
-		t.Fatalf("expected updated score 1, got %+v", update.Entries)
+	if update.Type != app.EventLeaderboard {
+		t.Fatalf("expected leaderboard broadcast, got %s", update.Type)
+	}
+	lb := update.Payload.(domain.Leaderboard)
+	if len(lb.Entries) != 1 || lb.Entries[0].Score != 1 {
+		t.Fatalf("expected updated score 1, got %+v", lb.Entries)
+	}
+}
+
+func TestBroadcastSharedAcrossSubscribers(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
+
+	if _, err := service.Join(ctx, sessionID, "u1", "Alice"); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	first, cancelFirst, err := service.Subscribe(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancelFirst()
+	second, cancelSecond, err := service.Subscribe(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancelSecond()
+	<-first
+	<-second
+
+	if _, _, err := service.SubmitAnswer(ctx, sessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+
+	a, b := <-first, <-second
+	if a != b {
+		t.Fatalf("expected subscribers to share one broadcast")
+	}
+	encodes := 0
+	encode := func(*app.Broadcast) (any, error) {
+		encodes++
+		return "frame", nil
+	}
+	_, _ = a.Prepare(encode)
+	_, _ = b.Prepare(encode)
+	if encodes != 1 {
+		t.Fatalf("expected a single encode, got %d", encodes)
+	}
+}
+
+func TestBroadcastCoalescesWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithBroadcastWindow(50*time.Millisecond))
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
+
+	if _, err := service.Join(ctx, sessionID, "u1", "Alice"); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	ch, cancel, err := service.Subscribe(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancel()
+	<-ch
+
+	for i := 0; i < 5; i++ {
+		_, _, err := service.SubmitAnswer(ctx, sessionID, "u1", domain.AnswerSubmission{QuestionID: fmt.Sprintf("q%d", i+1), OptionID: "o2"})
+		if err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+
+	select {
+	case update := <-ch:
+		lb := update.Payload.(domain.Leaderboard)
+		if lb.Entries[0].Score != 5 {
+			t.Fatalf("expected latest snapshot with score 5, got %+v", lb.Entries)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected coalesced broadcast")
+	}
+	if stats := service.BroadcastStats(); stats.Coalesced != 4 {
+		t.Fatalf("expected 4 coalesced updates, got %+v", stats)
+	}
+}
+
+func TestSubscribeViewIsPersonalized(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
+
+	// u0 ends up with the highest score, u9 with none.
+	for i := 0; i < 10; i++ {
+		userID := fmt.Sprintf("u%d", i)
+		if _, err := service.Join(ctx, sessionID, userID, fmt.Sprintf("Player %d", i)); err != nil {
+			t.Fatalf("join failed: %v", err)
+		}
+		for j := i; j < 9; j++ {
+			if _, _, err := service.SubmitAnswer(ctx, sessionID, userID, domain.AnswerSubmission{QuestionID: fmt.Sprintf("q%d", j+1), OptionID: "o2"}); err != nil {
+				t.Fatalf("submit failed: %v", err)
+			}
+		}
+	}
+
+	ch, cancel, err := service.SubscribeView(ctx, sessionID, "u6", domain.LeaderboardView{Top: 3, Around: 1})
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancel()
+
+	lb := (<-ch).Payload.(domain.Leaderboard)
+	if lb.Total != 10 || len(lb.Entries) != 3 || lb.Entries[0].UserID != "u0" {
+		t.Fatalf("expected top 3 of 10, got total=%d entries=%+v", lb.Total, lb.Entries)
+	}
+	if lb.Me == nil || lb.Me.UserID != "u6" || lb.Me.Rank != 7 {
+		t.Fatalf("expected u6 at rank 7, got %+v", lb.Me)
+	}
+	if len(lb.Around) != 3 || lb.Around[0].Rank != 6 || lb.Around[2].Rank != 8 {
+		t.Fatalf("expected ranks 6-8 around u6, got %+v", lb.Around)
+	}
+
+	// Four correct answers lift u6 to 7 points: past u3-u5, but behind u2 who got there first.
+	for i := 0; i < 4; i++ {
+		if _, _, err := service.SubmitAnswer(ctx, sessionID, "u6", domain.AnswerSubmission{QuestionID: fmt.Sprintf("q%d", i+1), OptionID: "o2"}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+	var latest domain.Leaderboard
+	for len(ch) > 0 {
+		latest = (<-ch).Payload.(domain.Leaderboard)
+	}
+	if latest.Me == nil || latest.Me.Rank != 4 {
+		t.Fatalf("expected u6 at rank 4, got %+v", latest.Me)
+	}
+	if latest.Me.RankDelta != 1 {
+		t.Fatalf("expected u6 to gain one rank on the last broadcast, got %+v", latest.Me)
+	}
+}
+
+func TestTeamsAutoAssignAndAggregate(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-teams", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	sessionID := info.SessionID
+
+	for _, userID := range []string{"u1", "u2", "u3"} {
+		if _, err := service.Join(ctx, sessionID, userID, userID); err != nil {
+			t.Fatalf("join failed: %v", err)
+		}
+	}
+	if _, err := service.JoinTeam(ctx, sessionID, "u4", "u4", "green"); err != domain.ErrTeamNotFound {
+		t.Fatalf("expected unknown team error, got %v", err)
+	}
+	for i, userID := range []string{"u1", "u1", "u2", "u3"} {
+		if _, _, err := service.SubmitAnswer(ctx, sessionID, userID, domain.AnswerSubmission{QuestionID: fmt.Sprintf("q%d", i+1), OptionID: "o2"}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+
+	// u1 and u3 land on red (2 and 1 points, average 1.5); u2 on blue with 1 point.
+	lb, err := service.LeaderboardView(ctx, sessionID, "", domain.LeaderboardView{})
+	if err != nil {
+		t.Fatalf("leaderboard failed: %v", err)
+	}
+	if len(lb.Teams) != 2 || lb.Teams[0].Team != "red" || lb.Teams[0].Score != 1.5 || lb.Teams[0].Members != 2 {
+		t.Fatalf("expected red leading with average 1.5, got %+v", lb.Teams)
+	}
+
+	if err := service.AssignTeam(ctx, sessionID, "guess", "u2", "red"); err != domain.ErrHostOnly {
+		t.Fatalf("expected players not to move teams, got %v", err)
+	}
+	if err := service.BalanceTeams(ctx, sessionID, "guess"); err != domain.ErrHostOnly {
+		t.Fatalf("expected players not to balance teams, got %v", err)
+	}
+	if err := service.AssignTeam(ctx, sessionID, info.HostToken, "u2", "red"); err != nil {
+		t.Fatalf("assign failed: %v", err)
+	}
+	if err := service.BalanceTeams(ctx, sessionID, info.HostToken); err != nil {
+		t.Fatalf("balance failed: %v", err)
+	}
+	lb, _ = service.LeaderboardView(ctx, sessionID, "", domain.LeaderboardView{})
+	for _, team := range lb.Teams {
+		if team.Members < 1 || team.Members > 2 {
+			t.Fatalf("expected balanced teams, got %+v", lb.Teams)
+		}
+	}
+}
+
+func TestCreateSessionSeparatesRunsOfOneQuiz(t *testing.T) {
+	ctx := context.Background()
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithJoinCodes(memory.NewJoinCodeStore(), time.Hour))
+
+	first, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	second, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if first.SessionID == second.SessionID || first.JoinCode == second.JoinCode {
+		t.Fatalf("expected distinct sessions and codes, got %+v and %+v", first, second)
+	}
+	if len(first.JoinCode) != 6 || strings.ContainsAny(first.JoinCode, "0O1IL") {
+		t.Fatalf("expected a 6 character unambiguous code, got %q", first.JoinCode)
+	}
+
+	sessionID, err := service.ResolveJoinCode(ctx, strings.ToLower(first.JoinCode))
+	if err != nil || sessionID != first.SessionID {
+		t.Fatalf("expected code to resolve to %s, got %s (%v)", first.SessionID, sessionID, err)
+	}
+	if _, err := service.ResolveJoinCode(ctx, "ZZZZZZ"); err != domain.ErrJoinCodeNotFound {
+		t.Fatalf("expected unknown code error, got %v", err)
+	}
+
+	for _, info := range []domain.SessionInfo{first, second} {
+		if _, err := service.Join(ctx, info.SessionID, "u1", "Alice"); err != nil {
+			t.Fatalf("join failed: %v", err)
+		}
+	}
+	lb, _, err := service.SubmitAnswer(ctx, first.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"})
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if lb.QuizID != "quiz-1" || lb.SessionID != first.SessionID || lb.Entries[0].Score != 1 {
+		t.Fatalf("expected score in first session only, got %+v", lb)
+	}
+	other, _ := service.LeaderboardView(ctx, second.SessionID, "", domain.LeaderboardView{})
+	if other.Entries[0].Score != 0 {
+		t.Fatalf("expected second session untouched, got %+v", other.Entries)
+	}
+
+	// Created sessions outlive their last participant until they expire.
+	service.Leave(ctx, first.SessionID, "u1")
+	if _, err := service.Join(ctx, first.SessionID, "u2", "Bob"); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
 	}
 }
 
 func TestSubmitRequiresParticipant(t *testing.T) {
 	ctx := context.Background()
 	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
 
-	_, _, _, _, err := service.SubmitAnswer(ctx, "quiz-unknown", "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o1"})
+	_, _, err := service.SubmitAnswer(ctx, "quiz-unknown", "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o1"})
 	if err != domain.ErrSessionNotFound {
 		t.Fatalf("expected session error, got %v", err)
 	}
 
-	_, _ = service.Join(ctx, "quiz-1", "u1", "Alice")
-	_, _, _, _, err = service.SubmitAnswer(ctx, "quiz-1", "u2", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"})
+	_, _ = service.Join(ctx, sessionID, "u1", "Alice")
+	_, _, err = service.SubmitAnswer(ctx, sessionID, "u2", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"})
 	if err != domain.ErrParticipantNotFound {
 		t.Fatalf("expected participant error, got %v", err)
 	}
 }
 
+func TestSessionSettingsAreHonoured(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+
+	capped := newTestSession(t, service, "quiz-1", domain.SessionSettings{MaxParticipants: 1, LateJoin: domain.LateJoinDeny})
+	if _, err := service.Join(ctx, capped, "u1", "Alice"); err != nil {
+		t.Fatalf("join failed: %v", err)
+	}
+	if _, err := service.Join(ctx, capped, "u2", "Bob"); err != domain.ErrSessionFull {
+		t.Fatalf("expected full session, got %v", err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, capped, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, capped, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != domain.ErrAlreadyAnswered {
+		t.Fatalf("expected first answer to be final, got %v", err)
+	}
+	service.Leave(ctx, capped, "u1")
+	if _, err := service.Join(ctx, capped, "u2", "Bob"); err != domain.ErrLateJoinClosed {
+		t.Fatalf("expected late join to be refused, got %v", err)
+	}
+
+	changeable := newTestSession(t, service, "quiz-1", domain.SessionSettings{AnswerChange: domain.AnswerChangeAllow})
+	_, _ = service.Join(ctx, changeable, "u1", "Alice")
+	for _, option := range []string{"o2", "o1"} {
+		if _, _, err := service.SubmitAnswer(ctx, changeable, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: option}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+	lb, _ := service.LeaderboardView(ctx, changeable, "", domain.LeaderboardView{})
+	if lb.Entries[0].Score != 0 {
+		t.Fatalf("expected changed answer to replace the earlier score, got %+v", lb.Entries)
+	}
+
+	if _, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{Scoring: "random"}); !errors.Is(err, domain.ErrInvalidSettings) {
+		t.Fatalf("expected invalid settings, got %v", err)
+	}
+}
+
+func TestQuestionTimeLimit(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-points", domain.SessionSettings{Scoring: domain.ScoringSpeed, QuestionTimeLimitMs: 50})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Alice")
+
+	if err := service.OpenQuestion(ctx, info.SessionID, "not-the-host", "q1"); err != domain.ErrHostOnly {
+		t.Fatalf("expected host-only error, got %v", err)
+	}
+	for _, questionID := range []string{"q1", "q2"} {
+		if err := service.OpenQuestion(ctx, info.SessionID, info.HostToken, questionID); err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+	}
+	_, result, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"})
+	if err != nil || result.Awarded < 50 || result.Awarded > 100 {
+		t.Fatalf("expected 50-100 speed points, got %d (%v)", result.Awarded, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q2", OptionID: "o2"}); err != domain.ErrTimeUp {
+		t.Fatalf("expected time up, got %v", err)
+	}
+}
+
+func TestHiddenLeaderboardOnlyShowsPlayersThemselves(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{LeaderboardVisibility: domain.LeaderboardHidden})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Alice")
+	_, _ = service.Join(ctx, info.SessionID, "u2", "Bob")
+
+	ch, cancel, err := service.SubscribeView(ctx, info.SessionID, "u1", domain.LeaderboardView{})
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancel()
+	lb := (<-ch).Payload.(domain.Leaderboard)
+	if len(lb.Entries) != 0 || lb.Me == nil || lb.Me.UserID != "u1" {
+		t.Fatalf("expected only the player's own entry, got %+v", lb)
+	}
+	if _, _, err := service.SubscribeView(ctx, info.SessionID, "u1", domain.LeaderboardView{Patch: true}); err != domain.ErrLeaderboardHidden {
+		t.Fatalf("expected patches to be refused, got %v", err)
+	}
+
+	host, err := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	if err != nil || len(host.Entries) != 2 {
+		t.Fatalf("expected the host to see everyone, got %+v (%v)", host.Entries, err)
+	}
+}
+
+func TestWaitingRoomAdmitsInOrder(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{MaxParticipants: 1, WaitingRoom: true})
+
+	if waiter, err := service.Enter(ctx, sessionID, "u1", "Alice", ""); err != nil || waiter != nil {
+		t.Fatalf("expected u1 to join straight away, got %v (%v)", waiter, err)
+	}
+	second, err := service.Enter(ctx, sessionID, "u2", "Bob", "")
+	if err != nil || second == nil {
+		t.Fatalf("expected u2 to wait, got %v", err)
+	}
+	third, _ := service.Enter(ctx, sessionID, "u3", "Carol", "")
+	if status := <-third.Updates; status.Position != 2 {
+		t.Fatalf("expected u3 second in line, got %+v", status)
+	}
+	if _, err := service.Join(ctx, sessionID, "u4", "Dan"); err != domain.ErrSessionFull {
+		t.Fatalf("expected direct join to be refused while people wait, got %v", err)
+	}
+
+	service.Leave(ctx, sessionID, "u1")
+	var last domain.WaitingStatus
+	for status := range second.Updates {
+		last = status
+	}
+	if !last.Admitted {
+		t.Fatalf("expected u2 admitted once u1 left, got %+v", last)
+	}
+	if status := <-third.Updates; status.Position != 1 || status.Waiting != 1 {
+		t.Fatalf("expected u3 to move up, got %+v", status)
+	}
+
+	third.Cancel()
+	lb, _ := service.LeaderboardView(ctx, sessionID, "", domain.LeaderboardView{})
+	if lb.Total != 1 || lb.Entries[0].UserID != "u2" {
+		t.Fatalf("expected only u2 in the session, got %+v", lb.Entries)
+	}
+}
+
+func TestHostAdmission(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{Admission: domain.AdmissionHost})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	alice, _ := service.Enter(ctx, info.SessionID, "u1", "Alice", "")
+	bob, _ := service.Enter(ctx, info.SessionID, "u2", "Bob", "")
+	if alice == nil || bob == nil {
+		t.Fatalf("expected everyone to wait for the host")
+	}
+	if _, err := service.WaitingRoom(ctx, info.SessionID, "guess"); err != domain.ErrHostOnly {
+		t.Fatalf("expected host-only error, got %v", err)
+	}
+	waiting, _ := service.WaitingRoom(ctx, info.SessionID, info.HostToken)
+	if len(waiting) != 2 || waiting[0].UserID != "u1" {
+		t.Fatalf("expected u1 then u2 waiting, got %+v", waiting)
+	}
+
+	if err := service.Admit(ctx, info.SessionID, info.HostToken, "u2"); err != nil {
+		t.Fatalf("admit failed: %v", err)
+	}
+	if err := service.Deny(ctx, info.SessionID, info.HostToken, "u1"); err != nil {
+		t.Fatalf("deny failed: %v", err)
+	}
+	var status domain.WaitingStatus
+	for status = range alice.Updates {
+	}
+	if !status.Denied {
+		t.Fatalf("expected u1 denied, got %+v", status)
+	}
+	if err := service.Admit(ctx, info.SessionID, info.HostToken, "u1"); err != domain.ErrNotWaiting {
+		t.Fatalf("expected u1 no longer waiting, got %v", err)
+	}
+	lb, _ := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	if lb.Total != 1 || lb.Entries[0].UserID != "u2" {
+		t.Fatalf("expected only u2 admitted, got %+v", lb.Entries)
+	}
+}
+
+func TestHostModeration(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := service.Join(ctx, info.SessionID, userID, "Rude "+userID); err != nil {
+			t.Fatalf("join failed: %v", err)
+		}
+	}
+	ch, cancel, _ := service.SubscribeView(ctx, info.SessionID, "u2", domain.LeaderboardView{})
+	defer cancel()
+
+	if err := service.Kick(ctx, info.SessionID, "guess", "u1", "spam"); err != domain.ErrHostOnly {
+		t.Fatalf("expected host-only error, got %v", err)
+	}
+	if err := service.Rename(ctx, info.SessionID, info.HostToken, "u1", "Player 1"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if _, err := service.Join(ctx, info.SessionID, "u1", "Rude again"); err != nil {
+		t.Fatalf("rejoin failed: %v", err)
+	}
+	if _, err := service.AdjustScore(ctx, info.SessionID, info.HostToken, "u1", 5, ""); err != domain.ErrAuditNoteRequired {
+		t.Fatalf("expected a note to be required, got %v", err)
+	}
+	if score, err := service.AdjustScore(ctx, info.SessionID, info.HostToken, "u1", 5, "bonus round"); err != nil || score != 5 {
+		t.Fatalf("expected score 5, got %d (%v)", score, err)
+	}
+	if score, err := service.ResetScore(ctx, info.SessionID, info.HostToken, "u1", "cheating"); err != nil || score != 0 {
+		t.Fatalf("expected score reset, got %d (%v)", score, err)
+	}
+	lb, _ := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	for _, entry := range lb.Entries {
+		if entry.UserID == "u1" && entry.DisplayName != "Player 1" {
+			t.Fatalf("expected forced name to stick, got %+v", entry)
+		}
+	}
+
+	if err := service.Ban(ctx, info.SessionID, info.HostToken, "u2", "harassment"); err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	var last *app.Broadcast
+	for b := range ch {
+		last = b
+	}
+	if removal, ok := last.Payload.(domain.Removal); !ok || !removal.Banned || removal.Reason != "harassment" {
+		t.Fatalf("expected a final ban notice, got %+v", last)
+	}
+	if _, err := service.Join(ctx, info.SessionID, "u2", "Back again"); err != domain.ErrBanned {
+		t.Fatalf("expected banned user to stay out, got %v", err)
+	}
+	if err := service.Kick(ctx, info.SessionID, info.HostToken, "u2", "again"); err != domain.ErrParticipantNotFound {
+		t.Fatalf("expected kick of absent user to fail, got %v", err)
+	}
+
+	audit, _ := service.AuditLog(ctx, info.SessionID, info.HostToken)
+	var actions []string
+	for _, action := range audit {
+		actions = append(actions, action.Action)
+	}
+	if strings.Join(actions, ",") != "rename,adjustScore,resetScore,ban" {
+		t.Fatalf("unexpected audit log %v", actions)
+	}
+}
+
+func TestPlayersRemovedBeforeSubscribingAreRefused(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		if _, err := service.Enter(ctx, info.SessionID, userID, "Player "+userID, ""); err != nil {
+			t.Fatalf("enter failed: %v", err)
+		}
+	}
+	// Removed after entering but before their socket subscribed, so no removal broadcast reached them.
+	if err := service.Kick(ctx, info.SessionID, info.HostToken, "u1", "spam"); err != nil {
+		t.Fatalf("kick failed: %v", err)
+	}
+	if err := service.Ban(ctx, info.SessionID, info.HostToken, "u2", "harassment"); err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	if _, _, err := service.SubscribeView(ctx, info.SessionID, "u1", domain.LeaderboardView{}); err != domain.ErrParticipantNotFound {
+		t.Fatalf("expected the kicked player to be refused, got %v", err)
+	}
+	if _, _, err := service.SubscribeView(ctx, info.SessionID, "u2", domain.LeaderboardView{}); err != domain.ErrBanned {
+		t.Fatalf("expected the banned player to be refused, got %v", err)
+	}
+	if _, cancel, err := service.SubscribeView(ctx, info.SessionID, "", domain.LeaderboardView{}); err != nil {
+		t.Fatalf("expected anonymous listeners to be let in, got %v", err)
+	} else {
+		cancel()
+	}
+}
+
+func TestDisplayNamePolicy(t *testing.T) {
+	ctx := context.Background()
+	names := app.NewNamePolicy(2, 12)
+	names.DefaultLocale = "en"
+	names.AddWords("en", []string{"badword"})
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithNamePolicy(names))
+	sessionID := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
+
+	rejected := map[string]string{
+		"\u200b \u200d":        domain.NameEmpty,
+		"A":                    domain.NameTooShort,
+		"Much Too Long A Name": domain.NameTooLong,
+		"B4dW0rd":              domain.NameProfanity,
+		"b.a.d word":           domain.NameProfanity,
+		"ｂａｄｗｏｒｄ":              domain.NameProfanity,
+	}
+	for name, reason := range rejected {
+		_, err := service.Join(ctx, sessionID, "u-bad", name)
+		var nameErr *domain.NameError
+		if !errors.As(err, &nameErr) || nameErr.Reason != reason || !errors.Is(err, domain.ErrInvalidName) {
+			t.Fatalf("%q: expected %s rejection, got %v", name, reason, err)
+		}
+	}
+
+	joins := []struct{ userID, name, want string }{
+		{"u1", "  Alice\u200b  Smith ", "Alice Smith"},
+		{"u2", "alice smith", "alice smit 2"},           // trimmed to fit the suffix
+		{"u3", "\u0410lice Smith", "\u0410lice Smit 3"}, // Cyrillic А
+		{"u1", "Alice Smith", "Alice Smith"},            // rejoining keeps their own name
+	}
+	for _, join := range joins {
+		lb, err := service.Join(ctx, sessionID, join.userID, join.name)
+		if err != nil {
+			t.Fatalf("join %s failed: %v", join.userID, err)
+		}
+		var got string
+		for _, entry := range lb.Entries {
+			if entry.UserID == join.userID {
+				got = entry.DisplayName
+			}
+		}
+		if got != join.want {
+			t.Fatalf("%s: expected name %q, got %q", join.userID, join.want, got)
+		}
+	}
+}
+
+func TestDevicePolicies(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	inSession := func(sessionID string) bool {
+		lb, _ := service.LeaderboardView(ctx, sessionID, "u1", domain.LeaderboardView{})
+		return len(lb.Entries) == 1
+	}
+	connect := func(sessionID string) *app.Connection {
+		conn, err := service.Connect(ctx, sessionID, "u1")
+		if err != nil {
+			t.Fatalf("connect failed: %v", err)
+		}
+		if _, err := service.Join(ctx, sessionID, "u1", "Alice"); err != nil {
+			t.Fatalf("join failed: %v", err)
+		}
+		return conn
+	}
+
+	multi := newTestSession(t, service, "quiz-1", domain.SessionSettings{})
+	phone, laptop := connect(multi), connect(multi)
+	phone.Close()
+	if !inSession(multi) {
+		t.Fatalf("expected the player to stay while another device is connected")
+	}
+	laptop.Close()
+	if inSession(multi) {
+		t.Fatalf("expected the player to leave with their last device")
+	}
+
+	takeover := newTestSession(t, service, "quiz-1", domain.SessionSettings{Devices: domain.DevicesTakeover})
+	phone = connect(takeover)
+	laptop = connect(takeover)
+	select {
+	case <-phone.TakenOver:
+	default:
+		t.Fatalf("expected the older connection to be taken over")
+	}
+	phone.Close()
+	if !inSession(takeover) {
+		t.Fatalf("expected the taken-over connection not to remove the player")
+	}
+	laptop.Close()
+	if inSession(takeover) {
+		t.Fatalf("expected the player to leave when the newest connection closes")
+	}
+}
+
+func TestAnswerStats(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{RevealStats: true})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2", "u3", "u4"} {
+		_, _ = service.Join(ctx, info.SessionID, userID, "Player "+userID)
+	}
+	host, cancelHost, _ := service.Subscribe(ctx, info.SessionID)
+	defer cancelHost()
+	player, cancelPlayer, _ := service.SubscribeView(ctx, info.SessionID, "u1", domain.LeaderboardView{})
+	defer cancelPlayer()
+
+	if err := service.OpenQuestion(ctx, info.SessionID, info.HostToken, "q1"); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	for userID, optionID := range map[string]string{"u1": "o2", "u2": "o2", "u3": "o1"} {
+		if _, _, err := service.SubmitAnswer(ctx, info.SessionID, userID, domain.AnswerSubmission{QuestionID: "q1", OptionID: optionID}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+	stats, err := service.AnswerStats(ctx, info.SessionID, info.HostToken, "q1")
+	if err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	if stats.Responses != 3 || stats.Participants != 4 || stats.PercentCorrect != 66.7 ||
+		stats.Options[0].Count != 1 || stats.Options[1].Count != 2 || stats.Options[1].Percent != 66.7 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if last := lastStats(host); last == nil || last.Responses != 3 {
+		t.Fatalf("expected the host to get live stats, got %+v", last)
+	}
+	if last := lastStats(player); last != nil {
+		t.Fatalf("expected players not to see stats while the question is open, got %+v", last)
+	}
+
+	if err := service.CloseQuestion(ctx, info.SessionID, info.HostToken, "q1"); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u4", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != domain.ErrQuestionClosed {
+		t.Fatalf("expected closed question, got %v", err)
+	}
+	if last := lastStats(player); last == nil || !last.Closed || last.Responses != 3 {
+		t.Fatalf("expected the closed histogram to be revealed to players, got %+v", last)
+	}
+}
+
+// lastStats drains the broadcasts already queued on ch and returns the last answer stats among them.
+func lastStats(ch <-chan *app.Broadcast) *domain.AnswerStats {
+	var last *domain.AnswerStats
+	for {
+		select {
+		case b := <-ch:
+			if stats, ok := b.Payload.(domain.AnswerStats); ok {
+				last = &stats
+			}
+		default:
+			return last
+		}
+	}
+}
+
+func TestPollsAreAggregatedButNeverScored(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-poll", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	rating := func(v int) *int { return &v }
+	responses := map[string][]domain.AnswerSubmission{
+		"u1": {
+			{QuestionID: "mood", OptionID: "happy"},
+			{QuestionID: "topics", OptionIDs: []string{"maths", "art", "maths"}},
+			{QuestionID: "confidence", Rating: rating(3)},
+			{QuestionID: "oneWord", Text: "Fun, fun!"},
+		},
+		"u2": {
+			{QuestionID: "mood", OptionID: "happy"},
+			{QuestionID: "topics", OptionIDs: []string{"art"}},
+			{QuestionID: "confidence", Rating: rating(2)},
+			{QuestionID: "oneWord", Text: "fun times"},
+		},
+	}
+	for userID, submissions := range responses {
+		_, _ = service.Join(ctx, info.SessionID, userID, "Player "+userID)
+		for _, submission := range submissions {
+			if err := service.SubmitResponse(ctx, info.SessionID, userID, submission); err != nil {
+				t.Fatalf("%s %s: respond failed: %v", userID, submission.QuestionID, err)
+			}
+		}
+	}
+
+	invalid := []domain.AnswerSubmission{
+		{QuestionID: "graded", OptionID: "o2"},
+		{QuestionID: "confidence", Rating: rating(4)},
+		{QuestionID: "oneWord", Text: " ?! "},
+	}
+	for _, submission := range invalid {
+		if err := service.SubmitResponse(ctx, info.SessionID, "u1", submission); err != domain.ErrInvalidResponse {
+			t.Fatalf("%s: expected an invalid response, got %v", submission.QuestionID, err)
+		}
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "mood", OptionID: "happy"}); err != domain.ErrInvalidResponse {
+		t.Fatalf("expected polls to be refused as graded answers, got %v", err)
+	}
+
+	lb, _ := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	for _, entry := range lb.Entries {
+		if entry.Score != 0 {
+			t.Fatalf("expected polls not to score, got %+v", entry)
+		}
+	}
+	stats := func(questionID string) domain.AnswerStats {
+		stats, err := service.AnswerStats(ctx, info.SessionID, info.HostToken, questionID)
+		if err != nil {
+			t.Fatalf("stats failed: %v", err)
+		}
+		return stats
+	}
+	if mood := stats("mood"); mood.Type != domain.QuestionPoll || mood.Options[0].Count != 2 || mood.Options[0].Percent != 100 {
+		t.Fatalf("unexpected poll results: %+v", mood)
+	}
+	if topics := stats("topics"); topics.Options[0].Count != 1 || topics.Options[1].Count != 2 || topics.Options[1].Percent != 100 {
+		t.Fatalf("unexpected multi choice results: %+v", topics)
+	}
+	if confidence := stats("confidence"); confidence.AverageRating != 2.5 || len(confidence.Ratings) != 3 || confidence.Ratings[2].Count != 1 {
+		t.Fatalf("unexpected rating results: %+v", confidence)
+	}
+	if cloud := stats("oneWord"); len(cloud.Words) != 2 || cloud.Words[0] != (domain.WordCount{Word: "fun", Count: 2}) {
+		t.Fatalf("unexpected word cloud: %+v", cloud)
+	}
+}
+
+func TestLifelinesCostPointsAndExplanationsAreRevealed(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-lifelines", domain.SessionSettings{FiftyFifty: 1, FiftyFiftyCost: 10})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2"} {
+		_, _ = service.Join(ctx, info.SessionID, userID, "Player "+userID)
+	}
+	updates, cancel, _ := service.SubscribeView(ctx, info.SessionID, "u2", domain.LeaderboardView{})
+	defer cancel()
+
+	half, err := service.UseLifeline(ctx, info.SessionID, "u1", "capital", domain.LifelineFiftyFifty)
+	if err != nil {
+		t.Fatalf("50:50 failed: %v", err)
+	}
+	if len(half.RemovedOptionIDs) != 2 || half.Cost != 10 || half.Remaining != 0 {
+		t.Fatalf("unexpected 50:50: %+v", half)
+	}
+	for _, id := range half.RemovedOptionIDs {
+		if id == "paris" {
+			t.Fatalf("expected 50:50 to keep the right answer, removed %v", half.RemovedOptionIDs)
+		}
+	}
+	if layout, err := service.Layout(ctx, info.SessionID, info.HostToken, "u1"); err != nil || !slices.Equal(layout.Removed["capital"], half.RemovedOptionIDs) {
+		t.Fatalf("expected the layout to record the removed options %v, got %+v: %v", half.RemovedOptionIDs, layout.Removed, err)
+	}
+	if _, err := service.UseLifeline(ctx, info.SessionID, "u1", "capital", domain.LifelineFiftyFifty); err != domain.ErrLifelineUsed {
+		t.Fatalf("expected a second 50:50 on the question to be refused, got %v", err)
+	}
+	if _, err := service.UseLifeline(ctx, info.SessionID, "u1", "river", domain.LifelineFiftyFifty); err != domain.ErrLifelineUnavailable {
+		t.Fatalf("expected the 50:50 allowance to be used up, got %v", err)
+	}
+	hint, err := service.UseLifeline(ctx, info.SessionID, "u1", "capital", domain.LifelineHint)
+	if err != nil || hint.Hint != "It's on the Seine" || hint.Cost != 20 || hint.Remaining != 1 {
+		t.Fatalf("unexpected hint %+v: %v", hint, err)
+	}
+
+	_, result, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "capital", OptionID: "paris"})
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if result.Penalty != 30 || result.Awarded != 70 || result.TotalScore != 70 {
+		t.Fatalf("expected lifelines to cost 30 points, got %+v", result)
+	}
+	if _, err := service.UseLifeline(ctx, info.SessionID, "u1", "capital", domain.LifelineHint); err != domain.ErrAlreadyAnswered {
+		t.Fatalf("expected no lifelines after answering, got %v", err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u2", domain.AnswerSubmission{QuestionID: "capital", OptionID: "paris"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+
+	if err := service.CloseQuestion(ctx, info.SessionID, info.HostToken, "capital"); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	var reveal *domain.Reveal
+	for len(updates) > 0 {
+		if r, ok := (<-updates).Payload.(domain.Reveal); ok {
+			reveal = &r
+		}
+	}
+	if reveal == nil || reveal.Explanation != "Paris has been the capital since 987." ||
+		len(reveal.CorrectOptionIDs) != 1 || reveal.CorrectOptionIDs[0] != "paris" {
+		t.Fatalf("expected the answer and explanation to be revealed, got %+v", reveal)
+	}
+	// Leaving drops the player's lifelines, but replays still need to know what they saw.
+	service.Leave(ctx, info.SessionID, "u1")
+	if layout, err := service.Layout(ctx, info.SessionID, info.HostToken, "u1"); err != nil || !slices.Equal(layout.Removed["capital"], half.RemovedOptionIDs) {
+		t.Fatalf("expected the layout to keep the removed options %v after leaving, got %+v: %v", half.RemovedOptionIDs, layout.Removed, err)
+	}
+}
+
+func TestShuffledOptionsMapBackToCanonicalIDs(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-lifelines", domain.SessionSettings{ShuffleOptions: true})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	orders := make(map[string]bool)
+	for i := 1; i <= 8; i++ {
+		userID := fmt.Sprintf("u%d", i)
+		_, _ = service.Join(ctx, info.SessionID, userID, "Player "+userID)
+		seen, err := service.PlayerQuestion(ctx, info.SessionID, userID, "capital")
+		if err != nil {
+			t.Fatalf("question failed: %v", err)
+		}
+		again, _ := service.PlayerQuestion(ctx, info.SessionID, userID, "capital")
+		layout, err := service.Layout(ctx, info.SessionID, info.HostToken, userID)
+		if err != nil {
+			t.Fatalf("layout failed: %v", err)
+		}
+		var ids []string
+		right := 0
+		for _, option := range seen.Options {
+			ids = append(ids, option.ID)
+			if option.ID == "paris" {
+				right = option.Position
+			}
+		}
+		if !slices.Equal(ids, optionIDs(again)) || !slices.Equal(ids, layout.Options["capital"]) {
+			t.Fatalf("expected %s to see the same order every time, got %v, %v and %v", userID, ids, optionIDs(again), layout.Options["capital"])
+		}
+		orders[strings.Join(ids, ",")] = true
+
+		_, result, err := service.SubmitAnswer(ctx, info.SessionID, userID, domain.AnswerSubmission{QuestionID: "capital", Position: right})
+		if err != nil || !result.Correct {
+			t.Fatalf("expected position %d to map to the right option for %s, got %+v: %v", right, userID, result, err)
+		}
+	}
+	if len(orders) < 2 {
+		t.Fatalf("expected players to see different orders, got %v", orders)
+	}
+	stats, _ := service.AnswerStats(ctx, info.SessionID, info.HostToken, "capital")
+	if stats.Options[1].OptionID != "paris" || stats.Options[1].Count != 8 {
+		t.Fatalf("expected stats in canonical order, got %+v", stats.Options)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "river", Position: 4}); err != domain.ErrOptionNotFound {
+		t.Fatalf("expected an out of range position to be refused, got %v", err)
+	}
+
+	plain, _ := service.CreateSession(ctx, "quiz-lifelines", domain.SessionSettings{})
+	seen, _ := service.PlayerQuestion(ctx, plain.SessionID, "u1", "capital")
+	if !slices.Equal(optionIDs(seen), []string{"lyon", "paris", "nice", "lille"}) {
+		t.Fatalf("expected the quiz's order without shuffling, got %v", optionIDs(seen))
+	}
+}
+
+func optionIDs(question domain.PlayerQuestion) []string {
+	var ids []string
+	for _, option := range question.Options {
+		ids = append(ids, option.ID)
+	}
+	return ids
+}
+
+func TestSelfPacedSessions(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-points", domain.SessionSettings{Mode: domain.ModeSelfPaced, QuestionTimeLimitMs: 40})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if info.Settings.Deadline == nil || !info.ExpiresAt.After(*info.Settings.Deadline) {
+		t.Fatalf("expected the session to outlast a default deadline, got %+v", info)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Player u1")
+	step, err := service.NextQuestion(ctx, info.SessionID, "u1")
+	if err != nil || step.Question == nil || step.Question.ID != "q1" || step.Number != 1 || step.Total != 2 || step.QuestionEndsAt == nil {
+		t.Fatalf("expected the first question with its own clock, got %+v: %v", step, err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q2", OptionID: "o2"}); err != domain.ErrQuestionNotReached {
+		t.Fatalf("expected answers to questions ahead to be refused, got %v", err)
+	}
+	if _, err := service.PlayerQuestion(ctx, info.SessionID, "u1", "q2"); err != domain.ErrQuestionNotReached {
+		t.Fatalf("expected questions ahead to stay hidden, got %v", err)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+
+	// Leaving keeps the score and progress; coming back resumes at the next question.
+	service.Leave(ctx, info.SessionID, "u1")
+	lb, err := service.Join(ctx, info.SessionID, "u1", "Player u1")
+	if err != nil || len(lb.Entries) != 1 || lb.Entries[0].Score != 100 {
+		t.Fatalf("expected u1 to keep their score after leaving, got %+v: %v", lb, err)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u2", "Player u2")
+	if step, _ := service.NextQuestion(ctx, info.SessionID, "u2"); step.Question == nil || step.Question.ID != "q1" {
+		t.Fatalf("expected u2 to start on their own, got %+v", step)
+	}
+	if step, _ := service.NextQuestion(ctx, info.SessionID, "u1"); step.Question == nil || step.Question.ID != "q2" || step.Number != 2 {
+		t.Fatalf("expected u1 to resume at q2, got %+v", step)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q2", OptionID: "o2"}); err != domain.ErrTimeUp {
+		t.Fatalf("expected u1's own time limit to apply, got %v", err)
+	}
+	step, err = service.NextQuestion(ctx, info.SessionID, "u1")
+	if err != nil || !step.Finished || step.Question != nil || step.Score != 100 {
+		t.Fatalf("expected u1 to be finished, got %+v: %v", step, err)
+	}
+	lb, _ = service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	for _, entry := range lb.Entries {
+		if entry.Finished != (entry.UserID == "u1") {
+			t.Fatalf("expected only u1 to be marked finished, got %+v", lb.Entries)
+		}
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	closing, err := service.CreateSession(ctx, "quiz-points", domain.SessionSettings{Mode: domain.ModeSelfPaced, Deadline: &deadline})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, _ = service.Join(ctx, closing.SessionID, "u1", "Player u1")
+	_, _ = service.NextQuestion(ctx, closing.SessionID, "u1")
+	time.Sleep(60 * time.Millisecond)
+	if _, _, err := service.SubmitAnswer(ctx, closing.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != domain.ErrAssignmentClosed {
+		t.Fatalf("expected answers after the deadline to be refused, got %v", err)
+	}
+	if _, err := service.Join(ctx, closing.SessionID, "u2", "Player u2"); err != domain.ErrAssignmentClosed {
+		t.Fatalf("expected joins after the deadline to be refused, got %v", err)
+	}
+
+	if _, err := service.CreateSession(ctx, "quiz-points", domain.SessionSettings{Mode: domain.ModeSelfPaced, Deadline: &deadline}); !errors.Is(err, domain.ErrInvalidSettings) {
+		t.Fatalf("expected a past deadline to be refused, got %v", err)
+	}
+	live := newTestSession(t, service, "quiz-points", domain.SessionSettings{})
+	_, _ = service.Join(ctx, live, "u1", "Player u1")
+	if _, err := service.NextQuestion(ctx, live, "u1"); err != domain.ErrNotSelfPaced {
+		t.Fatalf("expected live sessions to refuse NextQuestion, got %v", err)
+	}
+}
+
+func TestSelfPacedLeaderboardOutlivesDeadline(t *testing.T) {
+	ctx := context.Background()
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithJoinCodes(memory.NewJoinCodeStore(), time.Hour))
+	deadline := time.Now().Add(50 * time.Millisecond)
+	info, err := service.CreateSession(ctx, "quiz-points", domain.SessionSettings{Mode: domain.ModeSelfPaced, Deadline: &deadline})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Player u1")
+	_, _ = service.NextQuestion(ctx, info.SessionID, "u1")
+	if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	service.Leave(ctx, info.SessionID, "u1")
+	time.Sleep(60 * time.Millisecond)
+
+	// Creating another session sweeps idle ones; the closed assignment must survive it.
+	newTestSession(t, service, "quiz-points", domain.SessionSettings{})
+	lb, err := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+	if err != nil || len(lb.Entries) != 1 || lb.Entries[0].Score != 100 {
+		t.Fatalf("expected the final leaderboard after the deadline, got %+v: %v", lb, err)
+	}
+}
+
+func TestAdaptiveSessionsStopOnceTheEstimateIsPrecise(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-adaptive", domain.SessionSettings{Mode: domain.ModeAdaptive, AdaptiveSE: 0.5})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Player u1")
+	// u1 gets right every question up to difficulty 1 and nothing harder.
+	var asked []string
+	var step domain.SelfPacedStep
+	for {
+		step, err = service.NextQuestion(ctx, info.SessionID, "u1")
+		if err != nil {
+			t.Fatalf("next failed: %v", err)
+		}
+		if step.Finished {
+			break
+		}
+		asked = append(asked, step.Question.ID)
+		difficulty := adaptiveDifficulty(step.Question.ID)
+		optionID := "o1"
+		if difficulty <= 1 {
+			optionID = "o2"
+		}
+		_, result, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: step.Question.ID, OptionID: optionID})
+		if err != nil || result.Ability == nil || result.Ability.Answered != len(asked) {
+			t.Fatalf("expected the answer to come with a running estimate, got %+v: %v", result, err)
+		}
+	}
+	if asked[0] != "i6" || adaptiveDifficulty(asked[1]) <= 0 {
+		t.Fatalf("expected the first pick to match an average learner and the next to be harder, got %v", asked)
+	}
+	if len(asked) >= 12 || step.Ability == nil || step.Ability.StandardError >= 0.5 {
+		t.Fatalf("expected the test to stop early on a precise estimate, asked %v and got %+v", asked, step.Ability)
+	}
+	if step.Ability.Theta < 0.5 || step.Ability.Theta > 1.7 {
+		t.Fatalf("expected an ability near 1, got %+v", step.Ability)
+	}
+	if step.Score == 0 {
+		t.Fatalf("expected the raw score alongside the estimate, got %+v", step)
+	}
+
+	short, _ := service.CreateSession(ctx, "quiz-adaptive", domain.SessionSettings{Mode: domain.ModeAdaptive, AdaptiveMaxQuestions: 3})
+	_, _ = service.Join(ctx, short.SessionID, "u1", "Player u1")
+	for i := 0; i < 3; i++ {
+		step, _ := service.NextQuestion(ctx, short.SessionID, "u1")
+		if step.Total != 3 || step.Question == nil {
+			t.Fatalf("expected question %d of 3, got %+v", i+1, step)
+		}
+		_, _, _ = service.SubmitAnswer(ctx, short.SessionID, "u1", domain.AnswerSubmission{QuestionID: step.Question.ID, OptionID: "o2"})
+	}
+	if step, _ := service.NextQuestion(ctx, short.SessionID, "u1"); !step.Finished || step.Ability.Answered != 3 {
+		t.Fatalf("expected the question limit to end the test, got %+v", step)
+	}
+}
+
+// adaptiveDifficulty is the IRT difficulty of quiz-adaptive's question id: i1 is -2.5 and each
+// next one 0.5 harder.
+func adaptiveDifficulty(id string) float64 {
+	var n int
+	fmt.Sscanf(id, "i%d", &n)
+	return -2.5 + 0.5*float64(n-1)
+}
+
+func TestItemAnalytics(t *testing.T) {
+	ctx := context.Background()
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithAnswerLog(memory.NewAnswerStore()))
+	first := newTestSession(t, service, "quiz-points", domain.SessionSettings{AnswerChange: domain.AnswerChangeAllow})
+	second := newTestSession(t, service, "quiz-points", domain.SessionSettings{})
+	answers := []struct {
+		sessionID, userID, q1, q2 string
+	}{
+		{first, "u1", "o2", "o2"},
+		{first, "u2", "o2", "o2"},
+		{first, "u3", "o2", "o1"},
+		{second, "u1", "o1", "o2"}, // same user in another session counts separately
+	}
+	for _, a := range answers {
+		_, _ = service.Join(ctx, a.sessionID, a.userID, "Player "+a.userID)
+		for questionID, optionID := range map[string]string{"q1": a.q1, "q2": a.q2} {
+			if _, _, err := service.SubmitAnswer(ctx, a.sessionID, a.userID, domain.AnswerSubmission{QuestionID: questionID, OptionID: optionID}); err != nil {
+				t.Fatalf("submit failed: %v", err)
+			}
+		}
+	}
+	// A changed answer replaces the recorded one.
+	if _, _, err := service.SubmitAnswer(ctx, first, "u3", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o1"}); err != nil {
+		t.Fatalf("change failed: %v", err)
+	}
+
+	analytics, err := service.ItemAnalytics(ctx, "quiz-points", 0)
+	if err != nil {
+		t.Fatalf("analytics failed: %v", err)
+	}
+	if analytics.Respondents != 4 || len(analytics.Items) != 2 {
+		t.Fatalf("unexpected analytics: %+v", analytics)
+	}
+	q1, q2 := analytics.Items[0], analytics.Items[1]
+	if q1.Responses != 4 || q1.PValue != 0.5 || q2.PValue != 0.75 {
+		t.Fatalf("unexpected p-values: %+v %+v", q1, q2)
+	}
+	if q1.PointBiserial != 0.577 || q2.PointBiserial != 0.577 {
+		t.Fatalf("unexpected point-biserials: %v %v", q1.PointBiserial, q2.PointBiserial)
+	}
+	if q1.Options[0] != (domain.DistractorStats{OptionID: "o1", Count: 2, Rate: 0.5}) || !q1.Options[1].Correct {
+		t.Fatalf("unexpected distractor rates: %+v", q1.Options)
+	}
+}
+
+func TestQuestionBankDraws(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService()
+	info, err := service.CreateSession(ctx, "quiz-bank", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if info.Settings.Seed == 0 || len(info.QuestionIDs) != 4 || info.QuestionIDs[0] != "intro" {
+		t.Fatalf("expected the quiz's own question and three draws with a recorded seed, got %+v", info)
+	}
+	for _, id := range info.QuestionIDs[1:3] {
+		if !strings.HasPrefix(id, "easy-vocab") {
+			t.Fatalf("expected two easy vocab questions first, got %v", info.QuestionIDs)
+		}
+	}
+	if !strings.HasPrefix(info.QuestionIDs[3], "hard-grammar") {
+		t.Fatalf("expected a hard grammar question last, got %v", info.QuestionIDs)
+	}
+
+	same, err := service.CreateSession(ctx, "quiz-bank", domain.SessionSettings{Seed: info.Settings.Seed})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !slices.Equal(same.QuestionIDs, info.QuestionIDs) {
+		t.Fatalf("expected the same seed to draw %v, got %v", info.QuestionIDs, same.QuestionIDs)
+	}
+
+	_, _ = service.Join(ctx, info.SessionID, "u1", "Player u1")
+	if _, result, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: info.QuestionIDs[1], OptionID: "o2"}); err != nil || !result.Correct {
+		t.Fatalf("expected drawn questions to be answerable, got %+v: %v", result, err)
+	}
+	for _, id := range []string{"easy-vocab-1", "easy-vocab-2", "easy-vocab-3"} {
+		if slices.Contains(info.QuestionIDs, id) {
+			continue
+		}
+		if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: id, OptionID: "o2"}); err != domain.ErrQuestionNotFound {
+			t.Fatalf("expected questions left in the bank not to be part of the session, got %v", err)
+		}
+	}
+
+	if _, err := service.CreateSession(ctx, "quiz-bank-too-big", domain.SessionSettings{}); !errors.Is(err, domain.ErrNotEnoughQuestions) {
+		t.Fatalf("expected a draw the bank can't fill to fail, got %v", err)
+	}
+}
+
+func TestSessionsStayOnTheQuizVersionTheyStartedWith(t *testing.T) {
+	ctx := context.Background()
+	loader := &versionedLoader{}
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: drillQuestions(2)})
+	repo := memory.NewQuizRepository(loader, time.Hour)
+	service := app.NewQuizService(memory.NewSessionStore(), repo)
+
+	before, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil || before.QuizVersion != 1 {
+		t.Fatalf("expected a session of version 1, got %+v, %v", before, err)
+	}
+	changed := drillQuestions(3)
+	changed[0].Options[0].Correct, changed[0].Options[1].Correct = true, false
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: changed})
+	_ = repo.Invalidate(ctx, "quiz-1")
+	after, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil || after.QuizVersion != 2 {
+		t.Fatalf("expected a session of version 2, got %+v, %v", after, err)
+	}
+
+	for _, info := range []domain.SessionInfo{before, after} {
+		if _, err := service.Join(ctx, info.SessionID, "u1", "Alice"); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	if _, result, _ := service.SubmitAnswer(ctx, before.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); !result.Correct {
+		t.Fatalf("expected the running session to keep grading by version 1, got %+v", result)
+	}
+	if _, _, err := service.SubmitAnswer(ctx, before.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q3", OptionID: "o2"}); !errors.Is(err, domain.ErrQuestionNotFound) {
+		t.Fatalf("expected version 2's new question to be missing from the running session, got %v", err)
+	}
+	if _, result, _ := service.SubmitAnswer(ctx, after.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"}); result.Correct {
+		t.Fatalf("expected the new session to grade by version 2, got %+v", result)
+	}
+}
+
+func TestItemAnalyticsPerQuizVersion(t *testing.T) {
+	ctx := context.Background()
+	loader := &versionedLoader{}
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: drillQuestions(1)})
+	repo := memory.NewQuizRepository(loader, time.Hour)
+	service := app.NewQuizService(memory.NewSessionStore(), repo, app.WithAnswerLog(memory.NewAnswerStore()))
+	answer := func(optionID string) {
+		t.Helper()
+		info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		_, _ = service.Join(ctx, info.SessionID, "u1", "Alice")
+		if _, _, err := service.SubmitAnswer(ctx, info.SessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: optionID}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+	answer("o2")
+	// Version 2 makes o1 the right answer to q1.
+	changed := drillQuestions(1)
+	changed[0].Options[0].Correct, changed[0].Options[1].Correct = true, false
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: changed})
+	_ = repo.Invalidate(ctx, "quiz-1")
+	answer("o1")
+	answer("o2")
+
+	latest, err := service.ItemAnalytics(ctx, "quiz-1", 0)
+	if err != nil {
+		t.Fatalf("analytics: %v", err)
+	}
+	if latest.Version != 2 || latest.Respondents != 2 || latest.Items[0].PValue != 0.5 || !latest.Items[0].Options[0].Correct {
+		t.Fatalf("expected version 2's answers graded by version 2, got %+v", latest)
+	}
+	first, err := service.ItemAnalytics(ctx, "quiz-1", 1)
+	if err != nil {
+		t.Fatalf("analytics: %v", err)
+	}
+	if first.Version != 1 || first.Respondents != 1 || first.Items[0].PValue != 1 || !first.Items[0].Options[1].Correct {
+		t.Fatalf("expected only version 1's answer, got %+v", first)
+	}
+}
+
+func TestAnswersAreLoggedInTheBackground(t *testing.T) {
+	ctx := context.Background()
+	answers := &gatedAnswerLog{AnswerStore: memory.NewAnswerStore(), gate: make(chan struct{})}
+	service := app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithAnswerLog(answers))
+	sessionID := newTestSession(t, service, "quiz-points", domain.SessionSettings{})
+	_, _ = service.Join(ctx, sessionID, "u1", "Alice")
+
+	submitted := make(chan error, 1)
+	go func() {
+		_, _, err := service.SubmitAnswer(ctx, sessionID, "u1", domain.AnswerSubmission{QuestionID: "q1", OptionID: "o2"})
+		submitted <- err
+	}()
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected SubmitAnswer not to wait for the answer log")
+	}
+
+	close(answers.gate)
+	service.Close()
+	records, _ := answers.Answers(ctx, "quiz-points", 0)
+	if len(records) != 1 || records[0].OptionID != "o2" {
+		t.Fatalf("expected Close to write the pending answer, got %+v", records)
+	}
+}
+
+// gatedAnswerLog holds every write until gate is closed.
+type gatedAnswerLog struct {
+	*memory.AnswerStore
+	gate chan struct{}
+}
+
+func (l *gatedAnswerLog) Record(ctx context.Context, record domain.AnswerRecord) error {
+	<-l.gate
+	return l.AnswerStore.Record(ctx, record)
+}
+
+func TestDiffQuiz(t *testing.T) {
+	ctx := context.Background()
+	loader := &versionedLoader{}
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: drillQuestions(3)})
+	next := drillQuestions(4)
+	next[0].Points = 2
+	next[0], next[1] = next[1], next[0]
+	next = slices.Delete(next, 2, 3)
+	next[2].Hints = []domain.Hint{{Text: "Not o1"}}
+	loader.publish(domain.Quiz{ID: "quiz-1", Questions: next, Settings: domain.QuizSettings{BroadcastWindowMs: 250}})
+	service := app.NewQuizService(memory.NewSessionStore(), memory.NewQuizRepository(loader, time.Minute))
+
+	diff, err := service.DiffQuiz(ctx, "quiz-1", 1, 2)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	var got []string
+	for _, change := range diff.Changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		`quiz settings changed: {} -> {"broadcastWindowMs":250}`,
+		`quiz order changed: ["q1","q2"] -> ["q2","q1"]`,
+		`question q1 points changed: 1 -> 2`,
+		`question q4 added`,
+		`question q3 removed`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+	if _, err := service.DiffQuiz(ctx, "quiz-1", 1, 3); !errors.Is(err, domain.ErrQuizVersionNotFound) {
+		t.Fatalf("expected ErrQuizVersionNotFound, got %v", err)
+	}
+}
+
+// versionedLoader keeps every published version of its quizzes, like the database sources do.
+type versionedLoader struct {
+	mu       sync.Mutex
+	versions map[string][]domain.Quiz
+}
+
+func (l *versionedLoader) publish(quiz domain.Quiz) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.versions == nil {
+		l.versions = make(map[string][]domain.Quiz)
+	}
+	quiz.Version = len(l.versions[quiz.ID]) + 1
+	l.versions[quiz.ID] = append(l.versions[quiz.ID], quiz)
+}
+
+func (l *versionedLoader) LoadQuiz(ctx context.Context, quizID string) (domain.Quiz, error) {
+	l.mu.Lock()
+	n := len(l.versions[quizID])
+	l.mu.Unlock()
+	if n == 0 {
+		return domain.Quiz{}, domain.ErrQuizNotFound
+	}
+	return l.LoadQuizVersion(ctx, quizID, n)
+}
+
+func (l *versionedLoader) LoadQuizVersion(_ context.Context, quizID string, version int) (domain.Quiz, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	versions := l.versions[quizID]
+	if version < 1 || version > len(versions) {
+		return domain.Quiz{}, domain.ErrQuizVersionNotFound
+	}
+	return versions[version-1], nil
+}
+
 func newTestService() *app.QuizService {
-	sessionStore := memory.NewSessionStore()
-	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(map[string]domain.Quiz{
+	return app.NewQuizService(memory.NewSessionStore(), newTestQuizRepo(), app.WithQuestionBanks(newTestBanks()))
+}
+
+func newTestBanks() app.QuestionBankRepository {
+	var questions []domain.Question
+	add := func(prefix, difficulty string, n int, tags ...string) {
+		for i, q := range drillQuestions(n) {
+			q.ID = fmt.Sprintf("%s-%d", prefix, i+1)
+			q.Tags, q.Difficulty = tags, difficulty
+			questions = append(questions, q)
+		}
+	}
+	add("easy-vocab", domain.DifficultyEasy, 3, "vocab")
+	add("hard-vocab", domain.DifficultyHard, 2, "vocab")
+	add("hard-grammar", domain.DifficultyHard, 2, "grammar", "tenses")
+	return memory.NewStaticQuestionBanks(map[string]domain.QuestionBank{
+		"english": {ID: "english", Questions: questions},
+	})
+}
+
+// newTestSession creates a session the way a host would and returns its ID.
+func newTestSession(t *testing.T, service *app.QuizService, quizID string, settings domain.SessionSettings) string {
+	t.Helper()
+	info, err := service.CreateSession(context.Background(), quizID, settings)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return info.SessionID
+}
+
+// drillQuestions returns n one-point questions q1..qn whose right answer is always o2.
+func drillQuestions(n int) []domain.Question {
+	questions := make([]domain.Question, n)
+	for i := range questions {
+		questions[i] = domain.Question{
+			ID:     fmt.Sprintf("q%d", i+1),
+			Prompt: "Select the right option",
+			Options: []domain.Option{
+				{ID: "o1", Text: "Wrong", Correct: false},
+				{ID: "o2", Text: "Right", Correct: true},
+			},
+			Points: 1,
+		}
+	}
+	return questions
+}
+
+func newTestQuizRepo() app.QuizRepository {
+	return memory.NewQuizRepository(memory.NewStaticQuizLoader(map[string]domain.Quiz{
 		"quiz-1": {
-			ID: "quiz-1",
+			ID:        "quiz-1",
+			Questions: drillQuestions(10),
+		},
+		"quiz-points": {
+			ID: "quiz-points",
+			Questions: []domain.Question{
+				{ID: "q1", Options: []domain.Option{{ID: "o1"}, {ID: "o2", Correct: true}}, Points: 100},
+				{ID: "q2", Options: []domain.Option{{ID: "o1"}, {ID: "o2", Correct: true}}, Points: 100},
+			},
+		},
+		"quiz-poll": {
+			ID: "quiz-poll",
+			Questions: []domain.Question{
+				{ID: "graded", Options: []domain.Option{{ID: "o1"}, {ID: "o2", Correct: true}}},
+				{ID: "mood", Type: domain.QuestionPoll, Options: []domain.Option{{ID: "happy"}, {ID: "meh"}}},
+				{ID: "topics", Type: domain.QuestionMultiPoll, Options: []domain.Option{{ID: "maths"}, {ID: "art"}, {ID: "music"}}},
+				{ID: "confidence", Type: domain.QuestionRating, Scale: &domain.RatingScale{Min: 1, Max: 3}},
+				{ID: "oneWord", Type: domain.QuestionWordCloud},
+			},
+		},
+		"quiz-lifelines": {
+			ID: "quiz-lifelines",
 			Questions: []domain.Question{
 				{
-					ID:     "q1",
-					Prompt: "Select the right option",
-					Options: []domain.Option{
-						{ID: "o1", Text: "Wrong", Correct: false},
-						{ID: "o2", Text: "Right", Correct: true},
-					},
-					Points: 1,
+					ID:          "capital",
+					Options:     []domain.Option{{ID: "lyon"}, {ID: "paris", Correct: true}, {ID: "nice"}, {ID: "lille"}},
+					Points:      100,
+					Explanation: "Paris has been the capital since 987.",
+					Hints:       []domain.Hint{{Text: "It's on the Seine", Cost: 20}, {Text: "The Eiffel Tower is there", Cost: 30}},
+				},
+				{ID: "river", Options: []domain.Option{{ID: "seine", Correct: true}, {ID: "rhone"}, {ID: "loire"}}, Points: 100},
+			},
+		},
+		"quiz-bank": {
+			ID:        "quiz-bank",
+			Questions: []domain.Question{{ID: "intro", Options: []domain.Option{{ID: "o1", Correct: true}}}},
+			Draws: []domain.QuestionDraw{
+				{Bank: "english", Tags: []string{"vocab"}, Difficulty: domain.DifficultyEasy, Count: 2},
+				{Bank: "english", Tags: []string{"grammar", "tenses"}, Count: 1},
+			},
+		},
+		"quiz-bank-too-big": {
+			ID:    "quiz-bank-too-big",
+			Draws: []domain.QuestionDraw{{Bank: "english", Tags: []string{"vocab"}, Difficulty: domain.DifficultyHard, Count: 3}},
+		},
+		"quiz-adaptive": {
+			ID: "quiz-adaptive",
+			Questions: func() []domain.Question {
+				questions := drillQuestions(12)
+				for i := range questions {
+					questions[i].ID = fmt.Sprintf("i%d", i+1)
+					questions[i].IRT = &domain.ItemParams{Discrimination: 2, Difficulty: adaptiveDifficulty(questions[i].ID)}
+				}
+				return questions
+			}(),
+		},
+		"quiz-teams": {
+			ID:        "quiz-teams",
+			Questions: drillQuestions(4),
+			Settings: domain.QuizSettings{
+				Teams: &domain.TeamSettings{
+					Teams:      []string{"red", "blue"},
+					Aggregate:  domain.TeamAggregateAverage,
+					AutoAssign: true,
 				},
 			},
 		},
 	}), 5*time.Minute)
-	return app.NewQuizService(sessionStore, quizRepo)
 }
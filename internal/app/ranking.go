@@ -0,0 +1,303 @@
+package app
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+const (
+	rankMaxLevel = 32
+	rankP        = 0.25
+)
+
+// rankKey orders participants: score desc, then earliest LastUpdated, then display name, then userID.
+type rankKey struct {
+	score   int
+	updated time.Time
+	name    string
+	userID  string
+}
+
+func keyOf(p *domain.Participant) rankKey {
+	return rankKey{score: p.Score, updated: p.LastUpdated, name: p.DisplayName, userID: p.UserID}
+}
+
+func (a rankKey) less(b rankKey) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if !a.updated.Equal(b.updated) {
+		return a.updated.Before(b.updated)
+	}
+	if a.name != b.name {
+		return a.name < b.name
+	}
+	return a.userID < b.userID
+}
+
+// rankIndex is an indexable skip list: every link records how many positions it spans,
+// so rank lookups and positional access are O(log n) and ordered reads need no sorting.
+type rankIndex struct {
+	head   *rankNode
+	level  int
+	length int
+	nodes  map[string]*rankNode
+	marks  map[string]rankMark
+	// departed holds the marked rank of participants removed since the last mark, since
+	// everyone below them moves up.
+	departed map[string]int
+	epoch    int // how many marks have been taken
+	rnd      *rand.Rand
+}
+
+// rankMark remembers what a participant looked like when they were last marked, to report movement.
+type rankMark struct {
+	rank  int
+	delta int
+	epoch int // the mark that recorded delta; later marks left the participant where they were
+	score int
+	name  string
+	team  string
+}
+
+type rankNode struct {
+	key         rankKey
+	participant *domain.Participant
+	next        []rankLink
+}
+
+type rankLink struct {
+	node *rankNode
+	span int
+}
+
+func newRankIndex() *rankIndex {
+	return &rankIndex{
+		head:     &rankNode{next: make([]rankLink, rankMaxLevel)},
+		level:    1,
+		nodes:    make(map[string]*rankNode),
+		marks:    make(map[string]rankMark),
+		departed: make(map[string]int),
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (r *rankIndex) len() int {
+	return r.length
+}
+
+func (r *rankIndex) randomLevel() int {
+	level := 1
+	for level < rankMaxLevel && r.rnd.Float64() < rankP {
+		level++
+	}
+	return level
+}
+
+// upsert (re)positions a participant; call it after mutating any field that feeds the key.
+func (r *rankIndex) upsert(p *domain.Participant) {
+	if node, ok := r.nodes[p.UserID]; ok {
+		if node.key == keyOf(p) {
+			return
+		}
+		r.unlink(node)
+	}
+	r.insert(p)
+}
+
+func (r *rankIndex) insert(p *domain.Participant) {
+	key := keyOf(p)
+	var update [rankMaxLevel]*rankNode
+	var rankAt [rankMaxLevel]int
+
+	x := r.head
+	for i := r.level - 1; i >= 0; i-- {
+		if i < r.level-1 {
+			rankAt[i] = rankAt[i+1]
+		}
+		for x.next[i].node != nil && x.next[i].node.key.less(key) {
+			rankAt[i] += x.next[i].span
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+
+	level := r.randomLevel()
+	if level > r.level {
+		for i := r.level; i < level; i++ {
+			update[i] = r.head
+			r.head.next[i].span = r.length
+		}
+		r.level = level
+	}
+
+	node := &rankNode{key: key, participant: p, next: make([]rankLink, level)}
+	for i := 0; i < level; i++ {
+		node.next[i].node = update[i].next[i].node
+		update[i].next[i].node = node
+		node.next[i].span = update[i].next[i].span - (rankAt[0] - rankAt[i])
+		update[i].next[i].span = rankAt[0] - rankAt[i] + 1
+	}
+	for i := level; i < r.level; i++ {
+		update[i].next[i].span++
+	}
+	r.length++
+	r.nodes[p.UserID] = node
+}
+
+// remove drops a participant along with its rank history; the next mark still moves up the
+// participants who were below them.
+func (r *rankIndex) remove(userID string) {
+	if target, ok := r.nodes[userID]; ok {
+		r.unlink(target)
+		if m, ok := r.marks[userID]; ok {
+			r.departed[userID] = m.rank
+		}
+		delete(r.marks, userID)
+	}
+}
+
+func (r *rankIndex) unlink(target *rankNode) {
+	var update [rankMaxLevel]*rankNode
+	x := r.head
+	for i := r.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && x.next[i].node.key.less(target.key) {
+			x = x.next[i].node
+		}
+		update[i] = x
+	}
+	for i := 0; i < r.level; i++ {
+		if update[i].next[i].node == target {
+			update[i].next[i].span += target.next[i].span - 1
+			update[i].next[i].node = target.next[i].node
+		} else {
+			update[i].next[i].span--
+		}
+	}
+	for r.level > 1 && r.head.next[r.level-1].node == nil {
+		r.level--
+	}
+	r.length--
+	delete(r.nodes, target.participant.UserID)
+}
+
+// rank returns the 1-based position of a participant, or 0 if unknown.
+func (r *rankIndex) rank(userID string) int {
+	target, ok := r.nodes[userID]
+	if !ok {
+		return 0
+	}
+	rank := 0
+	x := r.head
+	for i := r.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && !target.key.less(x.next[i].node.key) {
+			rank += x.next[i].span
+			x = x.next[i].node
+		}
+		if x == target {
+			return rank
+		}
+	}
+	return 0
+}
+
+// at returns the node at a 1-based rank, or nil when out of range.
+func (r *rankIndex) at(rank int) *rankNode {
+	if rank < 1 || rank > r.length {
+		return nil
+	}
+	traversed := 0
+	x := r.head
+	for i := r.level - 1; i >= 0; i-- {
+		for x.next[i].node != nil && traversed+x.next[i].span <= rank {
+			traversed += x.next[i].span
+			x = x.next[i].node
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// ascend calls fn for participants in rank order starting at the 1-based rank from, until fn returns false.
+func (r *rankIndex) ascend(from int, fn func(rank int, p *domain.Participant) bool) {
+	node := r.at(from)
+	for rank := from; node != nil; rank++ {
+		if !fn(rank, node.participant) {
+			return
+		}
+		node = node.next[0].node
+	}
+}
+
+// mark records the current rank and movement since the previous mark of everyone whose rank may
+// have changed: the moved participants and everyone between where they were and where they are
+// now. Joins and departures shift everyone below them, so their span runs to the end of the board.
+// Everyone else kept their rank, so their marks stand and they show no movement. The cost is
+// O(m log n) for m moved participants plus the number of ranks they span.
+func (r *rankIndex) mark(moved []string) {
+	r.epoch++
+	type span struct{ from, to int }
+	spans := make([]span, 0, len(moved)+len(r.departed))
+	add := func(from, to int) {
+		if from > to {
+			from, to = to, from
+		}
+		to = min(to, r.length)
+		if from <= to {
+			spans = append(spans, span{from, to})
+		}
+	}
+	for _, userID := range moved {
+		rank := r.rank(userID)
+		if rank == 0 {
+			continue
+		}
+		if prev, ok := r.marks[userID]; ok {
+			add(prev.rank, rank)
+		} else {
+			add(rank, r.length)
+		}
+	}
+	for _, rank := range r.departed {
+		add(rank, r.length)
+	}
+	clear(r.departed)
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+	next := 1 // the first rank not yet marked
+	for _, s := range spans {
+		from := max(s.from, next)
+		if from > s.to {
+			continue
+		}
+		r.ascend(from, func(rank int, p *domain.Participant) bool {
+			prev, ok := r.marks[p.UserID]
+			delta := 0
+			if ok {
+				delta = prev.rank - rank
+			}
+			r.marks[p.UserID] = rankMark{rank: rank, delta: delta, epoch: r.epoch, score: p.Score, name: p.DisplayName, team: p.Team}
+			return rank < s.to
+		})
+		next = s.to + 1
+	}
+}
+
+// lastMark returns what was recorded for a participant at the last mark.
+func (r *rankIndex) lastMark(userID string) (rankMark, bool) {
+	m, ok := r.marks[userID]
+	return m, ok
+}
+
+// delta returns the movement recorded at the last mark.
+func (r *rankIndex) delta(userID string) int {
+	if m := r.marks[userID]; m.epoch == r.epoch {
+		return m.delta
+	}
+	return 0
+}
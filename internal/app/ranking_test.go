@@ -0,0 +1,204 @@
+package app
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// TestRankIndexMatchesSortOrder drives random joins, scores and leaves and checks the skip list
+// against a plain sort using the leaderboard tie-break rules.
+func TestRankIndexMatchesSortOrder(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		base := time.Unix(0, 0)
+		index := newRankIndex()
+		participants := make(map[string]*domain.Participant)
+
+		for step := 0; step < 300; step++ {
+			userID := fmt.Sprintf("u%d", rnd.Intn(40))
+			// Coarse timestamps and a small name pool force every tie-break rule to be exercised.
+			now := base.Add(time.Duration(rnd.Intn(20)) * time.Second)
+			switch op := rnd.Intn(10); {
+			case op < 3:
+				p, ok := participants[userID]
+				if !ok {
+					p = &domain.Participant{UserID: userID}
+					participants[userID] = p
+				}
+				p.DisplayName = fmt.Sprintf("name-%d", rnd.Intn(5))
+				p.LastUpdated = now
+				index.upsert(p)
+			case op < 9:
+				if p, ok := participants[userID]; ok {
+					p.Score += rnd.Intn(3)
+					p.LastUpdated = now
+					index.upsert(p)
+				}
+			default:
+				delete(participants, userID)
+				index.remove(userID)
+			}
+		}
+
+		expected := sortedReference(participants)
+		if index.len() != len(expected) {
+			t.Fatalf("seed %d: expected %d participants, got %d", seed, len(expected), index.len())
+		}
+		index.ascend(1, func(rank int, p *domain.Participant) bool {
+			if expected[rank-1].UserID != p.UserID {
+				t.Fatalf("seed %d: rank %d expected %s, got %s", seed, rank, expected[rank-1].UserID, p.UserID)
+			}
+			return true
+		})
+		for i, p := range expected {
+			if got := index.rank(p.UserID); got != i+1 {
+				t.Fatalf("seed %d: rank(%s) expected %d, got %d", seed, p.UserID, i+1, got)
+			}
+			if node := index.at(i + 1); node == nil || node.participant.UserID != p.UserID {
+				t.Fatalf("seed %d: at(%d) expected %s", seed, i+1, p.UserID)
+			}
+		}
+	}
+}
+
+// TestRankIndexMarksMatchFullRecount checks that marking only the moved participants gives every
+// participant the same movement as re-ranking the whole board would, and forgets those who left.
+func TestRankIndexMarksMatchFullRecount(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		base := time.Unix(0, 0)
+		index := newRankIndex()
+		participants := make(map[string]*domain.Participant)
+		prevRanks := make(map[string]int)
+
+		for round := 0; round < 40; round++ {
+			moved := make(map[string]bool)
+			for step := rnd.Intn(6); step >= 0; step-- {
+				userID := fmt.Sprintf("u%d", rnd.Intn(30))
+				moved[userID] = true
+				switch op := rnd.Intn(10); {
+				case op < 8:
+					p, ok := participants[userID]
+					if !ok {
+						p = &domain.Participant{UserID: userID, DisplayName: userID}
+						participants[userID] = p
+					}
+					p.Score += rnd.Intn(4)
+					p.LastUpdated = base.Add(time.Duration(rnd.Intn(20)) * time.Second)
+					index.upsert(p)
+				default:
+					// Leaving drops the rank history, even for a quick rejoin.
+					delete(participants, userID)
+					delete(prevRanks, userID)
+					index.remove(userID)
+				}
+			}
+			ids := make([]string, 0, len(moved))
+			for userID := range moved {
+				ids = append(ids, userID)
+			}
+			index.mark(ids)
+
+			ranks := make(map[string]int)
+			for i, p := range sortedReference(participants) {
+				ranks[p.UserID] = i + 1
+				want := 0
+				if prev, ok := prevRanks[p.UserID]; ok {
+					want = prev - (i + 1)
+				}
+				if got := index.delta(p.UserID); got != want {
+					t.Fatalf("seed %d round %d: delta(%s) expected %d, got %d", seed, round, p.UserID, want, got)
+				}
+				if m, _ := index.lastMark(p.UserID); m.rank != i+1 {
+					t.Fatalf("seed %d round %d: mark of %s expected rank %d, got %d", seed, round, p.UserID, i+1, m.rank)
+				}
+			}
+			if len(index.marks) != len(participants) || len(index.departed) != 0 {
+				t.Fatalf("seed %d round %d: expected marks only for the %d participants, got %d", seed, round, len(participants), len(index.marks))
+			}
+			prevRanks = ranks
+		}
+	}
+}
+
+func sortedReference(participants map[string]*domain.Participant) []*domain.Participant {
+	out := make([]*domain.Participant, 0, len(participants))
+	for _, p := range participants {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if !out[i].LastUpdated.Equal(out[j].LastUpdated) {
+			return out[i].LastUpdated.Before(out[j].LastUpdated)
+		}
+		if out[i].DisplayName != out[j].DisplayName {
+			return out[i].DisplayName < out[j].DisplayName
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
+
+func BenchmarkApplyScore10k(b *testing.B) {
+	session := benchSession(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := session.addScore(fmt.Sprintf("u%d", i%10000), 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRankLookup10k(b *testing.B) {
+	session := benchSession(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if session.ranking.rank(fmt.Sprintf("u%d", i%10000)) == 0 {
+			b.Fatal("missing participant")
+		}
+	}
+}
+
+func BenchmarkTop10Of10k(b *testing.B) {
+	session := benchSession(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		top := make([]*domain.Participant, 0, 10)
+		session.ranking.ascend(1, func(_ int, p *domain.Participant) bool {
+			top = append(top, p)
+			return len(top) < 10
+		})
+	}
+}
+
+func BenchmarkSnapshot10k(b *testing.B) {
+	session := benchSession(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = session.leaderboard()
+	}
+}
+
+func benchSession(n int) *Session {
+	tick := time.Unix(0, 0)
+	session := newSessionWithClock("bench", "quiz-bench", func() time.Time {
+		tick = tick.Add(time.Millisecond)
+		return tick
+	})
+	// A long window keeps setup from broadcasting a full snapshot on every insert.
+	session.window = time.Hour
+	for i := 0; i < n; i++ {
+		_ = session.join(fmt.Sprintf("u%d", i), fmt.Sprintf("Player %d", i), "")
+		if _, err := session.addScore(fmt.Sprintf("u%d", i), i%50); err != nil {
+			panic(err)
+		}
+	}
+	return session
+}
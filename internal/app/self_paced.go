@@ -0,0 +1,208 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// pace is a participant's progress through a self-paced session.
+type pace struct {
+	order   []string             // question IDs in the order this participant gets them
+	current int                  // index into order of the question they are on
+	served  map[string]time.Time // questionID -> when the participant was first given it
+	// pool holds the graded questions an adaptive session picks from; order grows one pick at a time.
+	pool []domain.Question
+}
+
+func (s *Session) selfPacedLocked() bool {
+	return s.settings.SelfPaced()
+}
+
+func (s *Session) adaptiveLocked() bool {
+	return s.settings.Mode == domain.ModeAdaptive
+}
+
+// closedLocked reports whether a self-paced session's deadline has passed.
+func (s *Session) closedLocked() bool {
+	return s.selfPacedLocked() && s.settings.Deadline != nil && !s.now().Before(*s.settings.Deadline)
+}
+
+// servedLocked reports when userID was given a self-paced question, which is when its clock started.
+func (s *Session) servedLocked(userID, questionID string) (time.Time, bool) {
+	p := s.paces[userID]
+	if p == nil {
+		return time.Time{}, false
+	}
+	at, ok := p.served[questionID]
+	return at, ok
+}
+
+// reached reports whether userID may see a question: always in live sessions, and once they have
+// been given it in self-paced ones.
+func (s *Session) reached(userID, questionID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.selfPacedLocked() {
+		return true
+	}
+	_, ok := s.servedLocked(userID, questionID)
+	return ok
+}
+
+// next serves the question a self-paced participant is on, starting its clock the first time they
+// get it. Questions they answered or ran out of time on are behind them; a participant with none
+// left gets a finished step and no question.
+func (s *Session) next(userID string, questions []domain.Question) (domain.Question, domain.SelfPacedStep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.selfPacedLocked() {
+		return domain.Question{}, domain.SelfPacedStep{}, domain.ErrNotSelfPaced
+	}
+	participant, ok := s.participants[userID]
+	if !ok {
+		return domain.Question{}, domain.SelfPacedStep{}, domain.ErrParticipantNotFound
+	}
+	if s.closedLocked() {
+		return domain.Question{}, domain.SelfPacedStep{}, domain.ErrAssignmentClosed
+	}
+	p := s.paces[userID]
+	if p == nil {
+		p = &pace{served: make(map[string]time.Time)}
+		if s.adaptiveLocked() {
+			for _, q := range questions {
+				if q.Graded() {
+					p.pool = append(p.pool, q)
+				}
+			}
+		} else {
+			for _, q := range questionOrder(s.id, userID, s.settings, questions) {
+				p.order = append(p.order, q.ID)
+			}
+		}
+		s.paces[userID] = p
+	}
+	s.advanceLocked(participant, p)
+
+	step := domain.SelfPacedStep{
+		SessionID: s.id,
+		Number:    min(p.current+1, len(p.order)),
+		Total:     s.totalLocked(p),
+		Score:     participant.Score,
+		Finished:  participant.Finished,
+	}
+	if s.settings.Deadline != nil {
+		step.Deadline = *s.settings.Deadline
+	}
+	if s.adaptiveLocked() {
+		ability := s.abilityLocked(userID, p)
+		step.Ability = &ability
+	}
+	if participant.Finished {
+		return domain.Question{}, step, nil
+	}
+	id := p.order[p.current]
+	i := slices.IndexFunc(questions, func(q domain.Question) bool { return q.ID == id })
+	if i < 0 {
+		return domain.Question{}, domain.SelfPacedStep{}, domain.ErrQuestionNotFound
+	}
+	servedAt, served := p.served[id]
+	if !served {
+		servedAt = s.now()
+		p.served[id] = servedAt
+		s.startLocked()
+	}
+	if limit := time.Duration(s.settings.QuestionTimeLimitMs) * time.Millisecond; limit > 0 {
+		ends := servedAt.Add(limit)
+		step.QuestionEndsAt = &ends
+	}
+	return questions[i], step, nil
+}
+
+// advanceLocked moves a participant past the questions they are done with, marking them finished
+// on the leaderboard once there are none left.
+func (s *Session) advanceLocked(participant *domain.Participant, p *pace) {
+	limit := time.Duration(s.settings.QuestionTimeLimitMs) * time.Millisecond
+	for p.current < len(p.order) {
+		id := p.order[p.current]
+		servedAt, served := p.served[id]
+		_, answered := s.answers[participant.UserID][id]
+		timedOut := served && limit > 0 && s.now().Sub(servedAt) > limit
+		if !answered && !timedOut {
+			return
+		}
+		p.current++
+	}
+	if s.adaptiveLocked() {
+		if id, ok := s.pickLocked(participant.UserID, p); ok {
+			p.order = append(p.order, id)
+			return
+		}
+	}
+	if !participant.Finished {
+		participant.Finished = true
+		s.dirty[participant.UserID] = struct{}{}
+		s.scheduleBroadcastLocked()
+	}
+}
+
+// totalLocked is how many questions the participant gets; for adaptive sessions it is the most
+// they can get, as the test may stop early.
+func (s *Session) totalLocked(p *pace) int {
+	if !s.adaptiveLocked() {
+		return len(p.order)
+	}
+	if limit := s.settings.AdaptiveMaxQuestions; limit > 0 {
+		return min(limit, len(p.pool))
+	}
+	return len(p.pool)
+}
+
+// pickLocked chooses an adaptive participant's next question: the one that tells the most about
+// a learner of their current ability estimate. It reports false once the estimate is precise
+// enough, the question limit is reached or the pool runs out.
+func (s *Session) pickLocked(userID string, p *pace) (string, bool) {
+	if len(p.order) >= s.totalLocked(p) {
+		return "", false
+	}
+	ability := s.abilityLocked(userID, p)
+	if ability.Answered > 0 && ability.StandardError < s.settings.AdaptiveSE {
+		return "", false
+	}
+	best, bestInfo := "", -1.0
+	for _, q := range p.pool {
+		if slices.Contains(p.order, q.ID) {
+			continue
+		}
+		a, b := itemParams(q)
+		if info := information(ability.Theta, a, b); info > bestInfo {
+			best, bestInfo = q.ID, info
+		}
+	}
+	return best, best != ""
+}
+
+// abilityLocked estimates an adaptive participant's ability from the questions behind them;
+// questions they ran out of time on count as wrong.
+func (s *Session) abilityLocked(userID string, p *pace) domain.Ability {
+	responses := make([]itemResponse, 0, p.current)
+	for _, id := range p.order[:p.current] {
+		i := slices.IndexFunc(p.pool, func(q domain.Question) bool { return q.ID == id })
+		if i < 0 {
+			continue
+		}
+		a, b := itemParams(p.pool[i])
+		responses = append(responses, itemResponse{a: a, b: b, correct: s.answers[userID][id].correct})
+	}
+	return estimateAbility(responses)
+}
+
+// departLocked handles a participant going away on their own. In self-paced sessions they keep
+// their place on the leaderboard and their progress, so they can come back before the deadline.
+func (s *Session) departLocked(userID string) {
+	if !s.selfPacedLocked() {
+		s.leaveLocked(userID)
+	}
+	s.admitWaitingLocked()
+}
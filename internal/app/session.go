@@ -0,0 +1,681 @@
+package app
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// Session is an in-memory representation of one live run of a quiz.
+type Session struct {
+	id           string
+	quizID       string
+	quizVersion  int       // the quiz version pinned when the session was created; zero if unversioned
+	expiresAt    time.Time // sessions linger until then, even when empty
+	settings     domain.SessionSettings
+	hostToken    string
+	createdAt    time.Time
+	now          func() time.Time
+	mu           sync.RWMutex
+	participants map[string]*domain.Participant
+	ranking      *rankIndex
+	teams        *teamBoard // nil unless the quiz enables team play
+	subscribers  map[*subscriber]struct{}
+	conns        map[string][]*connection // userID -> open connections, oldest first
+
+	waiting []*waiter // waiting room, in arrival order
+
+	// Display names: nameKey -> userID, so look-alike names get a numeric suffix.
+	nameOwners    map[string]string
+	maxNameLength int
+
+	// Moderation: bans and forced names outlive the participant leaving.
+	bans        map[string]string // userID -> reason
+	forcedNames map[string]string
+	audit       []domain.ModerationAction
+
+	// questions drawn from question banks when the session was created; nil runs the quiz's own.
+	questions []domain.Question
+
+	// Question state: a session starts when the host opens a question or the first answer lands.
+	started bool
+	opened  map[string]time.Time         // questionID -> when the host opened it
+	answers map[string]map[string]answer // userID -> questionID -> latest answer
+
+	// Answer histograms per question; dirty ones go out with the next broadcast.
+	stats        map[string]*questionStats
+	statsDirty   map[string]struct{}
+	statsPending bool // a results-only flush is scheduled
+
+	lifelines    map[string]map[string]*lifelineUse // userID -> questionID -> lifelines used
+	fiftyFifties map[string][]string                // userID -> questions they used a 50:50 on, kept after they leave
+	paces        map[string]*pace                   // self-paced progress by userID
+
+	// Leaderboard coalescing: at most one broadcast per window, always carrying the latest snapshot.
+	window        time.Duration
+	metrics       *broadcastMetrics
+	lastBroadcast time.Time
+	flushPending  bool
+
+	// Versioning: each broadcast bumps version and records the patch from the previous one.
+	version uint64
+	dirty   map[string]struct{}
+	patches []domain.LeaderboardPatch
+}
+
+// patchHistory bounds how far back a patch subscriber may catch up before falling back to a snapshot.
+const patchHistory = 64
+
+func newSession(id, quizID string) *Session {
+	return newSessionWithClock(id, quizID, time.Now)
+}
+
+// newSessionWithClock allows deterministic timestamps in tests.
+func newSessionWithClock(id, quizID string, now func() time.Time) *Session {
+	return &Session{
+		id:            id,
+		quizID:        quizID,
+		createdAt:     now(),
+		now:           now,
+		participants:  make(map[string]*domain.Participant),
+		ranking:       newRankIndex(),
+		subscribers:   make(map[*subscriber]struct{}),
+		conns:         make(map[string][]*connection),
+		opened:        make(map[string]time.Time),
+		answers:       make(map[string]map[string]answer),
+		stats:         make(map[string]*questionStats),
+		statsDirty:    make(map[string]struct{}),
+		lifelines:     make(map[string]map[string]*lifelineUse),
+		fiftyFifties:  make(map[string][]string),
+		paces:         make(map[string]*pace),
+		bans:          make(map[string]string),
+		forcedNames:   make(map[string]string),
+		nameOwners:    make(map[string]string),
+		maxNameLength: DefaultMaxNameLength,
+		metrics:       &broadcastMetrics{},
+		dirty:         make(map[string]struct{}),
+	}
+}
+
+// configure applies the host's settings right after the session is created. window is the
+// service default, used when the settings don't set their own. maxNameLength bounds suffixed
+// duplicate names.
+func (s *Session) configure(settings domain.SessionSettings, hostToken string, window time.Duration, metrics *broadcastMetrics, maxNameLength int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = settings
+	s.hostToken = hostToken
+	s.window = window
+	s.maxNameLength = maxNameLength
+	if settings.BroadcastWindowMs > 0 {
+		s.window = time.Duration(settings.BroadcastWindowMs) * time.Millisecond
+	}
+	if metrics != nil {
+		s.metrics = metrics
+	}
+	if settings.Teams != nil && len(settings.Teams.Teams) > 0 {
+		s.teams = newTeamBoard(*settings.Teams)
+	}
+}
+
+// useQuestions pins the questions drawn for the session.
+func (s *Session) useQuestions(questions []domain.Question) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.questions = questions
+}
+
+func (s *Session) pinVersion(version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quizVersion = version
+}
+
+func (s *Session) drawnQuestions() []domain.Question {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.questions
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// QuizID returns the quiz this session runs.
+func (s *Session) QuizID() string {
+	return s.quizID
+}
+
+// QuizVersion returns the quiz version the session is pinned to, or zero if the quiz is unversioned.
+func (s *Session) QuizVersion() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quizVersion
+}
+
+// Settings returns the settings the session was created with.
+func (s *Session) Settings() domain.SessionSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// SetExpiry keeps the session alive while empty until the given time.
+func (s *Session) SetExpiry(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresAt = at
+}
+
+// Idle reports whether the session can be discarded: nobody is in it or waiting for it, and it
+// is past its expiry. Self-paced participants stay on the board after leaving, so there it is
+// open connections that count.
+func (s *Session) Idle() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	present := len(s.participants)
+	if s.selfPacedLocked() {
+		present = len(s.conns)
+	}
+	return present == 0 && len(s.waiting) == 0 && !s.now().Before(s.expiresAt)
+}
+
+// join adds or refreshes a participant without going through the waiting room.
+// Newcomers are subject to the session's late-join policy, capacity and admission mode.
+func (s *Session) join(userID, displayName, team string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, banned := s.bans[userID]; banned {
+		return domain.ErrBanned
+	}
+	if _, ok := s.participants[userID]; !ok {
+		if err := s.lateJoinLocked(); err != nil {
+			return err
+		}
+		if s.settings.Admission == domain.AdmissionHost {
+			return domain.ErrAdmissionRequired
+		}
+		if !s.hasRoomLocked() || len(s.waiting) > 0 {
+			return domain.ErrSessionFull
+		}
+	}
+	return s.joinLocked(userID, displayName, team)
+}
+
+func (s *Session) lateJoinLocked() error {
+	if s.selfPacedLocked() {
+		if s.closedLocked() {
+			return domain.ErrAssignmentClosed
+		}
+		return nil
+	}
+	if s.started && s.settings.LateJoin == domain.LateJoinDeny {
+		return domain.ErrLateJoinClosed
+	}
+	return nil
+}
+
+func (s *Session) hasRoomLocked() bool {
+	limit := s.settings.MaxParticipants
+	return limit <= 0 || len(s.participants) < limit
+}
+
+// joinLocked adds or refreshes a participant. In team sessions an empty team keeps a returning
+// participant's current team, or auto-assigns a newcomer when the quiz allows it.
+func (s *Session) joinLocked(userID, displayName, team string) error {
+	if forced, ok := s.forcedNames[userID]; ok {
+		displayName = forced
+	}
+	displayName = s.uniqueNameLocked(userID, displayName)
+	participant, ok := s.participants[userID]
+	if s.teams != nil {
+		if team == "" && ok {
+			team = participant.Team
+		}
+		resolved, err := s.teams.resolve(team)
+		if err != nil {
+			return err
+		}
+		team = resolved
+	} else {
+		team = ""
+	}
+
+	now := s.now()
+	if ok {
+		participant.LastUpdated = now
+	} else {
+		participant = &domain.Participant{
+			UserID:      userID,
+			Score:       0,
+			LastUpdated: now,
+		}
+		s.participants[userID] = participant
+	}
+	s.setNameLocked(participant, displayName)
+	s.setTeamLocked(participant, team)
+	s.ranking.upsert(participant)
+	s.dirty[userID] = struct{}{}
+	s.scheduleBroadcastLocked()
+	return nil
+}
+
+func (s *Session) setTeamLocked(participant *domain.Participant, team string) {
+	if s.teams == nil || participant.Team == team {
+		return
+	}
+	s.teams.remove(participant.Team, participant.UserID)
+	s.teams.add(team, participant.UserID)
+	participant.Team = team
+	s.dirty[participant.UserID] = struct{}{}
+}
+
+// assignTeam moves a participant onto a specific team.
+func (s *Session) assignTeam(userID, team string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.teams == nil {
+		return domain.ErrTeamNotFound
+	}
+	participant, ok := s.participants[userID]
+	if !ok {
+		return domain.ErrParticipantNotFound
+	}
+	if _, err := s.teams.resolve(team); err != nil || team == "" {
+		return domain.ErrTeamNotFound
+	}
+	s.setTeamLocked(participant, team)
+	s.scheduleBroadcastLocked()
+	return nil
+}
+
+// balanceTeams evens out team sizes (to within one member), moving as few participants as possible.
+// Movers are taken from the largest team in userID order so the result is deterministic.
+func (s *Session) balanceTeams() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.teams == nil {
+		return domain.ErrTeamNotFound
+	}
+	for {
+		largest, smallest := s.teams.largest(), s.teams.smallest()
+		if len(s.teams.members[largest])-len(s.teams.members[smallest]) <= 1 {
+			break
+		}
+		members := make([]string, 0, len(s.teams.members[largest]))
+		for userID := range s.teams.members[largest] {
+			members = append(members, userID)
+		}
+		sort.Strings(members)
+		s.setTeamLocked(s.participants[members[0]], smallest)
+	}
+	s.scheduleBroadcastLocked()
+	return nil
+}
+
+// addScore adds delta points to a participant and returns their new total.
+func (s *Session) addScore(userID string, delta int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	participant, ok := s.participants[userID]
+	if !ok {
+		return 0, domain.ErrParticipantNotFound
+	}
+	s.addScoreLocked(participant, delta)
+	return participant.Score, nil
+}
+
+// addScoreLocked updates the participant and repositions them in the rank index in O(log n).
+func (s *Session) addScoreLocked(participant *domain.Participant, delta int) {
+	participant.Score += delta
+	participant.LastUpdated = s.now()
+	s.ranking.upsert(participant)
+	s.dirty[participant.UserID] = struct{}{}
+	s.scheduleBroadcastLocked()
+}
+
+// leave drops the participant together with their answers; rejoining starts from zero.
+// The freed slot goes to the head of the waiting room. Self-paced participants keep their
+// progress instead.
+func (s *Session) leave(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.departLocked(userID)
+}
+
+func (s *Session) leaveLocked(userID string) {
+	participant, ok := s.participants[userID]
+	if !ok {
+		return
+	}
+	if s.teams != nil {
+		s.teams.remove(participant.Team, userID)
+	}
+	if key := nameKey(participant.DisplayName); s.nameOwners[key] == userID {
+		delete(s.nameOwners, key)
+	}
+	delete(s.participants, userID)
+	for questionID, a := range s.answers[userID] {
+		s.countAnswerLocked(questionID, a, -1)
+	}
+	delete(s.answers, userID)
+	delete(s.lifelines, userID)
+	delete(s.paces, userID)
+	s.ranking.remove(userID)
+	s.dirty[userID] = struct{}{}
+	s.scheduleBroadcastLocked()
+}
+
+// leaderboard snapshots under the read lock so concurrent readers don't block each other.
+func (s *Session) leaderboard() domain.Leaderboard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshotLocked()
+}
+
+func (s *Session) isEmpty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.participants) == 0
+}
+
+// IsEmpty reports whether the session has no participants.
+func (s *Session) IsEmpty() bool {
+	return s.isEmpty()
+}
+
+// subscriber is one listener on the session; userID is empty for anonymous full-board listeners.
+// hidden subscribers are players of a session whose leaderboard is hidden: they only get their own entry.
+type subscriber struct {
+	ch     chan *Broadcast
+	userID string
+	view   domain.LeaderboardView
+	hidden bool
+}
+
+// subscribe registers a listener. A player must still be a participant: one removed since they
+// joined missed the removal broadcast, so they get domain.ErrBanned or domain.ErrParticipantNotFound instead.
+func (s *Session) subscribe(userID string, view domain.LeaderboardView) (<-chan *Broadcast, func(), error) {
+	sub := &subscriber{ch: make(chan *Broadcast, 8), userID: userID, view: view}
+
+	s.mu.Lock()
+	if userID != "" {
+		if _, banned := s.bans[userID]; banned {
+			s.mu.Unlock()
+			return nil, nil, domain.ErrBanned
+		}
+		if _, ok := s.participants[userID]; !ok {
+			s.mu.Unlock()
+			return nil, nil, domain.ErrParticipantNotFound
+		}
+	}
+	sub.hidden = s.hidesFromLocked(userID)
+	s.subscribers[sub] = struct{}{}
+	initial := s.playerViewLocked(userID, view)
+	s.mu.Unlock()
+
+	// Patch subscribers catch up explicitly via since, starting from whatever version they hold.
+	if !view.Patch {
+		sub.ch <- newBroadcast(EventLeaderboard, initial)
+	}
+
+	cancel := func() {
+		s.mu.Lock()
+		if _, ok := s.subscribers[sub]; ok {
+			delete(s.subscribers, sub)
+			close(sub.ch)
+		}
+		s.mu.Unlock()
+	}
+	return sub.ch, cancel, nil
+}
+
+// scheduleBroadcastLocked broadcasts immediately when the window has elapsed since the last
+// broadcast, otherwise folds the change into a single trailing flush.
+func (s *Session) scheduleBroadcastLocked() {
+	if s.flushPending {
+		s.metrics.coalesced.Add(1)
+		return
+	}
+	elapsed := s.now().Sub(s.lastBroadcast)
+	if s.window <= 0 || elapsed >= s.window {
+		s.broadcastLocked()
+		return
+	}
+	s.flushPending = true
+	time.AfterFunc(s.window-elapsed, s.flush)
+}
+
+func (s *Session) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushPending = false
+	s.broadcastLocked()
+}
+
+// broadcastLocked marks rank movement and fans the leaderboard out. Subscribers that share a
+// non-personalized view share one Broadcast, so it is serialized once for all of them.
+func (s *Session) broadcastLocked() {
+	s.lastBroadcast = s.now()
+	patch := s.recordPatchLocked()
+	s.metrics.broadcasts.Add(1)
+
+	shared := make(map[domain.LeaderboardView]*Broadcast)
+	for sub := range s.subscribers {
+		if sub.hidden {
+			s.deliverLocked(sub, newBroadcast(EventLeaderboard, s.boundedLocked(sub.userID, domain.LeaderboardView{}, false)))
+			continue
+		}
+		if sub.view.Patch {
+			b, ok := shared[sub.view]
+			if !ok {
+				b = newBroadcast(EventLeaderboardPatch, patch)
+				shared[sub.view] = b
+			}
+			s.deliverLocked(sub, b)
+			continue
+		}
+		if sub.userID != "" && !sub.view.Full() {
+			s.deliverLocked(sub, newBroadcast(EventLeaderboard, s.viewLocked(sub.userID, sub.view)))
+			continue
+		}
+		b, ok := shared[sub.view]
+		if !ok {
+			b = newBroadcast(EventLeaderboard, s.viewLocked("", sub.view))
+			shared[sub.view] = b
+		}
+		s.deliverLocked(sub, b)
+	}
+	s.broadcastStatsLocked()
+}
+
+// recordPatchLocked bumps the version, marks rank movement and returns the ops that turn the
+// previous version into this one. Only dirty participants produce ops: everyone else keeps their
+// relative order, so clients derive the shifted ranks from position. Marking only visits the
+// dirty participants and the ranks they crossed, so a flush doesn't walk the whole board.
+func (s *Session) recordPatchLocked() domain.LeaderboardPatch {
+	type change struct {
+		userID string
+		prev   rankMark
+		seen   bool
+	}
+	changes := make([]change, 0, len(s.dirty))
+	moved := make([]string, 0, len(s.dirty))
+	for userID := range s.dirty {
+		prev, seen := s.ranking.lastMark(userID)
+		changes = append(changes, change{userID: userID, prev: prev, seen: seen})
+		moved = append(moved, userID)
+	}
+	s.ranking.mark(moved)
+
+	var removes, updates []domain.PatchOp
+	for _, c := range changes {
+		participant, ok := s.participants[c.userID]
+		if !ok {
+			removes = append(removes, domain.PatchOp{Op: domain.PatchRemove, UserID: c.userID})
+			continue
+		}
+		rank := s.ranking.rank(c.userID)
+		switch {
+		case !c.seen || c.prev.score != participant.Score || c.prev.name != participant.DisplayName || c.prev.team != participant.Team:
+			entry := s.entryLocked(rank, participant)
+			updates = append(updates, domain.PatchOp{Op: domain.PatchUpsert, UserID: c.userID, Rank: rank, Entry: &entry})
+		case c.prev.rank != rank:
+			updates = append(updates, domain.PatchOp{Op: domain.PatchMove, UserID: c.userID, Rank: rank})
+		}
+	}
+	sort.Slice(removes, func(i, j int) bool { return removes[i].UserID < removes[j].UserID })
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Rank < updates[j].Rank })
+	clear(s.dirty)
+
+	patch := domain.LeaderboardPatch{
+		SessionID:   s.id,
+		QuizID:      s.quizID,
+		BaseVersion: s.version,
+		Version:     s.version + 1,
+		Ops:         append(removes, updates...),
+		Total:       s.ranking.len(),
+		UpdatedAt:   s.now(),
+		Teams:       s.teamStandingsLocked(),
+	}
+	s.version = patch.Version
+	s.patches = append(s.patches, patch)
+	if len(s.patches) > patchHistory {
+		s.patches = s.patches[len(s.patches)-patchHistory:]
+	}
+	return patch
+}
+
+// since returns the patches that bring a client at version up to date, or a full snapshot
+// when that version has fallen out of the history (or is from the future).
+func (s *Session) since(version uint64) ([]domain.LeaderboardPatch, *domain.Leaderboard) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if version == s.version {
+		return nil, nil
+	}
+	for i, patch := range s.patches {
+		if patch.BaseVersion == version {
+			return append([]domain.LeaderboardPatch(nil), s.patches[i:]...), nil
+		}
+	}
+	snapshot := s.snapshotLocked()
+	return nil, &snapshot
+}
+
+func (s *Session) deliverLocked(sub *subscriber, b *Broadcast) {
+	select {
+	case sub.ch <- b:
+	default:
+		// AI-assisted: dropping stale updates prevents slow clients from blocking broadcast; verified via subscription tests.
+		select {
+		case <-sub.ch:
+			s.metrics.dropped.Add(1)
+		default:
+		}
+		sub.ch <- b
+	}
+}
+
+// snapshotLocked reads the leaderboard straight from the rank index; no sorting happens here.
+func (s *Session) snapshotLocked() domain.Leaderboard {
+	entries := make([]domain.LeaderboardEntry, 0, s.ranking.len())
+	s.ranking.ascend(1, func(rank int, participant *domain.Participant) bool {
+		entries = append(entries, s.entryLocked(rank, participant))
+		return true
+	})
+
+	return domain.Leaderboard{
+		SessionID: s.id,
+		QuizID:    s.quizID,
+		Entries:   entries,
+		UpdatedAt: s.now(),
+		Total:     s.ranking.len(),
+		Version:   s.version,
+		Teams:     s.teamStandingsLocked(),
+	}
+}
+
+// hidesFromLocked reports whether userID is a player who may only see their own entry.
+func (s *Session) hidesFromLocked(userID string) bool {
+	return userID != "" && s.settings.LeaderboardVisibility == domain.LeaderboardHidden
+}
+
+// playerViewLocked is viewLocked with the session's leaderboard visibility applied.
+func (s *Session) playerViewLocked(userID string, view domain.LeaderboardView) domain.Leaderboard {
+	if s.hidesFromLocked(userID) {
+		return s.boundedLocked(userID, domain.LeaderboardView{}, false)
+	}
+	return s.viewLocked(userID, view)
+}
+
+// viewLocked builds the leaderboard for a view: the full snapshot, or a bounded cut of it.
+func (s *Session) viewLocked(userID string, view domain.LeaderboardView) domain.Leaderboard {
+	if view.Full() {
+		return s.snapshotLocked()
+	}
+	return s.boundedLocked(userID, view, true)
+}
+
+// boundedLocked builds a bounded leaderboard: the top N, plus the caller's own entry and the
+// entries around it. Cost is O(log n + N + K) regardless of session size. Team standings are
+// left out unless withTeams is set.
+func (s *Session) boundedLocked(userID string, view domain.LeaderboardView, withTeams bool) domain.Leaderboard {
+	lb := domain.Leaderboard{
+		SessionID: s.id,
+		QuizID:    s.quizID,
+		Entries:   make([]domain.LeaderboardEntry, 0, view.Top),
+		UpdatedAt: s.now(),
+		Total:     s.ranking.len(),
+		Version:   s.version,
+	}
+	if withTeams {
+		lb.Teams = s.teamStandingsLocked()
+	}
+	if view.Top > 0 {
+		s.ranking.ascend(1, func(rank int, participant *domain.Participant) bool {
+			lb.Entries = append(lb.Entries, s.entryLocked(rank, participant))
+			return rank < view.Top
+		})
+	}
+
+	rank := s.ranking.rank(userID)
+	if rank == 0 {
+		return lb
+	}
+	me := s.entryLocked(rank, s.participants[userID])
+	lb.Me = &me
+	if view.Around > 0 {
+		from := rank - view.Around
+		if from < 1 {
+			from = 1
+		}
+		s.ranking.ascend(from, func(r int, participant *domain.Participant) bool {
+			lb.Around = append(lb.Around, s.entryLocked(r, participant))
+			return r < rank+view.Around
+		})
+	}
+	return lb
+}
+
+func (s *Session) entryLocked(rank int, participant *domain.Participant) domain.LeaderboardEntry {
+	return domain.LeaderboardEntry{
+		UserID:      participant.UserID,
+		DisplayName: participant.DisplayName,
+		Team:        participant.Team,
+		Score:       participant.Score,
+		Rank:        rank,
+		RankDelta:   s.ranking.delta(participant.UserID),
+		Finished:    participant.Finished,
+	}
+}
+
+func (s *Session) teamStandingsLocked() []domain.TeamEntry {
+	if s.teams == nil {
+		return nil
+	}
+	return s.teams.standings(s.participants)
+}
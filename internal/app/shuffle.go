@@ -0,0 +1,103 @@
+package app
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// shuffleSeed derives a participant's shuffle seed from the session and user, plus the question
+// for option orders, so the same order can be rebuilt at any time without storing it.
+func shuffleSeed(sessionID, userID, questionID string) uint64 {
+	h := fnv.New64a()
+	for _, part := range []string{sessionID, userID, questionID} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// shuffled returns a copy of items in the order seeded for one participant.
+func shuffled[T any](items []T, seed uint64) []T {
+	out := slices.Clone(items)
+	rng := rand.New(rand.NewPCG(seed, seed))
+	rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out
+}
+
+// optionOrder is the order userID sees a question's options in.
+func optionOrder(sessionID, userID string, settings domain.SessionSettings, question domain.Question) []domain.Option {
+	if !settings.ShuffleOptions {
+		return question.Options
+	}
+	return shuffled(question.Options, shuffleSeed(sessionID, userID, question.ID))
+}
+
+// questionOrder is the order userID works through a self-paced session's questions in.
+func questionOrder(sessionID, userID string, settings domain.SessionSettings, questions []domain.Question) []domain.Question {
+	if !settings.ShuffleQuestions {
+		return questions
+	}
+	return shuffled(questions, shuffleSeed(sessionID, userID, ""))
+}
+
+// playerQuestion hides the answers and puts the options in the participant's order.
+func playerQuestion(sessionID, userID string, settings domain.SessionSettings, question domain.Question) domain.PlayerQuestion {
+	view := domain.PlayerQuestion{
+		ID:     question.ID,
+		Type:   question.Type,
+		Prompt: question.Prompt,
+		Points: question.Points,
+		Scale:  question.Scale,
+		Hints:  len(question.Hints),
+	}
+	for i, option := range optionOrder(sessionID, userID, settings, question) {
+		view.Options = append(view.Options, domain.PlayerOption{ID: option.ID, Text: option.Text, Position: i + 1})
+	}
+	return view
+}
+
+// canonicalSubmission maps the display positions in a submission back to option IDs, so
+// scoring and stats only ever deal in canonical IDs.
+func canonicalSubmission(sessionID, userID string, settings domain.SessionSettings, question domain.Question, submission domain.AnswerSubmission) (domain.AnswerSubmission, error) {
+	if submission.Position == 0 && len(submission.Positions) == 0 {
+		return submission, nil
+	}
+	order := optionOrder(sessionID, userID, settings, question)
+	at := func(position int) (string, error) {
+		if position < 1 || position > len(order) {
+			return "", domain.ErrOptionNotFound
+		}
+		return order[position-1].ID, nil
+	}
+	var err error
+	if submission.Position != 0 {
+		if submission.OptionID, err = at(submission.Position); err != nil {
+			return domain.AnswerSubmission{}, err
+		}
+	}
+	for _, position := range submission.Positions {
+		id, err := at(position)
+		if err != nil {
+			return domain.AnswerSubmission{}, err
+		}
+		submission.OptionIDs = append(submission.OptionIDs, id)
+	}
+	return submission, nil
+}
+
+// layout rebuilds the orders a participant was shown.
+func layout(sessionID, userID string, settings domain.SessionSettings, questions []domain.Question) domain.Layout {
+	l := domain.Layout{SessionID: sessionID, UserID: userID, Options: make(map[string][]string, len(questions))}
+	for _, question := range questionOrder(sessionID, userID, settings, questions) {
+		l.QuestionIDs = append(l.QuestionIDs, question.ID)
+		ids := make([]string, 0, len(question.Options))
+		for _, option := range optionOrder(sessionID, userID, settings, question) {
+			ids = append(ids, option.ID)
+		}
+		l.Options[question.ID] = ids
+	}
+	return l
+}
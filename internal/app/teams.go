@@ -0,0 +1,123 @@
+package app
+
+import (
+	"sort"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// teamBoard tracks team membership for a session; scores are aggregated from participants on demand.
+type teamBoard struct {
+	settings domain.TeamSettings
+	members  map[string]map[string]struct{} // team -> userIDs
+}
+
+func newTeamBoard(settings domain.TeamSettings) *teamBoard {
+	members := make(map[string]map[string]struct{}, len(settings.Teams))
+	for _, team := range settings.Teams {
+		members[team] = make(map[string]struct{})
+	}
+	return &teamBoard{settings: settings, members: members}
+}
+
+// resolve validates a requested team, or picks the smallest team when auto-assigning.
+func (t *teamBoard) resolve(team string) (string, error) {
+	if team != "" {
+		if _, ok := t.members[team]; !ok {
+			return "", domain.ErrTeamNotFound
+		}
+		return team, nil
+	}
+	if !t.settings.AutoAssign {
+		return "", domain.ErrTeamRequired
+	}
+	return t.smallest(), nil
+}
+
+// smallest returns the team with the fewest members, preferring earlier teams on ties.
+func (t *teamBoard) smallest() string {
+	best := ""
+	for _, team := range t.settings.Teams {
+		if best == "" || len(t.members[team]) < len(t.members[best]) {
+			best = team
+		}
+	}
+	return best
+}
+
+// largest returns the team with the most members, preferring earlier teams on ties.
+func (t *teamBoard) largest() string {
+	best := ""
+	for _, team := range t.settings.Teams {
+		if best == "" || len(t.members[team]) > len(t.members[best]) {
+			best = team
+		}
+	}
+	return best
+}
+
+func (t *teamBoard) add(team, userID string) {
+	if members, ok := t.members[team]; ok {
+		members[userID] = struct{}{}
+	}
+}
+
+func (t *teamBoard) remove(team, userID string) {
+	if members, ok := t.members[team]; ok {
+		delete(members, userID)
+	}
+}
+
+// standings aggregates member scores per team and ranks teams by score, then name.
+func (t *teamBoard) standings(participants map[string]*domain.Participant) []domain.TeamEntry {
+	entries := make([]domain.TeamEntry, 0, len(t.settings.Teams))
+	for _, team := range t.settings.Teams {
+		scores := make([]int, 0, len(t.members[team]))
+		for userID := range t.members[team] {
+			if p, ok := participants[userID]; ok {
+				scores = append(scores, p.Score)
+			}
+		}
+		entries = append(entries, domain.TeamEntry{
+			Team:    team,
+			Score:   t.aggregate(scores),
+			Members: len(scores),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Team < entries[j].Team
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
+
+func (t *teamBoard) aggregate(scores []int) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	switch t.settings.Aggregate {
+	case domain.TeamAggregateAverage:
+		return float64(sum(scores)) / float64(len(scores))
+	case domain.TeamAggregateBestN:
+		sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+		if t.settings.BestN > 0 && t.settings.BestN < len(scores) {
+			scores = scores[:t.settings.BestN]
+		}
+		return float64(sum(scores))
+	default:
+		return float64(sum(scores))
+	}
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
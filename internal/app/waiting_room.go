@@ -0,0 +1,225 @@
+package app
+
+import (
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// waiter is a player queued in a session's waiting room.
+type waiter struct {
+	userID      string
+	displayName string
+	team        string
+	since       time.Time
+	ch          chan domain.WaitingStatus
+	admitted    bool
+	done        bool // ch is closed
+}
+
+// Waiter is a player's place in a session's waiting room. Updates carries their position and
+// ends with a status that has Admitted or Denied set; it is closed afterwards, or straight away
+// if another connection for the same user takes the place over.
+type Waiter struct {
+	Updates <-chan domain.WaitingStatus
+	cancel  func()
+}
+
+// Cancel gives up the place in line. A player admitted in the meantime leaves the session again,
+// unless they hold a Connection, whose Close decides that.
+func (w *Waiter) Cancel() {
+	w.cancel()
+}
+
+// enter joins userID straight away when there is room, or queues them in the waiting room when
+// the session is full (and has one) or is moderated. A nil waiter means the player joined.
+func (s *Session) enter(userID, displayName, team string) (*Waiter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, banned := s.bans[userID]; banned {
+		return nil, domain.ErrBanned
+	}
+	if _, ok := s.participants[userID]; ok {
+		return nil, s.joinLocked(userID, displayName, team)
+	}
+	if err := s.lateJoinLocked(); err != nil {
+		return nil, err
+	}
+	moderated := s.settings.Admission == domain.AdmissionHost
+	if !moderated && s.hasRoomLocked() && len(s.waiting) == 0 {
+		return nil, s.joinLocked(userID, displayName, team)
+	}
+	if !s.settings.WaitingRoom {
+		return nil, domain.ErrSessionFull
+	}
+
+	w := &waiter{userID: userID, displayName: displayName, team: team, since: s.now(), ch: make(chan domain.WaitingStatus, 1)}
+	i := s.waitingIndexLocked(userID)
+	if i >= 0 {
+		// A second connection for the same user keeps their place in line.
+		s.closeWaiterLocked(s.waiting[i])
+		s.waiting[i] = w
+	} else {
+		if len(s.waiting) >= s.settings.MaxWaiting {
+			return nil, domain.ErrSessionFull
+		}
+		i = len(s.waiting)
+		s.waiting = append(s.waiting, w)
+	}
+	s.notifyWaitingLocked(i)
+	return &Waiter{Updates: w.ch, cancel: func() { s.cancelWaiter(w) }}, nil
+}
+
+func (s *Session) cancelWaiter(w *waiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.admitted {
+		if len(s.conns[w.userID]) == 0 {
+			s.departLocked(w.userID)
+		}
+		return
+	}
+	if i := s.indexOfWaiterLocked(w); i >= 0 {
+		s.removeWaiterLocked(i)
+		s.closeWaiterLocked(w)
+		s.notifyWaitingLocked(i)
+	}
+}
+
+// admitWaitingLocked hands free slots to the head of the queue when admission is automatic.
+// Once a session that refuses late joiners starts, the whole queue is turned away.
+func (s *Session) admitWaitingLocked() {
+	if len(s.waiting) == 0 {
+		return
+	}
+	if s.lateJoinLocked() != nil {
+		for _, w := range s.waiting {
+			s.sendWaiterLocked(w, domain.WaitingStatus{SessionID: s.id, Denied: true})
+			s.closeWaiterLocked(w)
+		}
+		s.waiting = nil
+		return
+	}
+	if s.settings.Admission == domain.AdmissionHost {
+		return
+	}
+	admitted := 0
+	for len(s.waiting) > 0 && s.hasRoomLocked() {
+		_ = s.admitLocked(s.waiting[0])
+		s.removeWaiterLocked(0)
+		admitted++
+	}
+	if admitted > 0 {
+		s.notifyWaitingLocked(0)
+	}
+}
+
+// admitLocked joins a waiter and sends them their final status. Players whose team can no longer
+// be resolved are denied instead.
+func (s *Session) admitLocked(w *waiter) error {
+	status := domain.WaitingStatus{SessionID: s.id, Waiting: len(s.waiting) - 1}
+	err := s.joinLocked(w.userID, w.displayName, w.team)
+	if err != nil {
+		status.Denied = true
+	} else {
+		status.Admitted = true
+		w.admitted = true
+	}
+	s.sendWaiterLocked(w, status)
+	s.closeWaiterLocked(w)
+	return err
+}
+
+// admit lets a waiting player in on the host's say-so, as long as there is room.
+func (s *Session) admit(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.waitingIndexLocked(userID)
+	if i < 0 {
+		return domain.ErrNotWaiting
+	}
+	if !s.hasRoomLocked() {
+		return domain.ErrSessionFull
+	}
+	err := s.admitLocked(s.waiting[i])
+	s.removeWaiterLocked(i)
+	s.notifyWaitingLocked(i)
+	return err
+}
+
+// deny turns a waiting player away.
+func (s *Session) deny(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.waitingIndexLocked(userID)
+	if i < 0 {
+		return domain.ErrNotWaiting
+	}
+	w := s.waiting[i]
+	s.removeWaiterLocked(i)
+	s.sendWaiterLocked(w, domain.WaitingStatus{SessionID: s.id, Waiting: len(s.waiting), Denied: true})
+	s.closeWaiterLocked(w)
+	s.notifyWaitingLocked(i)
+	return nil
+}
+
+// waitingRoom lists the queue in order for the host.
+func (s *Session) waitingRoom() []domain.WaitingEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := make([]domain.WaitingEntry, 0, len(s.waiting))
+	for i, w := range s.waiting {
+		entries = append(entries, domain.WaitingEntry{UserID: w.userID, DisplayName: w.displayName, Position: i + 1, Since: w.since})
+	}
+	return entries
+}
+
+// notifyWaitingLocked pushes fresh positions to everyone from index from onwards; players
+// ahead of a change keep their place and aren't told anything.
+func (s *Session) notifyWaitingLocked(from int) {
+	for i := from; i < len(s.waiting); i++ {
+		s.sendWaiterLocked(s.waiting[i], domain.WaitingStatus{SessionID: s.id, Position: i + 1, Waiting: len(s.waiting)})
+	}
+}
+
+// sendWaiterLocked keeps only the latest status for a waiter that hasn't read the previous one.
+func (s *Session) sendWaiterLocked(w *waiter, status domain.WaitingStatus) {
+	if w.done {
+		return
+	}
+	select {
+	case <-w.ch:
+	default:
+	}
+	w.ch <- status
+}
+
+func (s *Session) closeWaiterLocked(w *waiter) {
+	if !w.done {
+		w.done = true
+		close(w.ch)
+	}
+}
+
+func (s *Session) removeWaiterLocked(i int) {
+	s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+}
+
+func (s *Session) waitingIndexLocked(userID string) int {
+	for i, w := range s.waiting {
+		if w.userID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Session) indexOfWaiterLocked(w *waiter) int {
+	for i, queued := range s.waiting {
+		if queued == w {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/config"
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/infra/memory"
+	"github.com/spf13/cobra"
+)
+
+// NewAnalyticsCmd reports item statistics for a quiz from the answers recorded in Postgres or SQLite.
+func NewAnalyticsCmd(configPath *string) *cobra.Command {
+	var quizID, format string
+	var version int
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Report question difficulty, discrimination and distractors for a quiz",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalytics(cmd.Context(), cmd.OutOrStdout(), *configPath, quizID, version, format)
+		},
+	}
+	cmd.Flags().StringVar(&quizID, "quiz", "", "quiz ID to report on")
+	cmd.Flags().IntVar(&version, "version", 0, "quiz version to report on (default: the published one)")
+	cmd.Flags().StringVar(&format, "format", "table", "output format: table or csv")
+	_ = cmd.MarkFlagRequired("quiz")
+	return cmd
+}
+
+func runAnalytics(ctx context.Context, out io.Writer, configPath, quizID string, version int, format string) error {
+	if format != "table" && format != "csv" {
+		return fmt.Errorf("unknown format %q: use table or csv", format)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.Postgres.URL == "" && cfg.SQLite.Path == "" {
+		return fmt.Errorf("no database configured: answers are only kept in postgres or sqlite")
+	}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	loader, _, err := quizLoader(cfg, db)
+	if err != nil {
+		return err
+	}
+	service := app.NewQuizService(memory.NewSessionStore(), memory.NewQuizRepository(loader, 0),
+		app.WithQuestionBanks(db.banks()),
+		app.WithAnswerLog(db.answers()),
+	)
+	defer service.Close()
+	analytics, err := service.ItemAnalytics(ctx, quizID, version)
+	if err != nil {
+		return err
+	}
+	if format == "csv" {
+		return writeAnalyticsCSV(out, analytics)
+	}
+	return writeAnalyticsTable(out, analytics)
+}
+
+// writeAnalyticsTable prints one row per question, with each option's pick rate; * marks correct options.
+func writeAnalyticsTable(out io.Writer, analytics domain.QuizAnalytics) error {
+	if analytics.Version > 0 {
+		fmt.Fprintf(out, "quiz %s version %d: %d respondents\n\n", analytics.QuizID, analytics.Version, analytics.Respondents)
+	} else {
+		fmt.Fprintf(out, "quiz %s: %d respondents\n\n", analytics.QuizID, analytics.Respondents)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "QUESTION\tRESPONSES\tP-VALUE\tPOINT-BISERIAL\tAVG MS\tOPTIONS")
+	for _, item := range analytics.Items {
+		options := make([]string, 0, len(item.Options))
+		for _, option := range item.Options {
+			mark := ""
+			if option.Correct {
+				mark = "*"
+			}
+			options = append(options, fmt.Sprintf("%s%s %.1f%%", option.OptionID, mark, option.Rate*100))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.3f\t%.3f\t%.0f\t%s\n", item.QuestionID, item.Responses, item.PValue, item.PointBiserial, item.AvgResponseMs, strings.Join(options, "  "))
+	}
+	return w.Flush()
+}
+
+// writeAnalyticsCSV writes one row per option, repeating the question's statistics on each.
+func writeAnalyticsCSV(out io.Writer, analytics domain.QuizAnalytics) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{"quiz_id", "quiz_version", "question_id", "responses", "p_value", "point_biserial", "avg_response_ms", "option_id", "correct", "count", "rate"})
+	for _, item := range analytics.Items {
+		for _, option := range item.Options {
+			_ = w.Write([]string{
+				analytics.QuizID,
+				strconv.Itoa(analytics.Version),
+				item.QuestionID,
+				strconv.Itoa(item.Responses),
+				strconv.FormatFloat(item.PValue, 'f', 3, 64),
+				strconv.FormatFloat(item.PointBiserial, 'f', 3, 64),
+				strconv.FormatFloat(item.AvgResponseMs, 'f', 0, 64),
+				option.OptionID,
+				strconv.FormatBool(option.Correct),
+				strconv.Itoa(option.Count),
+				strconv.FormatFloat(option.Rate, 'f', 3, 64),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
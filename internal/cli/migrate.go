@@ -35,8 +35,18 @@ func runMigrations(ctx context.Context, configPath string) error {
 }
 
 func runMigrationsWithConfig(ctx context.Context, cfg config.Config) error {
+	if cfg.Postgres.URL == "" && cfg.SQLite.Path != "" {
+		// openStorage migrates SQLite as it opens it.
+		db, err := openStorage(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		db.Close()
+		log.Printf("migrations applied")
+		return nil
+	}
 	if cfg.Postgres.URL == "" {
-		return fmt.Errorf("postgres url not configured")
+		return fmt.Errorf("no database configured: set postgres.url or sqlite.path")
 	}
 
 	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(cfg.Postgres.URL)))
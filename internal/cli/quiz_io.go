@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"elsa-quiz-service/internal/config"
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/quizfmt"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCmd reads a quiz file, validates it and stores it in Postgres or SQLite as the quiz's
+// next version, published unless --draft is given.
+func NewImportCmd(configPath *string) *cobra.Command {
+	var file, format, quizID string
+	var dryRun, draft bool
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a quiz from GIFT, Moodle XML, CSV or YAML",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), *configPath, file, format, quizID, dryRun, draft)
+		},
+	}
+	cmd.Flags().StringVar(&file, "file", "", "quiz file to import")
+	cmd.Flags().StringVar(&format, "format", "", "file format: gift, moodle, csv or yaml (default: from the file extension)")
+	cmd.Flags().StringVar(&quizID, "id", "", "quiz ID (default: the YAML file's id, else the file name)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate and report issues without saving")
+	cmd.Flags().BoolVar(&draft, "draft", false, "save as a draft version without publishing it")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func runImport(ctx context.Context, out, errOut io.Writer, configPath, file, format, quizID string, dryRun, draft bool) error {
+	if format == "" {
+		var err error
+		if format, err = quizfmt.FormatOf(file); err != nil {
+			return err
+		}
+	}
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if quizID == "" && format != quizfmt.FormatYAML {
+		quizID = name
+	}
+	quiz, issues, err := quizfmt.Decode(format, bytes.NewReader(raw), quizID)
+	if err == nil && quiz.ID == "" {
+		// A YAML quiz without an id is named after its file, like the files quiz source does.
+		quiz, issues, err = quizfmt.Decode(format, bytes.NewReader(raw), name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(errOut, "%s: %s\n", file, issue)
+	}
+	if quizfmt.Invalid(issues) {
+		return fmt.Errorf("%w: %s was not imported", domain.ErrInvalidQuiz, file)
+	}
+	if dryRun {
+		fmt.Fprintf(out, "quiz %s: %d questions, valid\n", quiz.ID, len(quiz.Questions))
+		return nil
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	quizzes := db.quizzes()
+	if quizzes == nil {
+		return fmt.Errorf("no database configured: quizzes are imported into postgres or sqlite")
+	}
+	saved, err := quizzes.SaveQuiz(ctx, quiz)
+	if err != nil {
+		return err
+	}
+	if draft {
+		fmt.Fprintf(out, "imported quiz %s version %d as a draft: %d questions\n", quiz.ID, saved.Version, len(quiz.Questions))
+		return nil
+	}
+	if err := quizzes.PublishQuiz(ctx, quiz.ID, saved.Version); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "imported and published quiz %s version %d: %d questions\n", quiz.ID, saved.Version, len(quiz.Questions))
+	return nil
+}
+
+// NewExportCmd writes a quiz out as GIFT, Moodle XML, CSV or YAML, reading it from the
+// configured quiz source like the server does.
+func NewExportCmd(configPath *string) *cobra.Command {
+	var quizID, format, file string
+	var version int
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a quiz as GIFT, Moodle XML, CSV or YAML",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), *configPath, quizID, version, format, file)
+		},
+	}
+	cmd.Flags().StringVar(&quizID, "quiz", "", "quiz ID to export")
+	cmd.Flags().StringVar(&format, "format", "", "file format: gift, moodle, csv or yaml (default: from --out, else yaml)")
+	cmd.Flags().StringVar(&file, "out", "", "file to write (default: stdout)")
+	cmd.Flags().IntVar(&version, "version", 0, "quiz version to export (default: the published one)")
+	_ = cmd.MarkFlagRequired("quiz")
+	return cmd
+}
+
+func runExport(ctx context.Context, out, errOut io.Writer, configPath, quizID string, version int, format, file string) error {
+	switch {
+	case format != "":
+	case file != "":
+		var err error
+		if format, err = quizfmt.FormatOf(file); err != nil {
+			return err
+		}
+	default:
+		format = quizfmt.FormatYAML
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	loader, _, err := quizLoader(cfg, db)
+	if err != nil {
+		return err
+	}
+	var quiz domain.Quiz
+	if version > 0 {
+		quiz, err = loader.LoadQuizVersion(ctx, quizID, version)
+	} else {
+		quiz, err = loader.LoadQuiz(ctx, quizID)
+	}
+	if err != nil {
+		return err
+	}
+	// The version belongs to the store the quiz came from; importing it elsewhere numbers it afresh.
+	quiz.Version = 0
+
+	var buf bytes.Buffer
+	issues, err := quizfmt.Encode(format, &buf, quiz)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(errOut, "%s: %s\n", quizID, issue)
+	}
+	if file == "" {
+		_, err = out.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
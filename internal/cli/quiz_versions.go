@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/config"
+	"elsa-quiz-service/internal/infra/memory"
+	"github.com/spf13/cobra"
+)
+
+// NewVersionsCmd lists the stored versions of a quiz.
+func NewVersionsCmd(configPath *string) *cobra.Command {
+	var quizID string
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List a quiz's versions and which one is published",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersions(cmd.Context(), cmd.OutOrStdout(), *configPath, quizID)
+		},
+	}
+	cmd.Flags().StringVar(&quizID, "quiz", "", "quiz ID")
+	_ = cmd.MarkFlagRequired("quiz")
+	return cmd
+}
+
+func runVersions(ctx context.Context, out io.Writer, configPath, quizID string) error {
+	db, quizzes, err := openQuizStore(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	versions, err := quizzes.QuizVersions(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tCREATED\tPUBLISHED")
+	for _, v := range versions {
+		published := "-"
+		if v.PublishedAt != nil {
+			published = v.PublishedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", v.Version, v.Status, v.CreatedAt.UTC().Format(time.RFC3339), published)
+	}
+	return w.Flush()
+}
+
+// NewPublishCmd makes a stored version the one new sessions run. Running sessions stay on the
+// version they started with, and servers pick the new one up once their quiz cache expires.
+func NewPublishCmd(configPath *string) *cobra.Command {
+	var quizID string
+	var version int
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish a quiz version, archiving the one published before",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPublish(cmd.Context(), cmd.OutOrStdout(), *configPath, quizID, version)
+		},
+	}
+	cmd.Flags().StringVar(&quizID, "quiz", "", "quiz ID")
+	cmd.Flags().IntVar(&version, "version", 0, "version to publish")
+	_ = cmd.MarkFlagRequired("quiz")
+	_ = cmd.MarkFlagRequired("version")
+	return cmd
+}
+
+func runPublish(ctx context.Context, out io.Writer, configPath, quizID string, version int) error {
+	db, quizzes, err := openQuizStore(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := quizzes.PublishQuiz(ctx, quizID, version); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "published quiz %s version %d\n", quizID, version)
+	return nil
+}
+
+// openQuizStore opens the database quizzes are versioned in.
+func openQuizStore(ctx context.Context, configPath string) (*storage, quizStore, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	quizzes := db.quizzes()
+	if quizzes == nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("no database configured: quiz versions are kept in postgres or sqlite")
+	}
+	return db, quizzes, nil
+}
+
+// NewDiffCmd shows what changed between two versions of a quiz, from the configured quiz source.
+func NewDiffCmd(configPath *string) *cobra.Command {
+	var quizID string
+	var from, to int
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show the changes between two versions of a quiz",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(cmd.Context(), cmd.OutOrStdout(), *configPath, quizID, from, to)
+		},
+	}
+	cmd.Flags().StringVar(&quizID, "quiz", "", "quiz ID")
+	cmd.Flags().IntVar(&from, "from", 0, "older version")
+	cmd.Flags().IntVar(&to, "to", 0, "newer version")
+	_ = cmd.MarkFlagRequired("quiz")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func runDiff(ctx context.Context, out io.Writer, configPath, quizID string, from, to int) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	loader, _, err := quizLoader(cfg, db)
+	if err != nil {
+		return err
+	}
+	service := app.NewQuizService(memory.NewSessionStore(), memory.NewQuizRepository(loader, 0))
+	diff, err := service.DiffQuiz(ctx, quizID, from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "quiz %s: version %d -> %d\n", diff.QuizID, diff.From, diff.To)
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(out, "no changes")
+	}
+	for _, change := range diff.Changes {
+		fmt.Fprintln(out, change)
+	}
+	return nil
+}
@@ -35,5 +35,11 @@ func newRootCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&configPath, "config", envConfig, "path to YAML config")
 	cmd.AddCommand(NewStartCmd(&configPath, &port))
 	cmd.AddCommand(NewMigrateCmd(&configPath))
+	cmd.AddCommand(NewAnalyticsCmd(&configPath))
+	cmd.AddCommand(NewImportCmd(&configPath))
+	cmd.AddCommand(NewExportCmd(&configPath))
+	cmd.AddCommand(NewVersionsCmd(&configPath))
+	cmd.AddCommand(NewPublishCmd(&configPath))
+	cmd.AddCommand(NewDiffCmd(&configPath))
 	return cmd
 }
@@ -2,21 +2,24 @@ package cli
 
 import (
 	"context"
+	"expvar"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"elsa-quiz-service/internal/app"
 	"elsa-quiz-service/internal/config"
 	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/infra/filesystem"
 	"elsa-quiz-service/internal/infra/memory"
-	pgloader "elsa-quiz-service/internal/infra/postgres"
 	redissession "elsa-quiz-service/internal/infra/redis"
 	transport "elsa-quiz-service/internal/transport/http"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
 )
@@ -59,44 +62,101 @@ func runServer(ctx context.Context, configPath, portFlag string) error {
 			Password: cfg.Redis.Password,
 			DB:       cfg.Redis.DB,
 		})
+		// Sessions stay in this process, so no other server may hand out codes from the same database.
+		lease, err := redissession.AcquireInstanceLease(ctx, redisClient, 15*time.Second)
+		if err != nil {
+			return fmt.Errorf("redis %s: %w", cfg.Redis.Addr, err)
+		}
+		defer lease.Release(context.Background())
 	}
 	redisTTL := config.TTLDuration(cfg.Redis.TTL, 10*time.Minute)
 
-	var pool *pgxpool.Pool
-	if cfg.Postgres.URL != "" {
-		pool, err = pgxpool.Connect(ctx, cfg.Postgres.URL)
-		if err != nil {
-			return err
-		}
+	db, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
 	}
+	defer db.Close()
 
-	var loader memory.QuizLoader = memory.NewStaticQuizLoader(sampleQuizzes())
-	if pool != nil {
-		loader = pgloader.NewQuizLoader(pool)
+	loader, files, err := quizLoader(cfg, db)
+	if err != nil {
+		return err
 	}
 
 	quizTTL := config.TTLDuration(cfg.Quiz.TTL, 10*time.Minute)
+	notFoundTTL := config.TTLDuration(cfg.Quiz.NotFoundTTL, 10*time.Second)
+	staleTTL := config.TTLDuration(cfg.Quiz.StaleTTL, quizTTL)
 	var quizRepo app.QuizRepository
+	var invalidate func(ctx context.Context, quizID string) error
 	if redisClient != nil {
-		quizRepo = redissession.NewQuizRepository(redisClient, loader, quizTTL)
+		repo := redissession.NewQuizRepository(redisClient, loader, quizTTL,
+			redissession.WithNotFoundTTL(notFoundTTL), redissession.WithStaleTTL(staleTTL))
+		quizRepo, invalidate = repo, repo.Invalidate
 	} else {
-		quizRepo = memory.NewQuizRepository(loader, quizTTL)
+		repo := memory.NewQuizRepository(loader, quizTTL,
+			memory.WithNotFoundTTL(notFoundTTL), memory.WithStaleTTL(staleTTL))
+		quizRepo, invalidate = repo, repo.Invalidate
+	}
+	if files != nil {
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		defer stopWatching()
+		go files.Watch(watchCtx, config.TTLDuration(cfg.Quiz.PollInterval, 2*time.Second), func(quizID string) {
+			if err := invalidate(watchCtx, quizID); err != nil {
+				log.Printf("invalidate quiz %s: %v", quizID, err)
+				return
+			}
+			log.Printf("reloaded quiz %s", quizID)
+		})
 	}
 
 	var store app.SessionRepository
+	var joinCodes app.JoinCodeRepository
 	if redisClient != nil {
 		store = redissession.NewSessionStore(redisClient, redisTTL)
+		joinCodes = redissession.NewJoinCodeStore(redisClient)
 	} else {
 		store = memory.NewSessionStore()
+		joinCodes = memory.NewJoinCodeStore()
 	}
-	service := app.NewQuizService(store, quizRepo)
+	names, err := namePolicy(cfg)
+	if err != nil {
+		return err
+	}
+	broadcastWindow := config.TTLDuration(cfg.Quiz.BroadcastWindow, 0)
+	joinCodeTTL := config.TTLDuration(cfg.Session.JoinCodeTTL, 4*time.Hour)
+	service := app.NewQuizService(store, quizRepo,
+		app.WithBroadcastWindow(broadcastWindow),
+		app.WithJoinCodes(joinCodes, joinCodeTTL),
+		app.WithMaxParticipants(cfg.Session.MaxParticipants),
+		app.WithNamePolicy(names),
+		app.WithQuestionBanks(db.banks()),
+		app.WithAnswerLog(db.answers()),
+	)
+	defer service.Close()
 	wsHandler := transport.NewWSHandler(service)
+	sessionHandler := transport.NewSessionHandler(service)
+	quizHandler := transport.NewQuizHandler(service, cfg.Server.AdminToken)
+	publishBroadcastStats(service)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("ok"))
 	})
 	mux.HandleFunc("/ws", wsHandler.ServeWS)
+	mux.HandleFunc("POST /sessions", sessionHandler.CreateSession)
+	mux.HandleFunc("POST /sessions/{id}/questions/{questionId}/open", sessionHandler.OpenQuestion)
+	mux.HandleFunc("POST /sessions/{id}/questions/{questionId}/close", sessionHandler.CloseQuestion)
+	mux.HandleFunc("GET /sessions/{id}/questions/{questionId}/stats", sessionHandler.AnswerStats)
+	mux.HandleFunc("GET /sessions/{id}/leaderboard", sessionHandler.Leaderboard)
+	mux.HandleFunc("GET /sessions/{id}/waiting", sessionHandler.WaitingRoom)
+	mux.HandleFunc("POST /sessions/{id}/waiting/{userId}/admit", sessionHandler.Admit)
+	mux.HandleFunc("POST /sessions/{id}/waiting/{userId}/deny", sessionHandler.Deny)
+	mux.HandleFunc("POST /sessions/{id}/participants/{userId}/{command}", sessionHandler.Moderate)
+	mux.HandleFunc("POST /sessions/{id}/participants/{userId}/team", sessionHandler.AssignTeam)
+	mux.HandleFunc("POST /sessions/{id}/teams/balance", sessionHandler.BalanceTeams)
+	mux.HandleFunc("GET /sessions/{id}/participants/{userId}/layout", sessionHandler.Layout)
+	mux.HandleFunc("GET /sessions/{id}/audit", sessionHandler.AuditLog)
+	mux.HandleFunc("GET /quizzes/{id}/analytics", quizHandler.Analytics)
+	mux.HandleFunc("GET /quizzes/{id}/diff", quizHandler.Diff)
 
 	server := &http.Server{
 		Addr:         ":" + finalPort,
@@ -112,6 +172,19 @@ func runServer(ctx context.Context, configPath, portFlag string) error {
 		}
 	}()
 
+	var debugServer *http.Server
+	if addr := cfg.Server.DebugAddr; addr != "" {
+		debugMux := http.NewServeMux()
+		debugMux.Handle("/debug/vars", expvar.Handler())
+		debugServer = &http.Server{Addr: addr, Handler: debugMux}
+		go func() {
+			log.Printf("serving debug vars on %s", addr)
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("failed to start debug server: %v", err)
+			}
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
@@ -124,10 +197,85 @@ func runServer(ctx context.Context, configPath, portFlag string) error {
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	if debugServer != nil {
+		debugServer.Shutdown(shutdownCtx)
+	}
 	return server.Shutdown(shutdownCtx)
 }
 
-// sampleQuizzes provides a minimal set of quiz data; swap this loader with a document DB-backed one in production.
+var (
+	publishStats sync.Once
+	statsService atomic.Pointer[app.QuizService]
+)
+
+// publishBroadcastStats exposes the service's broadcast counters as the "broadcast" expvar.
+// expvar panics on a second Publish, so later calls only swap the service it reads.
+func publishBroadcastStats(service *app.QuizService) {
+	statsService.Store(service)
+	publishStats.Do(func() {
+		expvar.Publish("broadcast", expvar.Func(func() any {
+			return statsService.Load().BroadcastStats()
+		}))
+	})
+}
+
+// quizLoader builds the loader for the configured quiz source. For the files source it also
+// returns the directory loader, so the caller can watch it for changes.
+func quizLoader(cfg config.Config, db *storage) (memory.QuizLoader, *filesystem.QuizLoader, error) {
+	switch source := cfg.QuizSource(); source {
+	case config.QuizSourcePostgres:
+		if db.pool == nil {
+			return nil, nil, fmt.Errorf("quiz source %s needs postgres.url", source)
+		}
+		return db.quizzes(), nil, nil
+	case config.QuizSourceSQLite:
+		if db.sqlite == nil {
+			return nil, nil, fmt.Errorf("quiz source %s needs sqlite.path", source)
+		}
+		return db.quizzes(), nil, nil
+	case config.QuizSourceFiles:
+		dir := cfg.Quiz.Dir
+		if dir == "" {
+			dir = "quizzes"
+		}
+		files, err := filesystem.NewQuizLoader(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return files, files, nil
+	case config.QuizSourceSample:
+		return memory.NewStaticQuizLoader(sampleQuizzes()), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown quiz source %q: use %s, %s, %s or %s", source,
+			config.QuizSourcePostgres, config.QuizSourceSQLite, config.QuizSourceFiles, config.QuizSourceSample)
+	}
+}
+
+// namePolicy builds the display name rules from config, loading the profanity lists if configured.
+func namePolicy(cfg config.Config) (*app.NamePolicy, error) {
+	minLength, maxLength := cfg.Names.MinLength, cfg.Names.MaxLength
+	if minLength == 0 {
+		minLength = app.DefaultMinNameLength
+	}
+	if maxLength == 0 {
+		maxLength = app.DefaultMaxNameLength
+	}
+	policy := app.NewNamePolicy(minLength, maxLength)
+	policy.DefaultLocale = cfg.Names.DefaultLocale
+	if cfg.Names.ProfanityDir == "" {
+		return policy, nil
+	}
+	lists, err := config.LoadWordLists(cfg.Names.ProfanityDir)
+	if err != nil {
+		return nil, err
+	}
+	for locale, words := range lists {
+		policy.AddWords(locale, words)
+	}
+	return policy, nil
+}
+
+// sampleQuizzes provides a minimal set of quiz data, served by the sample quiz source.
 func sampleQuizzes() map[string]domain.Quiz {
 	return map[string]domain.Quiz{
 		"quiz-1": {
@@ -145,5 +293,40 @@ func sampleQuizzes() map[string]domain.Quiz {
 				},
 			},
 		},
+		"quiz-mixed": {
+			ID: "quiz-mixed",
+			Draws: []domain.QuestionDraw{
+				{Bank: "english", Tags: []string{"vocab"}, Difficulty: domain.DifficultyEasy, Count: 2},
+				{Bank: "english", Tags: []string{"grammar"}, Count: 1},
+			},
+		},
+	}
+}
+
+// sampleBanks provides a question bank for quiz-mixed.
+func sampleBanks() map[string]domain.QuestionBank {
+	choice := func(id, prompt, right, wrong, difficulty string, tags ...string) domain.Question {
+		return domain.Question{
+			ID:         id,
+			Prompt:     prompt,
+			Options:    []domain.Option{{ID: "o1", Text: wrong}, {ID: "o2", Text: right, Correct: true}},
+			Points:     1,
+			Tags:       tags,
+			Difficulty: difficulty,
+		}
+	}
+	return map[string]domain.QuestionBank{
+		"english": {
+			ID:   "english",
+			Name: "English",
+			Questions: []domain.Question{
+				choice("v1", "Synonym for quick?", "fast", "slow", domain.DifficultyEasy, "vocab"),
+				choice("v2", "Opposite of hot?", "cold", "warm", domain.DifficultyEasy, "vocab"),
+				choice("v3", "Synonym for big?", "large", "tiny", domain.DifficultyEasy, "vocab"),
+				choice("v4", "Synonym for ubiquitous?", "everywhere", "rare", domain.DifficultyHard, "vocab"),
+				choice("g1", "She ___ to school every day.", "goes", "go", domain.DifficultyEasy, "grammar"),
+				choice("g2", "If I ___ you, I would apologise.", "were", "was", domain.DifficultyHard, "grammar"),
+			},
+		},
 	}
 }
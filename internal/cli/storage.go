@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/config"
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/infra/memory"
+	pgloader "elsa-quiz-service/internal/infra/postgres"
+	"elsa-quiz-service/internal/infra/sqlite"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// storage is the database content and results are kept in: Postgres, SQLite, or neither, in
+// which case they stay in memory.
+type storage struct {
+	pool   *pgxpool.Pool
+	sqlite *sql.DB
+}
+
+// quizStore reads and writes versioned quizzes in a database.
+type quizStore interface {
+	memory.QuizLoader
+	// SaveQuiz stores the quiz as a new draft version.
+	SaveQuiz(ctx context.Context, quiz domain.Quiz) (domain.QuizVersion, error)
+	// PublishQuiz makes a version the one new sessions run, archiving the previous one.
+	PublishQuiz(ctx context.Context, quizID string, version int) error
+	QuizVersions(ctx context.Context, quizID string) ([]domain.QuizVersion, error)
+}
+
+// openStorage connects to the configured database. SQLite is migrated on the spot, since
+// there is no separate server to migrate ahead of time.
+func openStorage(ctx context.Context, cfg config.Config) (*storage, error) {
+	switch {
+	case cfg.Postgres.URL != "" && cfg.SQLite.Path != "":
+		return nil, fmt.Errorf("configure either postgres.url or sqlite.path, not both")
+	case cfg.Postgres.URL != "":
+		pool, err := pgxpool.Connect(ctx, cfg.Postgres.URL)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{pool: pool}, nil
+	case cfg.SQLite.Path != "":
+		db, err := sqlite.Open(cfg.SQLite.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlite.Migrate(ctx, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate sqlite: %w", err)
+		}
+		return &storage{sqlite: db}, nil
+	}
+	return &storage{}, nil
+}
+
+func (s *storage) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+	if s.sqlite != nil {
+		s.sqlite.Close()
+	}
+}
+
+// quizzes returns the database's quiz tables, or nil without a database.
+func (s *storage) quizzes() quizStore {
+	switch {
+	case s.pool != nil:
+		return pgloader.NewQuizLoader(s.pool)
+	case s.sqlite != nil:
+		return sqlite.NewQuizLoader(s.sqlite)
+	}
+	return nil
+}
+
+func (s *storage) banks() app.QuestionBankRepository {
+	switch {
+	case s.pool != nil:
+		return pgloader.NewQuestionBanks(s.pool)
+	case s.sqlite != nil:
+		return sqlite.NewQuestionBanks(s.sqlite)
+	}
+	return memory.NewStaticQuestionBanks(sampleBanks())
+}
+
+func (s *storage) answers() app.AnswerRepository {
+	switch {
+	case s.pool != nil:
+		return pgloader.NewAnswerStore(s.pool)
+	case s.sqlite != nil:
+		return sqlite.NewAnswerStore(s.sqlite)
+	}
+	return memory.NewAnswerStore()
+}
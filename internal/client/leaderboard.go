@@ -0,0 +1,95 @@
+// Package client is a reference implementation of the client side of the WebSocket contract.
+// Tests use it to check that patches reproduce the server's leaderboard exactly.
+package client
+
+import (
+	"errors"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// ErrVersionGap is returned when a patch doesn't start at the version the client holds;
+// the client must catch up (or resync from a snapshot) before applying it.
+var ErrVersionGap = errors.New("patch base version does not match local version")
+
+// Leaderboard is a client-held copy of a session leaderboard kept current by patches.
+type Leaderboard struct {
+	QuizID  string
+	Version uint64
+	Total   int
+	Entries []domain.LeaderboardEntry
+	Teams   []domain.TeamEntry
+}
+
+// Reset replaces local state with a full snapshot.
+func (l *Leaderboard) Reset(snapshot domain.Leaderboard) {
+	l.QuizID = snapshot.QuizID
+	l.Version = snapshot.Version
+	l.Total = snapshot.Total
+	l.Entries = append(l.Entries[:0], snapshot.Entries...)
+	l.Teams = snapshot.Teams
+}
+
+// Apply advances local state by one patch. Patches at or below the local version are ignored,
+// so replays after a catch-up are harmless.
+func (l *Leaderboard) Apply(patch domain.LeaderboardPatch) error {
+	if patch.Version <= l.Version {
+		return nil
+	}
+	if patch.BaseVersion != l.Version {
+		return ErrVersionGap
+	}
+
+	prevRank := make(map[string]int, len(l.Entries))
+	for i, entry := range l.Entries {
+		prevRank[entry.UserID] = i + 1
+	}
+
+	// Pull every touched row out, then re-insert upserts and moves at their final ranks.
+	// Ops arrive sorted by rank, and untouched rows keep their relative order.
+	touched := make(map[string]domain.LeaderboardEntry, len(patch.Ops))
+	for _, op := range patch.Ops {
+		touched[op.UserID] = domain.LeaderboardEntry{}
+	}
+	kept := l.Entries[:0]
+	for _, entry := range l.Entries {
+		if _, ok := touched[entry.UserID]; ok {
+			touched[entry.UserID] = entry
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	entries := kept
+	upserted := make(map[string]bool)
+	for _, op := range patch.Ops {
+		var entry domain.LeaderboardEntry
+		switch op.Op {
+		case domain.PatchRemove:
+			continue
+		case domain.PatchUpsert:
+			entry = *op.Entry
+			upserted[op.UserID] = true
+		case domain.PatchMove:
+			entry = touched[op.UserID]
+		}
+		at := min(max(op.Rank-1, 0), len(entries))
+		entries = append(entries, domain.LeaderboardEntry{})
+		copy(entries[at+1:], entries[at:])
+		entries[at] = entry
+	}
+
+	// Upserts carry the server's movement; everyone else moved by however far the patch shifted them.
+	for i := range entries {
+		rank := i + 1
+		entries[i].Rank = rank
+		if upserted[entries[i].UserID] {
+			continue
+		}
+		entries[i].RankDelta = prevRank[entries[i].UserID] - rank
+	}
+	l.Entries = entries
+	l.Version = patch.Version
+	l.Total = patch.Total
+	l.Teams = patch.Teams
+	return nil
+}
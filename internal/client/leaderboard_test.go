@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/infra/memory"
+)
+
+// TestPatchesReproduceServerLeaderboard replays random joins, answers and leaves, applying the
+// patch stream locally, and checks the result against the server's own snapshot after every step.
+func TestPatchesReproduceServerLeaderboard(t *testing.T) {
+	ctx := context.Background()
+	service := app.NewQuizService(memory.NewSessionStore(), memory.NewQuizRepository(memory.NewStaticQuizLoader(map[string]domain.Quiz{
+		"quiz-1": {
+			ID: "quiz-1",
+			Questions: []domain.Question{{
+				ID: "q1",
+				Options: []domain.Option{
+					{ID: "o1", Correct: false},
+					{ID: "o2", Correct: true},
+				},
+				Points: 1,
+			}},
+		},
+	}), time.Minute))
+
+	// Changed answers keep rescoring the same question, so scores move up and down.
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{AnswerChange: domain.AnswerChangeAllow})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	sessionID := info.SessionID
+	if _, err := service.Join(ctx, sessionID, "anchor", "Anchor"); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	updates, cancel, err := service.SubscribeView(ctx, sessionID, "anchor", domain.LeaderboardView{Patch: true})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer cancel()
+
+	local := &Leaderboard{}
+	catchUp := func() {
+		patches, snapshot, err := service.LeaderboardSince(ctx, sessionID, local.Version)
+		if err != nil {
+			t.Fatalf("since: %v", err)
+		}
+		if snapshot != nil {
+			local.Reset(*snapshot)
+		}
+		for _, patch := range patches {
+			if err := local.Apply(patch); err != nil {
+				t.Fatalf("apply catch-up patch: %v", err)
+			}
+		}
+	}
+	catchUp()
+
+	rnd := rand.New(rand.NewSource(42))
+	for step := 0; step < 400; step++ {
+		userID := fmt.Sprintf("u%d", rnd.Intn(25))
+		switch op := rnd.Intn(10); {
+		case op < 3:
+			_, err = service.Join(ctx, sessionID, userID, fmt.Sprintf("Player %d", rnd.Intn(8)))
+		case op < 9:
+			option := []string{"o1", "o2"}[rnd.Intn(2)]
+			_, _, err = service.SubmitAnswer(ctx, sessionID, userID, domain.AnswerSubmission{QuestionID: "q1", OptionID: option})
+		default:
+			service.Leave(ctx, sessionID, userID)
+		}
+		if err != nil && !errors.Is(err, domain.ErrParticipantNotFound) {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		err = nil
+
+		// Every so often let the subscriber buffer overflow so stale patches are dropped.
+		if step%50 > 40 {
+			continue
+		}
+		for len(updates) > 0 {
+			b := <-updates
+			if err := local.Apply(b.Payload.(domain.LeaderboardPatch)); errors.Is(err, ErrVersionGap) {
+				catchUp()
+			} else if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+		}
+
+		want, err := service.LeaderboardView(ctx, sessionID, "", domain.LeaderboardView{})
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+		if local.Version != want.Version || !reflect.DeepEqual(local.Entries, want.Entries) {
+			t.Fatalf("step %d: local v%d %+v\nserver v%d %+v", step, local.Version, local.Entries, want.Version, want.Entries)
+		}
+	}
+}
+
+func TestApplyRejectsVersionGaps(t *testing.T) {
+	local := &Leaderboard{Version: 3}
+	err := local.Apply(domain.LeaderboardPatch{BaseVersion: 5, Version: 6})
+	if !errors.Is(err, ErrVersionGap) {
+		t.Fatalf("expected version gap, got %v", err)
+	}
+	if err := local.Apply(domain.LeaderboardPatch{BaseVersion: 1, Version: 2}); err != nil {
+		t.Fatalf("expected stale patch to be ignored, got %v", err)
+	}
+}
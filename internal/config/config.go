@@ -1,7 +1,10 @@
 package config
 
 import (
+	"bufio"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -10,6 +13,10 @@ import (
 type Config struct {
 	Server struct {
 		Port string `yaml:"port"`
+		// AdminToken guards the quiz reporting routes; they are refused while it is empty.
+		AdminToken string `yaml:"adminToken"`
+		// DebugAddr is where /debug/vars is served, apart from the public port; empty turns it off.
+		DebugAddr string `yaml:"debugAddr"`
 	} `yaml:"server"`
 	Redis struct {
 		Addr     string `yaml:"addr"`
@@ -20,11 +27,54 @@ type Config struct {
 	Postgres struct {
 		URL string `yaml:"url"`
 	} `yaml:"postgres"`
+	// SQLite keeps content and results in a single database file instead of Postgres.
+	SQLite struct {
+		Path string `yaml:"path"`
+	} `yaml:"sqlite"`
+	Session struct {
+		JoinCodeTTL     string `yaml:"joinCodeTtl"`
+		MaxParticipants int    `yaml:"maxParticipants"`
+	} `yaml:"session"`
+	Names struct {
+		MinLength     int    `yaml:"minLength"`
+		MaxLength     int    `yaml:"maxLength"`
+		DefaultLocale string `yaml:"defaultLocale"`
+		ProfanityDir  string `yaml:"profanityDir"`
+	} `yaml:"names"`
 	Quiz struct {
-		TTL string `yaml:"ttl"`
+		TTL             string `yaml:"ttl"`
+		NotFoundTTL     string `yaml:"notFoundTtl"` // how long unknown quiz IDs are remembered
+		StaleTTL        string `yaml:"staleTtl"`    // how long an expired quiz is served while it reloads
+		BroadcastWindow string `yaml:"broadcastWindow"`
+		// Source is where quizzes are read from: QuizSourcePostgres, QuizSourceSQLite,
+		// QuizSourceFiles or QuizSourceSample. It defaults to the configured database, if any.
+		Source       string `yaml:"source"`
+		Dir          string `yaml:"dir"`          // quiz files, for the files source
+		PollInterval string `yaml:"pollInterval"` // how often Dir is checked for changes
 	} `yaml:"quiz"`
 }
 
+// Quiz sources.
+const (
+	QuizSourcePostgres = "postgres"
+	QuizSourceSQLite   = "sqlite"
+	QuizSourceFiles    = "files"
+	QuizSourceSample   = "sample"
+)
+
+// QuizSource returns the configured quiz source, filling in the default.
+func (c Config) QuizSource() string {
+	switch {
+	case c.Quiz.Source != "":
+		return c.Quiz.Source
+	case c.Postgres.URL != "":
+		return QuizSourcePostgres
+	case c.SQLite.Path != "":
+		return QuizSourceSQLite
+	}
+	return QuizSourceSample
+}
+
 // Load reads YAML config from path.
 func Load(path string) (Config, error) {
 	cfg := Config{}
@@ -48,3 +98,39 @@ func TTLDuration(raw string, fallback time.Duration) time.Duration {
 	}
 	return fallback
 }
+
+// LoadWordLists reads one word list per locale from dir, named after the locale ("en.txt").
+// Lists hold one word per line; blank lines and lines starting with # are skipped.
+func LoadWordLists(dir string) (map[string][]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	lists := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		words, err := readWordList(path)
+		if err != nil {
+			return nil, err
+		}
+		lists[strings.TrimSuffix(filepath.Base(path), ".txt")] = words
+	}
+	return lists, nil
+}
+
+func readWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrSessionNotFound is returned when a quiz session has not been initialized.
@@ -9,8 +12,89 @@ var (
 	ErrParticipantNotFound = errors.New("participant not found in quiz")
 	// ErrQuizNotFound indicates the quiz content could not be loaded.
 	ErrQuizNotFound = errors.New("quiz not found")
+	// ErrQuizVersionNotFound indicates a quiz version that was never stored.
+	ErrQuizVersionNotFound = errors.New("quiz version not found")
+	// ErrQuizVersionConflict is returned when another save of the same quiz took the version number.
+	ErrQuizVersionConflict = errors.New("quiz version saved concurrently")
+	// ErrBankNotFound indicates a quiz draws from a question bank that doesn't exist.
+	ErrBankNotFound = errors.New("question bank not found")
+	// ErrNotEnoughQuestions is returned when a question bank can't fill one of a quiz's draws.
+	ErrNotEnoughQuestions = errors.New("not enough questions in bank")
 	// ErrQuestionNotFound indicates a submitted question ID is invalid.
 	ErrQuestionNotFound = errors.New("question not found")
 	// ErrOptionNotFound indicates a submitted option ID is invalid.
 	ErrOptionNotFound = errors.New("option not found")
+	// ErrTeamNotFound indicates a team that isn't configured for the quiz.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamRequired is returned when a team quiz doesn't auto-assign and no team was picked.
+	ErrTeamRequired = errors.New("team required")
+	// ErrJoinCodeNotFound indicates an unknown or expired join code.
+	ErrJoinCodeNotFound = errors.New("join code not found")
+	// ErrInvalidQuiz wraps quiz content that fails validation.
+	ErrInvalidQuiz = errors.New("invalid quiz")
+	// ErrInvalidSettings wraps session settings that fail validation.
+	ErrInvalidSettings = errors.New("invalid session settings")
+	// ErrSessionFull is returned when a session has reached its participant limit.
+	ErrSessionFull = errors.New("session is full")
+	// ErrAdmissionRequired is returned when a moderated session needs the host to admit a player first.
+	ErrAdmissionRequired = errors.New("waiting for host admission")
+	// ErrNotWaiting is returned when a host acts on a player who isn't in the waiting room.
+	ErrNotWaiting = errors.New("participant is not waiting")
+	// ErrBanned is returned when a banned user tries to rejoin a session.
+	ErrBanned = errors.New("banned from this session")
+	// ErrInvalidName is returned for display names that can't be used.
+	ErrInvalidName = errors.New("invalid display name")
+	// ErrAuditNoteRequired is returned when a score adjustment comes without an audit note.
+	ErrAuditNoteRequired = errors.New("an audit note is required")
+	// ErrLateJoinClosed is returned when a session refuses new players after it has started.
+	ErrLateJoinClosed = errors.New("session has already started")
+	// ErrAssignmentClosed is returned once a self-paced session's deadline has passed.
+	ErrAssignmentClosed = errors.New("assignment is closed")
+	// ErrNotSelfPaced is returned when a self-paced action is used in a live session.
+	ErrNotSelfPaced = errors.New("session is not self-paced")
+	// ErrQuestionNotReached is returned for answers to a self-paced question the participant hasn't been given yet.
+	ErrQuestionNotReached = errors.New("question not reached yet")
+	// ErrAlreadyAnswered is returned when answers are final and the question was answered before.
+	ErrAlreadyAnswered = errors.New("question already answered")
+	// ErrTimeUp is returned for answers that arrive after a question's time limit.
+	ErrTimeUp = errors.New("time limit exceeded")
+	// ErrQuestionClosed is returned for answers to a question the host has closed.
+	ErrQuestionClosed = errors.New("question is closed")
+	// ErrInvalidResponse is returned when a submission doesn't fit the question's type, e.g. an
+	// answer to a poll or a rating outside the scale.
+	ErrInvalidResponse = errors.New("response does not fit the question")
+	// ErrLifelineUnavailable is returned for a lifeline the question or session doesn't offer,
+	// or that the player has used up.
+	ErrLifelineUnavailable = errors.New("lifeline not available")
+	// ErrLifelineUsed is returned when a player asks for the same 50:50 twice on one question.
+	ErrLifelineUsed = errors.New("lifeline already used on this question")
+	// ErrLeaderboardHidden is returned when a player asks for leaderboard patches in a session that hides the leaderboard.
+	ErrLeaderboardHidden = errors.New("leaderboard is hidden in this session")
+	// ErrHostOnly is returned when a host-only action is attempted without the host token.
+	ErrHostOnly = errors.New("host token required")
+	// ErrInvalidView indicates a leaderboard view combines options that can't be served together.
+	ErrInvalidView = errors.New("patch mode requires the full leaderboard view")
+)
+
+// Display name rejection reasons carried by NameError.
+const (
+	NameEmpty     = "empty"
+	NameTooShort  = "tooShort"
+	NameTooLong   = "tooLong"
+	NameProfanity = "profanity"
 )
+
+// NameError explains why a display name was rejected. It matches ErrInvalidName with errors.Is.
+type NameError struct {
+	Reason string `json:"reason"`
+	Min    int    `json:"min,omitempty"`
+	Max    int    `json:"max,omitempty"`
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidName, e.Reason)
+}
+
+func (e *NameError) Unwrap() error {
+	return ErrInvalidName
+}
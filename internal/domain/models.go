@@ -1,41 +1,245 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // Participant represents a quiz participant and their accumulated score.
 type Participant struct {
 	UserID      string
 	DisplayName string
+	Team        string
 	Score       int
 	LastUpdated time.Time
+	Finished    bool // answered or timed out on every question of a self-paced session
 }
 
 // LeaderboardEntry is a snapshot-friendly view of a participant.
 type LeaderboardEntry struct {
 	UserID      string `json:"userId"`
 	DisplayName string `json:"displayName"`
+	Team        string `json:"team,omitempty"`
 	Score       int    `json:"score"`
+	Rank        int    `json:"rank"`      // 1-based position
+	RankDelta   int    `json:"rankDelta"` // positions gained since the previous broadcast (negative when dropping)
+	Finished    bool   `json:"finished,omitempty"`
+}
+
+// SessionInfo describes a live session created by a host.
+type SessionInfo struct {
+	SessionID string          `json:"sessionId"`
+	QuizID    string          `json:"quizId"`
+	JoinCode  string          `json:"joinCode,omitempty"`
+	HostToken string          `json:"hostToken,omitempty"` // only returned to the creator
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Settings  SessionSettings `json:"settings"`
+	// QuizVersion is the version of the quiz the session is pinned to, whatever is published later.
+	QuizVersion int `json:"quizVersion,omitempty"`
+	// QuestionIDs lists, in order, the questions drawn for a quiz that uses question banks.
+	QuestionIDs []string `json:"questionIds,omitempty"`
 }
 
 // Leaderboard captures the ordered scoreboard for a quiz session.
 type Leaderboard struct {
+	SessionID string             `json:"sessionId"`
 	QuizID    string             `json:"quizId"`
 	Entries   []LeaderboardEntry `json:"entries"`
 	UpdatedAt time.Time          `json:"updatedAt"`
+	Total     int                `json:"total"`
+	Version   uint64             `json:"version"`
+	Teams     []TeamEntry        `json:"teams,omitempty"`
+	// Me and Around are only set on personalized views.
+	Me     *LeaderboardEntry  `json:"me,omitempty"`
+	Around []LeaderboardEntry `json:"around,omitempty"`
+}
+
+// TeamEntry is one row of the team leaderboard.
+type TeamEntry struct {
+	Team    string  `json:"team"`
+	Score   float64 `json:"score"`
+	Members int     `json:"members"`
+	Rank    int     `json:"rank"`
+}
+
+// LeaderboardView bounds what a subscriber receives. The zero value means the full leaderboard.
+type LeaderboardView struct {
+	Top    int `json:"top"`    // leading entries to include
+	Around int `json:"around"` // entries on each side of the subscriber's own rank
+	// Patch delivers LeaderboardPatch deltas instead of snapshots; only valid for the full leaderboard.
+	Patch bool `json:"patch"`
+}
+
+// Full reports whether the view asks for every entry.
+func (v LeaderboardView) Full() bool {
+	return v.Top <= 0 && v.Around <= 0
 }
 
-// AnswerSubmission models the scoring signal from clients.
+// Patch operations, applied in order. Upsert and move carry the entry's final rank.
+const (
+	PatchUpsert = "upsert" // entry joined or its score/name changed
+	PatchMove   = "move"   // only the entry's position changed
+	PatchRemove = "remove" // entry left the leaderboard
+)
+
+// PatchOp is a single row change in a LeaderboardPatch.
+type PatchOp struct {
+	Op     string            `json:"op"`
+	UserID string            `json:"userId"`
+	Rank   int               `json:"rank,omitempty"`
+	Entry  *LeaderboardEntry `json:"entry,omitempty"`
+}
+
+// LeaderboardPatch turns the leaderboard at BaseVersion into the one at Version.
+type LeaderboardPatch struct {
+	SessionID   string    `json:"sessionId"`
+	QuizID      string    `json:"quizId"`
+	BaseVersion uint64    `json:"baseVersion"`
+	Version     uint64    `json:"version"`
+	Ops         []PatchOp `json:"ops"`
+	Total       int       `json:"total"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	// Teams is the complete team leaderboard; it is small enough to resend on every patch.
+	Teams []TeamEntry `json:"teams,omitempty"`
+}
+
+// AnswerSubmission models the scoring signal from clients. Polls use the field that fits their
+// type: OptionID, OptionIDs for multi choice, Rating or Text.
 type AnswerSubmission struct {
 	QuestionID string
 	OptionID   string
+	OptionIDs  []string
+	// Position and Positions pick options by where the player saw them (1-based) instead of by
+	// ID, for sessions that shuffle options; they are mapped back to OptionID and OptionIDs.
+	Position  int
+	Positions []int
+	Rating    *int
+	Text      string
 }
 
 // AnswerResult summarizes the outcome of a submission for a single user.
 type AnswerResult struct {
 	QuestionID string `json:"questionId"`
 	Correct    bool   `json:"correct"`
-	Awarded    int    `json:"awarded"`
+	Awarded    int    `json:"awarded"`           // after Penalty, so it can be negative
+	Penalty    int    `json:"penalty,omitempty"` // cost of the lifelines used on the question
 	TotalScore int    `json:"totalScore"`
+	// Ability is the running estimate in adaptive sessions.
+	Ability *Ability `json:"ability,omitempty"`
+}
+
+// AnswerRecord is a participant's latest answer to a graded question in a session, kept after
+// the session ends for item analytics.
+type AnswerRecord struct {
+	SessionID   string    `json:"sessionId"`
+	QuizID      string    `json:"quizId"`
+	QuizVersion int       `json:"quizVersion,omitempty"` // the version the session was pinned to
+	QuestionID  string    `json:"questionId"`
+	UserID      string    `json:"userId"`
+	OptionID    string    `json:"optionId"`
+	Correct     bool      `json:"correct"`
+	Awarded     int       `json:"awarded"`
+	ResponseMs  int64     `json:"responseMs,omitempty"` // since the question was opened; zero if it never was
+	AnsweredAt  time.Time `json:"answeredAt"`
+}
+
+// QuizAnalytics describes how one version of a quiz's questions performed across every
+// session recorded against it.
+type QuizAnalytics struct {
+	QuizID      string          `json:"quizId"`
+	Version     int             `json:"version,omitempty"`
+	Respondents int             `json:"respondents"` // participants, counted once per session
+	Items       []ItemAnalytics `json:"items"`
+}
+
+// ItemAnalytics are classical test statistics for one question.
+type ItemAnalytics struct {
+	QuestionID string `json:"questionId"`
+	Prompt     string `json:"prompt,omitempty"`
+	Responses  int    `json:"responses"`
+	// PValue is the share of responses that were correct: low values mark hard questions.
+	PValue float64 `json:"pValue"`
+	// PointBiserial correlates getting the question right with the respondent's score on the
+	// other questions; values near zero or below mark questions that don't separate strong
+	// from weak respondents, often because they are ambiguous.
+	PointBiserial float64           `json:"pointBiserial"`
+	AvgResponseMs float64           `json:"avgResponseMs"`
+	Options       []DistractorStats `json:"options"`
+}
+
+// DistractorStats is how often an option was picked; Rate is a share of the question's responses.
+type DistractorStats struct {
+	OptionID string  `json:"optionId"`
+	Text     string  `json:"text,omitempty"`
+	Correct  bool    `json:"correct,omitempty"`
+	Count    int     `json:"count"`
+	Rate     float64 `json:"rate"`
+}
+
+// Lifelines a player can use on a question before answering it.
+const (
+	LifelineFiftyFifty = "fiftyFifty" // removes two wrong options from a graded question
+	LifelineHint       = "hint"       // reveals the question's next hint
+)
+
+// LifelineResult is what a lifeline gave the player and what it will cost them.
+type LifelineResult struct {
+	QuestionID       string   `json:"questionId"`
+	Lifeline         string   `json:"lifeline"`
+	RemovedOptionIDs []string `json:"removedOptionIds,omitempty"`
+	Hint             string   `json:"hint,omitempty"`
+	Cost             int      `json:"cost"`
+	// Remaining is how many more 50:50s the player has in the session, or hints for the question.
+	Remaining int `json:"remaining"`
+}
+
+// Reveal goes to everyone in a session when a question closes.
+type Reveal struct {
+	QuestionID       string   `json:"questionId"`
+	CorrectOptionIDs []string `json:"correctOptionIds,omitempty"`
+	Explanation      string   `json:"explanation,omitempty"`
+}
+
+// AnswerStats is the live answer distribution for one question, as a classroom clicker shows it.
+type AnswerStats struct {
+	SessionID      string        `json:"sessionId"`
+	QuestionID     string        `json:"questionId"`
+	Options        []OptionStats `json:"options"`
+	Responses      int           `json:"responses"`
+	Participants   int           `json:"participants"`
+	PercentCorrect float64       `json:"percentCorrect"`
+	// AvgResponseMs averages the time from the host opening the question to each answer.
+	AvgResponseMs int64 `json:"avgResponseMs,omitempty"`
+	Closed        bool  `json:"closed,omitempty"`
+
+	// Poll results: Type is set for ungraded questions, which fill in whichever of these fits.
+	Type          string        `json:"type,omitempty"`
+	Ratings       []RatingCount `json:"ratings,omitempty"`
+	AverageRating float64       `json:"averageRating,omitempty"`
+	Words         []WordCount   `json:"words,omitempty"`
+}
+
+// RatingCount counts the responses giving one value on a rating scale.
+type RatingCount struct {
+	Value int `json:"value"`
+	Count int `json:"count"`
+}
+
+// WordCount counts the word cloud responses that used a word.
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// OptionStats counts the answers given for one option.
+type OptionStats struct {
+	OptionID string  `json:"optionId"`
+	Count    int     `json:"count"`
+	Percent  float64 `json:"percent"`
+	Correct  bool    `json:"correct,omitempty"`
 }
 
 // Option represents a possible answer for a question.
@@ -45,16 +249,570 @@ type Option struct {
 	Correct bool   `json:"correct"`
 }
 
-// Question models an MCQ question with exactly one correct option.
+// Question types. Graded multiple choice is the default; the others are ungraded polls that
+// record responses without ever touching scores.
+const (
+	QuestionChoice    = "choice"    // graded: exactly one correct option
+	QuestionPoll      = "poll"      // pick one option
+	QuestionMultiPoll = "multiPoll" // pick any number of options
+	QuestionRating    = "rating"    // pick a whole number on a scale
+	QuestionWordCloud = "wordCloud" // short free text, tallied word by word
+)
+
+// Question models a quiz question: a graded MCQ with exactly one correct option unless Type
+// makes it a poll.
 type Question struct {
-	ID      string   `json:"id"`
-	Prompt  string   `json:"prompt"`
-	Options []Option `json:"options"`
-	Points  int      `json:"points"` // defaults to 1 if zero
+	ID      string       `json:"id"`
+	Type    string       `json:"type,omitempty"` // defaults to QuestionChoice
+	Prompt  string       `json:"prompt"`
+	Options []Option     `json:"options"`
+	Points  int          `json:"points"`          // defaults to 1 if zero
+	Scale   *RatingScale `json:"scale,omitempty"` // rating questions only; defaults to 1-5
+	// Explanation is shown to everyone when the question closes.
+	Explanation string `json:"explanation,omitempty"`
+	// Hints are handed out one at a time, in order, to players who ask for them.
+	Hints []Hint `json:"hints,omitempty"`
+	// Tags and Difficulty let quizzes draw the question from a question bank.
+	Tags       []string `json:"tags,omitempty"`
+	Difficulty string   `json:"difficulty,omitempty"`
+	// IRT calibrates the question for adaptive sessions.
+	IRT *ItemParams `json:"irt,omitempty"`
+}
+
+// ItemParams are a question's item response theory parameters: the chance a learner of ability
+// theta answers correctly is 1 / (1 + e^(-a(theta-b))). Leaving out a gives the Rasch model (a=1).
+type ItemParams struct {
+	Discrimination float64 `json:"a,omitempty"`
+	Difficulty     float64 `json:"b"`
+}
+
+// Ability is a learner's estimated ability on the IRT scale, with its standard error.
+type Ability struct {
+	Theta         float64 `json:"theta"`
+	StandardError float64 `json:"standardError"`
+	Answered      int     `json:"answered"`
+}
+
+// SelfPacedStep is where a participant stands in a self-paced session: the question they are on,
+// or Finished once there are none left.
+type SelfPacedStep struct {
+	SessionID string          `json:"sessionId"`
+	Question  *PlayerQuestion `json:"question,omitempty"`
+	Number    int             `json:"number"` // 1-based position of Question
+	Total     int             `json:"total"`
+	// QuestionEndsAt is when the participant's own time limit on Question runs out.
+	QuestionEndsAt *time.Time `json:"questionEndsAt,omitempty"`
+	Deadline       time.Time  `json:"deadline"`
+	Score          int        `json:"score"`
+	Ability        *Ability   `json:"ability,omitempty"` // adaptive sessions only
+	Finished       bool       `json:"finished,omitempty"`
+}
+
+// PlayerQuestion is a question as one participant sees it: options in their display order,
+// without the answers.
+type PlayerQuestion struct {
+	ID      string         `json:"id"`
+	Type    string         `json:"type,omitempty"`
+	Prompt  string         `json:"prompt"`
+	Options []PlayerOption `json:"options,omitempty"`
+	Points  int            `json:"points"`
+	Scale   *RatingScale   `json:"scale,omitempty"`
+	Hints   int            `json:"hints,omitempty"` // how many hints can be bought
 }
 
-// Quiz is a collection of questions.
+// PlayerOption is an option at the 1-based Position the participant sees it in.
+type PlayerOption struct {
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Position int    `json:"position"`
+}
+
+// Layout records the order a participant was shown a session's questions and each question's
+// options in, and the options their 50:50s removed, so replays and exports can show what they saw.
+type Layout struct {
+	SessionID   string              `json:"sessionId"`
+	UserID      string              `json:"userId"`
+	QuestionIDs []string            `json:"questionIds"`
+	Options     map[string][]string `json:"options"`           // questionID -> option IDs in display order
+	Removed     map[string][]string `json:"removed,omitempty"` // questionID -> option IDs a 50:50 took away
+}
+
+// Hint is a clue a player can buy; Cost comes off the points they get for the question.
+type Hint struct {
+	Text string `json:"text"`
+	Cost int    `json:"cost,omitempty"`
+}
+
+// Graded reports whether answers to the question are scored.
+func (q Question) Graded() bool {
+	return q.Type == "" || q.Type == QuestionChoice
+}
+
+// RatingScale bounds the answers to a rating question, inclusive.
+type RatingScale struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// RatingBounds returns the question's scale, or 1-5 when it doesn't set one.
+func (q Question) RatingBounds() (int, int) {
+	if q.Scale == nil {
+		return 1, 5
+	}
+	return q.Scale.Min, q.Scale.Max
+}
+
+// Team score aggregation strategies.
+const (
+	TeamAggregateSum     = "sum"
+	TeamAggregateAverage = "average"
+	TeamAggregateBestN   = "bestN" // sum of the top BestN member scores
+)
+
+// TeamSettings enables team play for a quiz.
+type TeamSettings struct {
+	Teams     []string `json:"teams"`
+	Aggregate string   `json:"aggregate,omitempty"` // defaults to sum
+	BestN     int      `json:"bestN,omitempty"`
+	// AutoAssign places players who don't pick a team on the smallest one.
+	AutoAssign bool `json:"autoAssign,omitempty"`
+}
+
+// QuizSettings carries per-quiz tuning authored alongside the content.
+type QuizSettings struct {
+	// BroadcastWindowMs batches leaderboard broadcasts within the window; zero uses the service default.
+	BroadcastWindowMs int           `json:"broadcastWindowMs,omitempty"`
+	Teams             *TeamSettings `json:"teams,omitempty"`
+}
+
+// Session setting values. The first value of each group is the default.
+const (
+	ModeLive      = "live"      // the host opens questions for everyone at once
+	ModeSelfPaced = "selfPaced" // participants work through the questions on their own clock
+	ModeAdaptive  = "adaptive"  // self-paced, with questions picked by item response theory
+
+	ScoringStandard = "standard" // correct answers earn the question's points
+	ScoringSpeed    = "speed"    // correct answers earn 50-100% of the points, scaled by time left
+
+	AnswerChangeNone  = "none"  // the first answer to a question is final
+	AnswerChangeAllow = "allow" // a new answer replaces the previous one and its score
+
+	LeaderboardLive   = "live"   // everyone sees the leaderboard
+	LeaderboardHidden = "hidden" // players only see their own entry; hosts see everything
+
+	LateJoinAllow = "allow" // players may join at any time
+	LateJoinDeny  = "deny"  // new players are refused once the first question opens or is answered
+
+	AdmissionAuto = "auto" // waiting players are admitted in order as slots free up
+	AdmissionHost = "host" // every new player waits until the host admits them
+
+	DevicesMultiple = "multiple" // a player may connect from several devices; they leave when the last one closes
+	DevicesTakeover = "takeover" // a new connection closes the player's older one
+)
+
+// DefaultMaxWaiting bounds a waiting room when the host doesn't pick a size.
+const DefaultMaxWaiting = 1000
+
+// DefaultAdaptiveSE is the standard error at which adaptive sessions stop asking questions.
+const DefaultAdaptiveSE = 0.3
+
+// SessionSettings are chosen by the host when creating a session and apply to that run only.
+type SessionSettings struct {
+	// Mode is live (the host opens questions for everyone) or self-paced (each participant works
+	// through the questions on their own until Deadline, which defaults to the join code expiry).
+	// Adaptive sessions are self-paced, but pick each participant's next question by their
+	// ability estimate and stop once its standard error is below AdaptiveSE (default 0.3) or
+	// after AdaptiveMaxQuestions.
+	Mode                  string     `json:"mode,omitempty"`
+	Deadline              *time.Time `json:"deadline,omitempty"`
+	AdaptiveSE            float64    `json:"adaptiveSE,omitempty"`
+	AdaptiveMaxQuestions  int        `json:"adaptiveMaxQuestions,omitempty"`
+	Scoring               string     `json:"scoring,omitempty"`
+	QuestionTimeLimitMs   int        `json:"questionTimeLimitMs,omitempty"` // measured from when the host opens a question
+	MaxParticipants       int        `json:"maxParticipants,omitempty"`     // zero means unlimited
+	AnswerChange          string     `json:"answerChange,omitempty"`
+	LeaderboardVisibility string     `json:"leaderboardVisibility,omitempty"`
+	LateJoin              string     `json:"lateJoin,omitempty"`
+	// WaitingRoom queues players who arrive while the session is full instead of refusing them.
+	// Host admission always uses the waiting room.
+	WaitingRoom bool   `json:"waitingRoom,omitempty"`
+	MaxWaiting  int    `json:"maxWaiting,omitempty"`
+	Admission   string `json:"admission,omitempty"`
+	Devices     string `json:"devices,omitempty"`
+	// RevealStats shows players a question's answer distribution once it closes.
+	RevealStats bool `json:"revealStats,omitempty"`
+	// FiftyFifty is how many 50:50 lifelines each player gets (none by default), and
+	// FiftyFiftyCost the points each one takes off the question's award.
+	FiftyFifty     int `json:"fiftyFifty,omitempty"`
+	FiftyFiftyCost int `json:"fiftyFiftyCost,omitempty"`
+	// ShuffleOptions shows each participant a question's options in their own order, and
+	// ShuffleQuestions does the same for the question order in self-paced sessions. Both orders
+	// follow from the session and user IDs, so they can be rebuilt later.
+	ShuffleOptions   bool `json:"shuffleOptions,omitempty"`
+	ShuffleQuestions bool `json:"shuffleQuestions,omitempty"`
+	// Seed makes question bank draws reproducible: zero picks a random one, and the seed used
+	// is recorded here, so creating a session with the same seed draws the same questions.
+	Seed int64 `json:"seed,omitempty"`
+	// Locale picks the profanity list applied to display names, on top of the server default.
+	Locale string `json:"locale,omitempty"`
+	// BroadcastWindowMs and Teams default to the quiz's own settings.
+	BroadcastWindowMs int           `json:"broadcastWindowMs,omitempty"`
+	Teams             *TeamSettings `json:"teams,omitempty"`
+}
+
+// WithDefaults fills unset fields from the defaults and the quiz's own settings.
+func (s SessionSettings) WithDefaults(quiz QuizSettings) SessionSettings {
+	if s.Mode == "" {
+		s.Mode = ModeLive
+	}
+	if s.Mode == ModeAdaptive && s.AdaptiveSE == 0 {
+		s.AdaptiveSE = DefaultAdaptiveSE
+	}
+	if s.Scoring == "" {
+		s.Scoring = ScoringStandard
+	}
+	if s.AnswerChange == "" {
+		s.AnswerChange = AnswerChangeNone
+	}
+	if s.LeaderboardVisibility == "" {
+		s.LeaderboardVisibility = LeaderboardLive
+	}
+	if s.LateJoin == "" {
+		s.LateJoin = LateJoinAllow
+	}
+	if s.Admission == "" {
+		s.Admission = AdmissionAuto
+	}
+	if s.Devices == "" {
+		s.Devices = DevicesMultiple
+	}
+	if s.Admission == AdmissionHost {
+		s.WaitingRoom = true
+	}
+	if s.WaitingRoom && s.MaxWaiting == 0 {
+		s.MaxWaiting = DefaultMaxWaiting
+	}
+	if s.BroadcastWindowMs == 0 {
+		s.BroadcastWindowMs = quiz.BroadcastWindowMs
+	}
+	if s.Teams == nil {
+		s.Teams = quiz.Teams
+	}
+	return s
+}
+
+// SelfPaced reports whether participants work through the questions on their own.
+func (s SessionSettings) SelfPaced() bool {
+	return s.Mode == ModeSelfPaced || s.Mode == ModeAdaptive
+}
+
+// Validate rejects unknown option values and negative limits.
+func (s SessionSettings) Validate() error {
+	checks := []struct {
+		field, value string
+		allowed      []string
+	}{
+		{"mode", s.Mode, []string{ModeLive, ModeSelfPaced, ModeAdaptive}},
+		{"scoring", s.Scoring, []string{ScoringStandard, ScoringSpeed}},
+		{"answerChange", s.AnswerChange, []string{AnswerChangeNone, AnswerChangeAllow}},
+		{"leaderboardVisibility", s.LeaderboardVisibility, []string{LeaderboardLive, LeaderboardHidden}},
+		{"lateJoin", s.LateJoin, []string{LateJoinAllow, LateJoinDeny}},
+		{"admission", s.Admission, []string{AdmissionAuto, AdmissionHost}},
+		{"devices", s.Devices, []string{DevicesMultiple, DevicesTakeover}},
+	}
+	for _, c := range checks {
+		if c.value != "" && !slices.Contains(c.allowed, c.value) {
+			return fmt.Errorf("%w: %s must be one of %v", ErrInvalidSettings, c.field, c.allowed)
+		}
+	}
+	if s.QuestionTimeLimitMs < 0 || s.MaxParticipants < 0 || s.MaxWaiting < 0 || s.BroadcastWindowMs < 0 ||
+		s.FiftyFifty < 0 || s.FiftyFiftyCost < 0 || s.AdaptiveSE < 0 || s.AdaptiveMaxQuestions < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidSettings)
+	}
+	if s.Deadline != nil && !s.SelfPaced() {
+		return fmt.Errorf("%w: deadline needs mode %s or %s", ErrInvalidSettings, ModeSelfPaced, ModeAdaptive)
+	}
+	return nil
+}
+
+// WaitingStatus is pushed to a player in a session's waiting room whenever their place changes.
+type WaitingStatus struct {
+	SessionID string `json:"sessionId"`
+	Position  int    `json:"position"` // 1-based; 0 once admitted or denied
+	Waiting   int    `json:"waiting"`
+	Admitted  bool   `json:"admitted,omitempty"`
+	Denied    bool   `json:"denied,omitempty"`
+}
+
+// WaitingEntry is a waiting player as the host sees them.
+type WaitingEntry struct {
+	UserID      string    `json:"userId"`
+	DisplayName string    `json:"displayName"`
+	Position    int       `json:"position"`
+	Since       time.Time `json:"since"`
+}
+
+// Host moderation actions recorded in a session's audit log.
+const (
+	ModerationKick        = "kick"
+	ModerationBan         = "ban"
+	ModerationRename      = "rename"
+	ModerationAdjustScore = "adjustScore"
+	ModerationResetScore  = "resetScore"
+)
+
+// ModerationAction is one host action in a session's audit log.
+type ModerationAction struct {
+	Action      string    `json:"action"`
+	UserID      string    `json:"userId"`
+	Note        string    `json:"note,omitempty"` // kick/ban reason or score audit note
+	DisplayName string    `json:"displayName,omitempty"`
+	Delta       int       `json:"delta,omitempty"`
+	Score       int       `json:"score,omitempty"` // score after an adjustment
+	At          time.Time `json:"at"`
+}
+
+// Removal tells a participant's connections that the host removed them, or, with TakenOver,
+// that a newer connection replaced this one.
+type Removal struct {
+	Reason    string `json:"reason,omitempty"`
+	Banned    bool   `json:"banned,omitempty"`
+	TakenOver bool   `json:"takenOver,omitempty"`
+}
+
+// Quiz is a collection of questions. A quiz with Draws adds questions picked from question
+// banks to its own, afresh for each session.
 type Quiz struct {
+	ID string `json:"id"`
+	// Version numbers the quiz's immutable revisions, from 1; zero for sources that don't keep them.
+	Version   int            `json:"version,omitempty"`
+	Questions []Question     `json:"questions"`
+	Draws     []QuestionDraw `json:"draws,omitempty"`
+	Settings  QuizSettings   `json:"settings,omitempty"`
+}
+
+// QuizKey names one version of a quiz as quizID@version, the way loaders and caches key it.
+// Version zero stands for whichever version is published, and is just the quiz ID.
+func QuizKey(quizID string, version int) string {
+	if version == 0 {
+		return quizID
+	}
+	return fmt.Sprintf("%s@%d", quizID, version)
+}
+
+// Quiz version statuses. A quiz has at most one published version, which new sessions run;
+// publishing another archives it. Drafts are never served until published.
+const (
+	QuizDraft     = "draft"
+	QuizPublished = "published"
+	QuizArchived  = "archived"
+)
+
+// QuizVersion describes one stored revision of a quiz.
+type QuizVersion struct {
+	QuizID      string     `json:"quizId"`
+	Version     int        `json:"version"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+}
+
+// Kinds of QuizChange.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// QuizDiff lists what changed between two versions of a quiz.
+type QuizDiff struct {
+	QuizID  string       `json:"quizId"`
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Changes []QuizChange `json:"changes"`
+}
+
+// QuizChange is one difference between two quiz versions: a question added or removed, or one
+// field of a question, or of the quiz itself when Question is empty, changed.
+type QuizChange struct {
+	Kind     string `json:"kind"`
+	Question string `json:"question,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Before   any    `json:"before,omitempty"`
+	After    any    `json:"after,omitempty"`
+}
+
+func (c QuizChange) String() string {
+	subject := "quiz"
+	if c.Question != "" {
+		subject = "question " + c.Question
+	}
+	if c.Field == "" {
+		return fmt.Sprintf("%s %s", subject, c.Kind)
+	}
+	return fmt.Sprintf("%s %s %s: %s -> %s", subject, c.Field, c.Kind, compactJSON(c.Before), compactJSON(c.After))
+}
+
+// compactJSON renders a diff value on one line; missing values show as "-".
+func compactJSON(v any) string {
+	if v == nil {
+		return "-"
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
+
+// QuizProblem is one reason a quiz fails validation. Question is empty for problems with the
+// quiz as a whole.
+type QuizProblem struct {
+	Question string `json:"question,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (p QuizProblem) String() string {
+	if p.Question == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("question %s: %s", p.Question, p.Message)
+}
+
+// Problems lists everything that keeps the quiz from being played, in question order.
+func (q Quiz) Problems() []QuizProblem {
+	var problems []QuizProblem
+	add := func(question, format string, args ...any) {
+		problems = append(problems, QuizProblem{Question: question, Message: fmt.Sprintf(format, args...)})
+	}
+	if q.ID == "" {
+		add("", "id is required")
+	}
+	if len(q.Questions) == 0 && len(q.Draws) == 0 {
+		add("", "quiz has no questions")
+	}
+	seen := make(map[string]bool, len(q.Questions))
+	for i, question := range q.Questions {
+		id := question.ID
+		if id == "" {
+			id = fmt.Sprintf("#%d", i+1)
+			add(id, "id is required")
+		} else if seen[id] {
+			add(id, "duplicate question id")
+		}
+		seen[question.ID] = true
+		for _, msg := range question.problems() {
+			add(id, "%s", msg)
+		}
+	}
+	for i, draw := range q.Draws {
+		if draw.Bank == "" {
+			add("", "draw %d: bank is required", i+1)
+		}
+		if draw.Count <= 0 {
+			add("", "draw %d: count must be positive", i+1)
+		}
+		if draw.Difficulty != "" && !slices.Contains([]string{DifficultyEasy, DifficultyMedium, DifficultyHard}, draw.Difficulty) {
+			add("", "draw %d: unknown difficulty %q", i+1, draw.Difficulty)
+		}
+	}
+	if q.Settings.BroadcastWindowMs < 0 {
+		add("", "broadcastWindowMs must not be negative")
+	}
+	return problems
+}
+
+// Validate wraps ErrInvalidQuiz with the quiz's problems, if it has any.
+func (q Quiz) Validate() error {
+	problems := q.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.String()
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidQuiz, strings.Join(msgs, "; "))
+}
+
+func (q Question) problems() []string {
+	var problems []string
+	if strings.TrimSpace(q.Prompt) == "" {
+		problems = append(problems, "prompt is required")
+	}
+	if q.Points < 0 {
+		problems = append(problems, "points must not be negative")
+	}
+	optionIDs := make(map[string]bool, len(q.Options))
+	correct := 0
+	for _, o := range q.Options {
+		if o.ID == "" {
+			problems = append(problems, "option id is required")
+		} else if optionIDs[o.ID] {
+			problems = append(problems, fmt.Sprintf("duplicate option id %s", o.ID))
+		}
+		optionIDs[o.ID] = true
+		if o.Correct {
+			correct++
+		}
+	}
+	switch q.Type {
+	case "", QuestionChoice:
+		if len(q.Options) < 2 {
+			problems = append(problems, "needs at least two options")
+		}
+		if correct != 1 {
+			problems = append(problems, fmt.Sprintf("needs exactly one correct option, has %d", correct))
+		}
+	case QuestionPoll, QuestionMultiPoll:
+		if len(q.Options) < 2 {
+			problems = append(problems, "needs at least two options")
+		}
+		if correct > 0 {
+			problems = append(problems, "polls can't have correct options")
+		}
+	case QuestionRating:
+		if lo, hi := q.RatingBounds(); lo >= hi {
+			problems = append(problems, "scale min must be below max")
+		}
+	case QuestionWordCloud:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown type %q", q.Type))
+	}
+	for _, h := range q.Hints {
+		if h.Cost < 0 {
+			problems = append(problems, "hint costs must not be negative")
+			break
+		}
+	}
+	if q.Difficulty != "" && !slices.Contains([]string{DifficultyEasy, DifficultyMedium, DifficultyHard}, q.Difficulty) {
+		problems = append(problems, fmt.Sprintf("unknown difficulty %q", q.Difficulty))
+	}
+	if q.IRT != nil && q.IRT.Discrimination < 0 {
+		problems = append(problems, "irt discrimination must not be negative")
+	}
+	return problems
+}
+
+// Question difficulties used to pick questions from a bank.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// QuestionBank is a pool of questions that quizzes draw from.
+type QuestionBank struct {
 	ID        string     `json:"id"`
+	Name      string     `json:"name,omitempty"`
 	Questions []Question `json:"questions"`
 }
+
+// QuestionDraw picks Count questions at random from a bank, among those carrying every one of
+// Tags and, when set, of the given Difficulty: e.g. 5 easy questions tagged "vocab".
+type QuestionDraw struct {
+	Bank       string   `json:"bank"`
+	Tags       []string `json:"tags,omitempty"`
+	Difficulty string   `json:"difficulty,omitempty"`
+	Count      int      `json:"count"`
+}
@@ -0,0 +1,211 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/quizfmt"
+)
+
+// QuizLoader serves quizzes from a directory of YAML or JSON files, one quiz per file, named
+// after the quiz: quizzes/quiz-1.yaml holds quiz-1. Files are read up front and again by Reload
+// whenever their size or modification time changes.
+//
+// Every edit becomes a new version of the quiz, numbered by a hash of its content so that the
+// same content has the same version on every server and after restarts: caches shared between
+// servers can then key quizzes by version. The loader keeps the old versions it has read for as
+// long as it runs so sessions pinned to them can finish.
+type QuizLoader struct {
+	dir string
+
+	mu       sync.RWMutex
+	quizzes  map[string]domain.Quiz   // the version each file holds now
+	versions map[string][]domain.Quiz // every version read, in the order they were read
+	stamps   map[string]fileStamp
+}
+
+// fileStamp tells whether a file changed since it was last read.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewQuizLoader reads every quiz in dir.
+func NewQuizLoader(dir string) (*QuizLoader, error) {
+	l := &QuizLoader{
+		dir:      dir,
+		quizzes:  make(map[string]domain.Quiz),
+		versions: make(map[string][]domain.Quiz),
+		stamps:   make(map[string]fileStamp),
+	}
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *QuizLoader) LoadQuiz(_ context.Context, quizID string) (domain.Quiz, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if quiz, ok := l.quizzes[quizID]; ok {
+		return quiz, nil
+	}
+	return domain.Quiz{}, domain.ErrQuizNotFound
+}
+
+// LoadQuizVersion returns any version read since the loader started, even once its file is gone.
+func (l *QuizLoader) LoadQuizVersion(_ context.Context, quizID string, version int) (domain.Quiz, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if quiz, ok := findVersion(l.versions[quizID], version); ok {
+		return quiz, nil
+	}
+	return domain.Quiz{}, domain.ErrQuizVersionNotFound
+}
+
+func findVersion(versions []domain.Quiz, version int) (domain.Quiz, bool) {
+	for _, quiz := range versions {
+		if quiz.Version == version {
+			return quiz, true
+		}
+	}
+	return domain.Quiz{}, false
+}
+
+// quizFile reports whether name looks like a quiz file.
+func quizFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return !strings.HasPrefix(name, ".")
+	}
+	return false
+}
+
+// Reload rereads the files that changed and returns the IDs of the quizzes that were added,
+// changed or removed. A file that no longer parses or validates is logged and skipped, and the
+// last good copy of its quiz keeps being served until the file is fixed or deleted. A file
+// touched without changing its content, or restored to an earlier version, is not a new version.
+func (l *QuizLoader) Reload() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read quiz dir: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var changed []string
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !quizFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(l.dir, entry.Name())
+		quizID := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if seen[quizID] {
+			log.Printf("quiz file %s: another file already holds quiz %s; ignored", path, quizID)
+			continue
+		}
+		seen[quizID] = true
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+		if old, ok := l.stamps[quizID]; ok && old == stamp {
+			continue
+		}
+		l.stamps[quizID] = stamp
+		quiz, err := readQuiz(path, quizID)
+		if err != nil {
+			log.Printf("quiz file %s: %v", path, err)
+			continue
+		}
+		quiz.Version = contentVersion(quiz)
+		if _, ok := findVersion(l.versions[quizID], quiz.Version); !ok {
+			l.versions[quizID] = append(l.versions[quizID], quiz)
+		}
+		if current, ok := l.quizzes[quizID]; ok && current.Version == quiz.Version {
+			continue
+		}
+		l.quizzes[quizID] = quiz
+		changed = append(changed, quizID)
+	}
+	for quizID := range l.stamps {
+		if !seen[quizID] {
+			delete(l.stamps, quizID)
+			if _, ok := l.quizzes[quizID]; ok {
+				delete(l.quizzes, quizID)
+				changed = append(changed, quizID)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// contentVersion numbers a quiz by an FNV-1a hash of its JSON, kept positive and non-zero so it
+// reads as a version.
+func contentVersion(quiz domain.Quiz) int {
+	quiz.Version = 0
+	raw, _ := json.Marshal(quiz)
+	h := fnv.New32a()
+	h.Write(raw)
+	if version := int(h.Sum32() & 0x7fffffff); version > 0 {
+		return version
+	}
+	return 1
+}
+
+// readQuiz decodes and validates one file. JSON is read as YAML, which it is a subset of.
+func readQuiz(path, quizID string) (domain.Quiz, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return domain.Quiz{}, err
+	}
+	defer f.Close()
+	quiz, issues, err := quizfmt.Decode(quizfmt.FormatYAML, f, quizID)
+	if err != nil {
+		return domain.Quiz{}, err
+	}
+	for _, issue := range issues {
+		if !issue.Invalid {
+			log.Printf("quiz file %s: %s", path, issue)
+		}
+	}
+	if err := quiz.Validate(); err != nil {
+		return domain.Quiz{}, err
+	}
+	return quiz, nil
+}
+
+// Watch calls Reload every interval until ctx is done, handing each changed quiz ID to onChange
+// so caches in front of the loader can drop their copy.
+func (l *QuizLoader) Watch(ctx context.Context, interval time.Duration, onChange func(quizID string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := l.Reload()
+			if err != nil {
+				log.Printf("reload quizzes: %v", err)
+				continue
+			}
+			for _, quizID := range changed {
+				onChange(quizID)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+	"elsa-quiz-service/internal/infra/memory"
+)
+
+const quizYAML = `questions:
+  - id: q1
+    prompt: What is 2 + 2?
+    options:
+      - {id: o1, text: "3"}
+      - {id: o2, text: "4", correct: true}
+`
+
+// writeFile writes a quiz file and moves its modification time on, so quick successive writes
+// still look changed.
+func writeFile(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	at := time.Now().Add(age)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatalf("touch %s: %v", path, err)
+	}
+}
+
+func TestQuizLoaderReloadsChangedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "quiz-1.yaml"), quizYAML, -time.Minute)
+	writeFile(t, filepath.Join(dir, "quiz-2.json"), `{"id":"ignored","questions":[{"id":"q1","prompt":"Yes?","options":[{"id":"y","text":"yes","correct":true},{"id":"n","text":"no"}]}]}`, -time.Minute)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not a quiz", -time.Minute)
+
+	loader, err := NewQuizLoader(dir)
+	if err != nil {
+		t.Fatalf("new loader: %v", err)
+	}
+	quiz, err := loader.LoadQuiz(ctx, "quiz-1")
+	if err != nil || quiz.ID != "quiz-1" || quiz.Questions[0].Prompt != "What is 2 + 2?" {
+		t.Fatalf("unexpected quiz-1 %+v, %v", quiz, err)
+	}
+	if quiz, err := loader.LoadQuiz(ctx, "quiz-2"); err != nil || quiz.ID != "quiz-2" {
+		t.Fatalf("expected the file name to be the quiz ID, got %+v, %v", quiz, err)
+	}
+
+	original := quiz.Version
+	restarted, err := NewQuizLoader(dir)
+	if err != nil {
+		t.Fatalf("new loader: %v", err)
+	}
+	if again, _ := restarted.LoadQuiz(ctx, "quiz-1"); again.Version != original || original == 0 {
+		t.Fatalf("expected the same content to keep version %d after a restart, got %d", original, again.Version)
+	}
+	deleted, _ := loader.LoadQuiz(ctx, "quiz-2")
+
+	changed, err := loader.Reload()
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("expected nothing to change, got %v, %v", changed, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "quiz-1.yaml"), quizYAML+"  - id: q2\n    prompt: Half-saved\n", 0)
+	changed, _ = loader.Reload()
+	if len(changed) != 0 {
+		t.Fatalf("expected an invalid file to be skipped, got %v", changed)
+	}
+	if quiz, _ := loader.LoadQuiz(ctx, "quiz-1"); len(quiz.Questions) != 1 {
+		t.Fatalf("expected the last good copy to be kept, got %+v", quiz)
+	}
+
+	writeFile(t, filepath.Join(dir, "quiz-1.yaml"), quizYAML+"    points: 5\n", time.Minute)
+	if err := os.Remove(filepath.Join(dir, "quiz-2.json")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	changed, _ = loader.Reload()
+	if !reflect.DeepEqual(changed, []string{"quiz-1", "quiz-2"}) {
+		t.Fatalf("expected quiz-1 and quiz-2 to change, got %v", changed)
+	}
+	edited, _ := loader.LoadQuiz(ctx, "quiz-1")
+	if edited.Questions[0].Points != 5 || edited.Version == original {
+		t.Fatalf("expected the edit to be picked up as a new version, got %+v", edited)
+	}
+	if quiz, err := loader.LoadQuizVersion(ctx, "quiz-1", original); err != nil || quiz.Questions[0].Points != 0 {
+		t.Fatalf("expected the first version to be kept, got %+v, %v", quiz, err)
+	}
+	if _, err := loader.LoadQuiz(ctx, "quiz-2"); !errors.Is(err, domain.ErrQuizNotFound) {
+		t.Fatalf("expected the deleted quiz to be gone, got %v", err)
+	}
+	if _, err := loader.LoadQuizVersion(ctx, "quiz-2", deleted.Version); err != nil {
+		t.Fatalf("expected sessions pinned to the deleted quiz to keep it, got %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "quiz-1.yaml"), quizYAML+"    points: 5\n", 2*time.Minute)
+	if changed, _ := loader.Reload(); len(changed) != 0 {
+		t.Fatalf("expected touching a file not to make a new version, got %v", changed)
+	}
+
+	writeFile(t, filepath.Join(dir, "quiz-1.yaml"), quizYAML, 3*time.Minute)
+	if changed, _ := loader.Reload(); len(changed) != 1 {
+		t.Fatalf("expected the revert to be served, got %v", changed)
+	}
+	if quiz, _ := loader.LoadQuiz(ctx, "quiz-1"); quiz.Version != original {
+		t.Fatalf("expected reverting the file to bring back version %d, got %d", original, quiz.Version)
+	}
+}
+
+func TestWatchInvalidatesCachedQuizzes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "quiz-1.yaml")
+	writeFile(t, path, quizYAML, -time.Minute)
+
+	loader, err := NewQuizLoader(dir)
+	if err != nil {
+		t.Fatalf("new loader: %v", err)
+	}
+	repo := memory.NewQuizRepository(loader, time.Hour)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	go loader.Watch(ctx, 5*time.Millisecond, func(quizID string) { _ = repo.Invalidate(ctx, quizID) })
+
+	writeFile(t, path, quizYAML+"    points: 3\n", 0)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		quiz, err := repo.GetQuiz(ctx, "quiz-1")
+		if err != nil {
+			t.Fatalf("get quiz: %v", err)
+		}
+		if quiz.Questions[0].Points == 3 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache still serves the old quiz: %+v", quiz)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// AnswerStore is an in-memory implementation of app.AnswerRepository; answers are lost on restart.
+type AnswerStore struct {
+	mu      sync.RWMutex
+	answers map[answerKey]domain.AnswerRecord
+	order   []answerKey // first-recorded order, so listings are stable
+}
+
+type answerKey struct {
+	sessionID, userID, questionID string
+}
+
+func NewAnswerStore() *AnswerStore {
+	return &AnswerStore{answers: make(map[answerKey]domain.AnswerRecord)}
+}
+
+func (s *AnswerStore) Record(_ context.Context, record domain.AnswerRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := answerKey{record.SessionID, record.UserID, record.QuestionID}
+	if _, ok := s.answers[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.answers[key] = record
+	return nil
+}
+
+func (s *AnswerStore) Answers(_ context.Context, quizID string, version int) ([]domain.AnswerRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []domain.AnswerRecord
+	for _, key := range s.order {
+		if record := s.answers[key]; record.QuizID == quizID && record.QuizVersion == version {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// JoinCodeStore is an in-memory implementation of app.JoinCodeRepository.
+type JoinCodeStore struct {
+	clock func() time.Time
+
+	mu    sync.Mutex
+	codes map[string]joinCode
+}
+
+type joinCode struct {
+	sessionID string
+	expiresAt time.Time
+}
+
+func NewJoinCodeStore() *JoinCodeStore {
+	return &JoinCodeStore{
+		clock: time.Now,
+		codes: make(map[string]joinCode),
+	}
+}
+
+func (s *JoinCodeStore) Reserve(_ context.Context, code, sessionID string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock()
+	if existing, ok := s.codes[code]; ok && existing.expiresAt.After(now) {
+		return false, nil
+	}
+	s.codes[code] = joinCode{sessionID: sessionID, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *JoinCodeStore) Resolve(_ context.Context, code string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.codes[code]
+	if !ok || !entry.expiresAt.After(s.clock()) {
+		delete(s.codes, code)
+		return "", domain.ErrJoinCodeNotFound
+	}
+	return entry.sessionID, nil
+}
@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"context"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// StaticQuestionBanks serves question banks from an in-memory map (useful for tests/demos).
+type StaticQuestionBanks struct {
+	banks map[string]domain.QuestionBank
+}
+
+func NewStaticQuestionBanks(banks map[string]domain.QuestionBank) *StaticQuestionBanks {
+	return &StaticQuestionBanks{banks: banks}
+}
+
+func (b *StaticQuestionBanks) GetBank(_ context.Context, bankID string) (domain.QuestionBank, error) {
+	if bank, ok := b.banks[bankID]; ok {
+		return bank, nil
+	}
+	return domain.QuestionBank{}, domain.ErrBankNotFound
+}
@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"log"
 	"math/rand"
 	"sync"
 	"time"
@@ -12,67 +14,124 @@ import (
 
 // QuizLoader fetches quiz content from a backing store (e.g., document DB).
 type QuizLoader interface {
+	// LoadQuiz returns the published version of a quiz.
 	LoadQuiz(ctx context.Context, quizID string) (domain.Quiz, error)
+	// LoadQuizVersion returns one version of a quiz, published or not.
+	LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error)
 }
 
-// QuizRepository caches quizzes with TTL to avoid repeated DB hits.
+// QuizRepository caches quizzes with TTL to avoid repeated DB hits. Entries are keyed by
+// domain.QuizKey: the published version under the quiz ID, pinned ones under quizID@version.
+//
+// Unknown quizzes are cached too, for a shorter TTL, so lookups of made-up IDs don't each reach
+// the loader. An expired quiz is still served for a while (stale-while-revalidate) as it is
+// reloaded in the background, and a failed reload keeps it until that window closes.
 type QuizRepository struct {
-	loader QuizLoader
-	ttl    time.Duration
-	clock  func() time.Time
-	sf     singleflight.Group
-	rnd    *rand.Rand
+	loader      QuizLoader
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	staleTTL    time.Duration
+	clock       func() time.Time
+	sf          singleflight.Group
+	rnd         *rand.Rand
 
 	mu    sync.RWMutex
 	cache map[string]cachedQuiz
+	// invalidations counts Invalidate calls, so a load that started before one doesn't cache
+	// what it read.
+	invalidations uint64
+	lastSweep     time.Time
 }
 
 type cachedQuiz struct {
-	quiz      domain.Quiz
-	expiresAt time.Time
+	quiz       domain.Quiz
+	err        error     // the loader's not-found error, for a negative entry
+	expiresAt  time.Time // fresh until then
+	staleUntil time.Time // then served while it is reloaded, until this
 }
 
-func NewQuizRepository(loader QuizLoader, ttl time.Duration) *QuizRepository {
-	return &QuizRepository{
-		loader: loader,
-		ttl:    ttl,
-		clock:  time.Now,
-		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
-		cache:  make(map[string]cachedQuiz),
+// QuizRepositoryOption customizes a QuizRepository.
+type QuizRepositoryOption func(*QuizRepository)
+
+// WithNotFoundTTL sets how long a quiz the loader doesn't have is remembered as missing; zero
+// asks the loader every time. The default is DefaultNotFoundTTL.
+func WithNotFoundTTL(ttl time.Duration) QuizRepositoryOption {
+	return func(r *QuizRepository) {
+		r.notFoundTTL = ttl
+	}
+}
+
+// WithStaleTTL sets how long past its TTL a quiz is still served while it is reloaded; zero
+// reloads expired quizzes before answering. The default is the TTL itself.
+func WithStaleTTL(ttl time.Duration) QuizRepositoryOption {
+	return func(r *QuizRepository) {
+		r.staleTTL = ttl
+	}
+}
+
+// DefaultNotFoundTTL is how long unknown quizzes are remembered unless WithNotFoundTTL says otherwise.
+const DefaultNotFoundTTL = 10 * time.Second
+
+func NewQuizRepository(loader QuizLoader, ttl time.Duration, opts ...QuizRepositoryOption) *QuizRepository {
+	r := &QuizRepository{
+		loader:      loader,
+		ttl:         ttl,
+		notFoundTTL: DefaultNotFoundTTL,
+		staleTTL:    ttl,
+		clock:       time.Now,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		cache:       make(map[string]cachedQuiz),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 func (r *QuizRepository) GetQuiz(ctx context.Context, quizID string) (domain.Quiz, error) {
-	now := r.clock()
+	return r.get(ctx, quizID, func(ctx context.Context) (domain.Quiz, error) {
+		return r.loader.LoadQuiz(ctx, quizID)
+	})
+}
+
+func (r *QuizRepository) GetQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	return r.get(ctx, domain.QuizKey(quizID, version), func(ctx context.Context) (domain.Quiz, error) {
+		return r.loader.LoadQuizVersion(ctx, quizID, version)
+	})
+}
 
+// get serves key from the cache or loads it once for all concurrent callers. A stale quiz is
+// served as is while a background reload runs.
+func (r *QuizRepository) get(ctx context.Context, key string, load func(context.Context) (domain.Quiz, error)) (domain.Quiz, error) {
 	r.mu.RLock()
-	if entry, ok := r.cache[quizID]; ok && entry.expiresAt.After(now) {
-		r.mu.RUnlock()
-		return entry.quiz, nil
-	}
+	entry, ok := r.cache[key]
 	r.mu.RUnlock()
-
-	result, err, _ := r.sf.Do(quizID, func() (interface{}, error) {
+	if ok {
 		now := r.clock()
-		r.mu.RLock()
-		if entry, ok := r.cache[quizID]; ok && entry.expiresAt.After(now) {
-			r.mu.RUnlock()
-			return entry.quiz, nil
+		if entry.expiresAt.After(now) {
+			return entry.quiz, entry.err
 		}
-		r.mu.RUnlock()
-
-		quiz, err := r.loader.LoadQuiz(ctx, quizID)
-		if err != nil {
-			return domain.Quiz{}, err
+		if entry.err == nil && entry.staleUntil.After(now) {
+			// The reload outlives this call, so it mustn't be canceled with it.
+			r.sf.DoChan(key, func() (interface{}, error) {
+				quiz, err := r.load(context.WithoutCancel(ctx), key, load)
+				if err != nil && !notFound(err) {
+					log.Printf("reload quiz %s: %v", key, err)
+				}
+				return quiz, err
+			})
+			return entry.quiz, nil
 		}
+	}
 
-		r.mu.Lock()
-		r.cache[quizID] = cachedQuiz{
-			quiz:      quiz,
-			expiresAt: now.Add(r.ttlWithJitter()),
+	result, err, _ := r.sf.Do(key, func() (interface{}, error) {
+		r.mu.RLock()
+		entry, ok := r.cache[key]
+		r.mu.RUnlock()
+		if ok && entry.expiresAt.After(r.clock()) {
+			return entry.quiz, entry.err
 		}
-		r.mu.Unlock()
-		return quiz, nil
+		return r.load(ctx, key, load)
 	})
 	if err != nil {
 		return domain.Quiz{}, err
@@ -80,6 +139,66 @@ func (r *QuizRepository) GetQuiz(ctx context.Context, quizID string) (domain.Qui
 	return result.(domain.Quiz), nil
 }
 
+// load calls the loader and caches what it says. A loaded quiz is also cached under its own
+// version, since that never changes; sessions pinned to the published version then find it
+// without another load. Not-found answers are cached for notFoundTTL; other errors leave the
+// cache alone, so a stale copy keeps being served.
+func (r *QuizRepository) load(ctx context.Context, key string, load func(context.Context) (domain.Quiz, error)) (domain.Quiz, error) {
+	r.mu.RLock()
+	invalidations := r.invalidations
+	r.mu.RUnlock()
+	quiz, err := load(ctx)
+	now := r.clock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.invalidations != invalidations {
+		return quiz, err
+	}
+	switch {
+	case err == nil:
+		ttl := r.ttlWithJitter()
+		entry := cachedQuiz{quiz: quiz, expiresAt: now.Add(ttl), staleUntil: now.Add(ttl + r.staleTTL)}
+		r.cache[key] = entry
+		if quiz.Version > 0 {
+			r.cache[domain.QuizKey(quiz.ID, quiz.Version)] = entry
+		}
+	case notFound(err) && r.notFoundTTL > 0:
+		r.sweepLocked(now)
+		r.cache[key] = cachedQuiz{err: err, expiresAt: now.Add(r.notFoundTTL)}
+	}
+	return quiz, err
+}
+
+// sweepLocked drops entries that can no longer be served, at most once per notFoundTTL, so
+// lookups of made-up IDs don't grow the cache for good. It must be called with mu held.
+func (r *QuizRepository) sweepLocked(now time.Time) {
+	if now.Sub(r.lastSweep) < r.notFoundTTL {
+		return
+	}
+	r.lastSweep = now
+	for key, entry := range r.cache {
+		if !entry.expiresAt.After(now) && !entry.staleUntil.After(now) {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// notFound reports whether the loader definitely doesn't have a quiz, as opposed to failing to look.
+func notFound(err error) bool {
+	return errors.Is(err, domain.ErrQuizNotFound) || errors.Is(err, domain.ErrQuizVersionNotFound)
+}
+
+// Invalidate drops the cached copy of a quiz so the next GetQuiz loads it afresh. Cached
+// versions stay, since a version's content never changes.
+func (r *QuizRepository) Invalidate(_ context.Context, quizID string) error {
+	r.mu.Lock()
+	delete(r.cache, quizID)
+	r.invalidations++
+	r.mu.Unlock()
+	return nil
+}
+
 // StaticQuizLoader is a simple loader backed by an in-memory map (useful for tests/demos).
 type StaticQuizLoader struct {
 	quizzes map[string]domain.Quiz
@@ -96,6 +215,15 @@ func (l *StaticQuizLoader) LoadQuiz(_ context.Context, quizID string) (domain.Qu
 	return domain.Quiz{}, domain.ErrQuizNotFound
 }
 
+// LoadQuizVersion serves a quiz only at the version it was given with.
+func (l *StaticQuizLoader) LoadQuizVersion(_ context.Context, quizID string, version int) (domain.Quiz, error) {
+	if quiz, ok := l.quizzes[quizID]; ok && quiz.Version == version {
+		return quiz, nil
+	}
+	return domain.Quiz{}, domain.ErrQuizVersionNotFound
+}
+
+// ttlWithJitter must be called with mu held, since rnd isn't safe for concurrent use.
 func (r *QuizRepository) ttlWithJitter() time.Duration {
 	if r.ttl <= 0 {
 		return 0
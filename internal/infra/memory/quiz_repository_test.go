@@ -2,6 +2,10 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +35,185 @@ func TestQuizRepositoryCaches(t *testing.T) {
 	}
 }
 
+func TestQuizRepositoryCachesVersions(t *testing.T) {
+	ctx := context.Background()
+	quiz := sampleQuiz()
+	quiz.Version = 3
+	loader := &countingLoader{QuizLoader: NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": quiz})}
+	repo := NewQuizRepository(loader, time.Minute)
+
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	_ = repo.Invalidate(ctx, "quiz-1")
+	if got, err := repo.GetQuizVersion(ctx, "quiz-1", 3); err != nil || got.Version != 3 {
+		t.Fatalf("get version: %+v, %v", got, err)
+	}
+	if loader.calls != 1 {
+		t.Fatalf("expected the published version to be cached as quiz-1@3 too, loader calls %d", loader.calls)
+	}
+	if _, err := repo.GetQuizVersion(ctx, "quiz-1", 2); err != domain.ErrQuizVersionNotFound {
+		t.Fatalf("expected ErrQuizVersionNotFound, got %v", err)
+	}
+}
+
+func TestQuizRepositoryCachesUnknownQuizzes(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{QuizLoader: NewStaticQuizLoader(map[string]domain.Quiz{})}
+	clock := newTestClock()
+	repo := NewQuizRepository(loader, time.Minute, WithNotFoundTTL(5*time.Second))
+	repo.clock = clock.Now
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.GetQuiz(ctx, "made-up"); !errors.Is(err, domain.ErrQuizNotFound) {
+			t.Fatalf("expected ErrQuizNotFound, got %v", err)
+		}
+	}
+	if loader.calls != 1 {
+		t.Fatalf("expected the miss to be cached, loader calls %d", loader.calls)
+	}
+	clock.Advance(6 * time.Second)
+	_, _ = repo.GetQuiz(ctx, "made-up")
+	if loader.calls != 2 {
+		t.Fatalf("expected the loader to be asked again after the not-found TTL, calls %d", loader.calls)
+	}
+}
+
+func TestQuizRepositorySweepsExpiredMisses(t *testing.T) {
+	ctx := context.Background()
+	loader := NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": sampleQuiz()})
+	clock := newTestClock()
+	repo := NewQuizRepository(loader, time.Minute, WithNotFoundTTL(5*time.Second))
+	repo.clock = clock.Now
+
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		_, _ = repo.GetQuiz(ctx, fmt.Sprintf("made-up-%d", i))
+	}
+	clock.Advance(6 * time.Second)
+	_, _ = repo.GetQuiz(ctx, "made-up-again")
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if len(repo.cache) != 2 {
+		t.Fatalf("expected expired misses to be swept, leaving quiz-1 and the new miss, got %d entries", len(repo.cache))
+	}
+}
+
+func TestQuizRepositoryServesStaleQuizzesWhileReloading(t *testing.T) {
+	ctx := context.Background()
+	loader := &flakyLoader{quiz: sampleQuiz()}
+	clock := newTestClock()
+	repo := NewQuizRepository(loader, time.Minute, WithStaleTTL(time.Minute))
+	repo.clock = clock.Now
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+
+	// Expired: the old copy is served at once while the reload waits on the loader.
+	clock.Advance(70 * time.Second)
+	updated := sampleQuiz()
+	updated.Questions[0].Points = 2
+	release := loader.set(updated, nil)
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 1 {
+		t.Fatalf("expected the stale quiz, got %+v, %v", quiz, err)
+	}
+	close(release)
+	waitFor(t, func() bool {
+		quiz, _ := repo.GetQuiz(ctx, "quiz-1")
+		return quiz.Questions[0].Points == 2
+	})
+
+	// A failing reload keeps the cached quiz.
+	clock.Advance(70 * time.Second)
+	close(loader.set(domain.Quiz{}, errors.New("connection refused")))
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 2 {
+		t.Fatalf("expected the stale quiz, got %+v, %v", quiz, err)
+	}
+	waitFor(t, func() bool { return loader.callCount() == 3 })
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 2 {
+		t.Fatalf("expected a loader error not to evict the quiz, got %+v, %v", quiz, err)
+	}
+
+	// Past the stale window the error comes through.
+	clock.Advance(2 * time.Minute)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err == nil {
+		t.Fatal("expected the loader error once the quiz is too stale to serve")
+	}
+}
+
+// testClock is a settable clock, safe to read from background reloads.
+type testClock struct {
+	now atomic.Int64
+}
+
+func newTestClock() *testClock {
+	c := &testClock{}
+	c.now.Store(time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *testClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+// flakyLoader serves one quiz, or an error, once the test lets it.
+type flakyLoader struct {
+	mu      sync.Mutex
+	quiz    domain.Quiz
+	err     error
+	release chan struct{}
+	calls   int
+}
+
+// set changes what the loader returns; loads wait until the returned channel is closed.
+func (l *flakyLoader) set(quiz domain.Quiz, err error) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quiz, l.err, l.release = quiz, err, make(chan struct{})
+	return l.release
+}
+
+func (l *flakyLoader) LoadQuiz(_ context.Context, _ string) (domain.Quiz, error) {
+	l.mu.Lock()
+	release := l.release
+	l.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	return l.quiz, l.err
+}
+
+func (l *flakyLoader) LoadQuizVersion(ctx context.Context, quizID string, _ int) (domain.Quiz, error) {
+	return l.LoadQuiz(ctx, quizID)
+}
+
+func (l *flakyLoader) callCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the background reload")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 type countingLoader struct {
 	QuizLoader
 	calls int
@@ -41,6 +224,11 @@ func (l *countingLoader) LoadQuiz(ctx context.Context, quizID string) (domain.Qu
 	return l.QuizLoader.LoadQuiz(ctx, quizID)
 }
 
+func (l *countingLoader) LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	l.calls++
+	return l.QuizLoader.LoadQuizVersion(ctx, quizID, version)
+}
+
 func sampleQuiz() domain.Quiz {
 	return domain.Quiz{
 		ID: "quiz-1",
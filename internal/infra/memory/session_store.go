@@ -2,6 +2,7 @@ package memory
 
 import (
 	"sync"
+	"time"
 
 	"elsa-quiz-service/internal/app"
 )
@@ -18,32 +19,36 @@ func NewSessionStore() *SessionStore {
 	}
 }
 
-func (s *SessionStore) GetOrCreate(quizID string) *app.Session {
+func (s *SessionStore) Create(sessionID, quizID string, expiresAt time.Time) *app.Session {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if session, ok := s.sessions[quizID]; ok {
-		return session
+	// Sweep created sessions that expired without anyone joining.
+	for id, session := range s.sessions {
+		if session.Idle() {
+			delete(s.sessions, id)
+		}
 	}
-	session := app.NewSession(quizID)
-	s.sessions[quizID] = session
+	session := app.NewSession(sessionID, quizID)
+	session.SetExpiry(expiresAt)
+	s.sessions[sessionID] = session
 	return session
 }
 
-func (s *SessionStore) Get(quizID string) (*app.Session, bool) {
+func (s *SessionStore) Get(sessionID string) (*app.Session, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	session, ok := s.sessions[quizID]
+	session, ok := s.sessions[sessionID]
 	return session, ok
 }
 
-func (s *SessionStore) DeleteIfEmpty(quizID string) {
+func (s *SessionStore) DeleteIfEmpty(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	session, ok := s.sessions[quizID]
+	session, ok := s.sessions[sessionID]
 	if !ok {
 		return
 	}
-	if session.IsEmpty() {
-		delete(s.sessions, quizID)
+	if session.Idle() {
+		delete(s.sessions, sessionID)
 	}
 }
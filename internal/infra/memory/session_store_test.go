@@ -1,20 +1,32 @@
 package memory
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestSessionStoreLifecycle(t *testing.T) {
 	store := NewSessionStore()
 
-	session := store.GetOrCreate("quiz-1")
+	session := store.Create("session-1", "quiz-1", time.Now().Add(time.Hour))
 	if session == nil {
 		t.Fatalf("expected session")
 	}
-	if _, ok := store.Get("quiz-1"); !ok {
+	if _, ok := store.Get("session-1"); !ok {
 		t.Fatalf("expected session present")
 	}
-
-	store.DeleteIfEmpty("quiz-1")
 	if _, ok := store.Get("quiz-1"); ok {
-		t.Fatalf("expected session removed when empty")
+		t.Fatalf("expected no session keyed by quiz ID")
+	}
+
+	store.DeleteIfEmpty("session-1")
+	if _, ok := store.Get("session-1"); !ok {
+		t.Fatalf("expected empty session kept until it expires")
+	}
+
+	store.Create("session-2", "quiz-1", time.Now())
+	store.DeleteIfEmpty("session-2")
+	if _, ok := store.Get("session-2"); ok {
+		t.Fatalf("expected expired empty session removed")
 	}
 }
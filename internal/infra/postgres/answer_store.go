@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"elsa-quiz-service/internal/domain"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// AnswerStore keeps every session's latest answer per participant and question in Postgres.
+type AnswerStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewAnswerStore(pool *pgxpool.Pool) *AnswerStore {
+	return &AnswerStore{pool: pool}
+}
+
+func (s *AnswerStore) Record(ctx context.Context, r domain.AnswerRecord) error {
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO answers (session_id, user_id, question_id, quiz_id, quiz_version, option_id, correct, awarded, response_ms, answered_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (session_id, user_id, question_id) DO UPDATE SET
+    quiz_version = EXCLUDED.quiz_version,
+    option_id = EXCLUDED.option_id,
+    correct = EXCLUDED.correct,
+    awarded = EXCLUDED.awarded,
+    response_ms = EXCLUDED.response_ms,
+    answered_at = EXCLUDED.answered_at`,
+		r.SessionID, r.UserID, r.QuestionID, r.QuizID, r.QuizVersion, r.OptionID, r.Correct, r.Awarded, r.ResponseMs, r.AnsweredAt)
+	if err != nil {
+		return fmt.Errorf("record answer: %w", err)
+	}
+	return nil
+}
+
+func (s *AnswerStore) Answers(ctx context.Context, quizID string, version int) ([]domain.AnswerRecord, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT session_id, user_id, question_id, quiz_id, quiz_version, option_id, correct, awarded, response_ms, answered_at
+FROM answers WHERE quiz_id=$1 AND quiz_version=$2 ORDER BY answered_at`, quizID, version)
+	if err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+	defer rows.Close()
+	var records []domain.AnswerRecord
+	for rows.Next() {
+		var r domain.AnswerRecord
+		if err := rows.Scan(&r.SessionID, &r.UserID, &r.QuestionID, &r.QuizID, &r.QuizVersion, &r.OptionID, &r.Correct, &r.Awarded, &r.ResponseMs, &r.AnsweredAt); err != nil {
+			return nil, fmt.Errorf("scan answer: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+	return records, nil
+}
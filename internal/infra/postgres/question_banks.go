@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"elsa-quiz-service/internal/domain"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// QuestionBanks loads question bank JSONB from Postgres. Banks are only read when a session
+// draws its questions, so they aren't cached.
+type QuestionBanks struct {
+	pool *pgxpool.Pool
+}
+
+func NewQuestionBanks(pool *pgxpool.Pool) *QuestionBanks {
+	return &QuestionBanks{pool: pool}
+}
+
+func (b *QuestionBanks) GetBank(ctx context.Context, bankID string) (domain.QuestionBank, error) {
+	var raw []byte
+	err := b.pool.QueryRow(ctx, `SELECT data FROM question_banks WHERE id=$1`, bankID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.QuestionBank{}, domain.ErrBankNotFound
+	}
+	if err != nil {
+		return domain.QuestionBank{}, fmt.Errorf("load question bank: %w", err)
+	}
+	var bank domain.QuestionBank
+	if err := json.Unmarshal(raw, &bank); err != nil {
+		return domain.QuestionBank{}, fmt.Errorf("unmarshal question bank: %w", err)
+	}
+	return bank, nil
+}
@@ -3,13 +3,17 @@ package postgres
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"elsa-quiz-service/internal/domain"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-// QuizLoader loads quiz JSONB from Postgres.
+// QuizLoader loads quiz JSONB from Postgres. Every save is a new immutable version in
+// quiz_versions; LoadQuiz serves the published one.
 type QuizLoader struct {
 	pool *pgxpool.Pool
 }
@@ -19,14 +23,145 @@ func NewQuizLoader(pool *pgxpool.Pool) *QuizLoader {
 }
 
 func (l *QuizLoader) LoadQuiz(ctx context.Context, quizID string) (domain.Quiz, error) {
+	var version int
 	var raw []byte
-	err := l.pool.QueryRow(ctx, `SELECT data FROM quizzes WHERE id=$1`, quizID).Scan(&raw)
+	err := l.pool.QueryRow(ctx, `SELECT version, data FROM quiz_versions WHERE quiz_id=$1 AND status='published'`, quizID).Scan(&version, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Quiz{}, domain.ErrQuizNotFound
+	}
 	if err != nil {
 		return domain.Quiz{}, fmt.Errorf("load quiz: %w", err)
 	}
+	return unmarshalQuiz(raw, version)
+}
+
+func (l *QuizLoader) LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	var raw []byte
+	err := l.pool.QueryRow(ctx, `SELECT data FROM quiz_versions WHERE quiz_id=$1 AND version=$2`, quizID, version).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Quiz{}, domain.ErrQuizVersionNotFound
+	}
+	if err != nil {
+		return domain.Quiz{}, fmt.Errorf("load quiz version: %w", err)
+	}
+	return unmarshalQuiz(raw, version)
+}
+
+func unmarshalQuiz(raw []byte, version int) (domain.Quiz, error) {
 	var quiz domain.Quiz
 	if err := json.Unmarshal(raw, &quiz); err != nil {
 		return domain.Quiz{}, fmt.Errorf("unmarshal quiz: %w", err)
 	}
+	quiz.Version = version
 	return quiz, nil
 }
+
+// SaveQuiz stores the quiz as a new draft version, numbered after the latest one, whatever
+// version the quiz itself carries. PublishQuiz makes it the version new sessions run.
+func (l *QuizLoader) SaveQuiz(ctx context.Context, quiz domain.Quiz) (domain.QuizVersion, error) {
+	quiz.Version = 0
+	raw, err := json.Marshal(quiz)
+	if err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("marshal quiz: %w", err)
+	}
+	saved := domain.QuizVersion{QuizID: quiz.ID, Status: domain.QuizDraft}
+	tx, err := l.pool.Begin(ctx)
+	if err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("save quiz: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	// Saves of the same quiz take turns, so each numbers its version after the one before.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, quiz.ID); err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("save quiz: %w", err)
+	}
+	err = tx.QueryRow(ctx, `
+INSERT INTO quiz_versions (quiz_id, version, status, data)
+SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM quiz_versions WHERE quiz_id = $1
+RETURNING version, created_at`,
+		quiz.ID, domain.QuizDraft, raw).Scan(&saved.Version, &saved.CreatedAt)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		return domain.QuizVersion{}, saveError(err)
+	}
+	return saved, nil
+}
+
+// uniqueViolation is Postgres's SQLSTATE for a duplicate key.
+const uniqueViolation = "23505"
+
+// saveError reports a version number another save took first as domain.ErrQuizVersionConflict.
+func saveError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("save quiz: %w", domain.ErrQuizVersionConflict)
+	}
+	return fmt.Errorf("save quiz: %w", err)
+}
+
+// PublishQuiz makes a version the one new sessions run, archiving the version published before
+// it. Publishing an archived version rolls the quiz back to it.
+func (l *QuizLoader) PublishQuiz(ctx context.Context, quizID string, version int) error {
+	tx, err := l.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	var raw []byte
+	err = tx.QueryRow(ctx, `SELECT status, data FROM quiz_versions WHERE quiz_id=$1 AND version=$2 FOR UPDATE`, quizID, version).Scan(&status, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrQuizVersionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if status == domain.QuizPublished {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE quiz_versions SET status=$1 WHERE quiz_id=$2 AND status=$3`,
+		domain.QuizArchived, quizID, domain.QuizPublished); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE quiz_versions SET status=$1, published_at=NOW() WHERE quiz_id=$2 AND version=$3`,
+		domain.QuizPublished, quizID, version); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+INSERT INTO quizzes (id, data, updated_at) VALUES ($1, $2, NOW())
+ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`,
+		quizID, raw); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	return nil
+}
+
+// QuizVersions lists a quiz's versions, oldest first.
+func (l *QuizLoader) QuizVersions(ctx context.Context, quizID string) ([]domain.QuizVersion, error) {
+	rows, err := l.pool.Query(ctx, `
+SELECT version, status, created_at, published_at FROM quiz_versions WHERE quiz_id=$1 ORDER BY version`, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("list quiz versions: %w", err)
+	}
+	defer rows.Close()
+	var versions []domain.QuizVersion
+	for rows.Next() {
+		v := domain.QuizVersion{QuizID: quizID}
+		if err := rows.Scan(&v.Version, &v.Status, &v.CreatedAt, &v.PublishedAt); err != nil {
+			return nil, fmt.Errorf("scan quiz version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list quiz versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, domain.ErrQuizNotFound
+	}
+	return versions, nil
+}
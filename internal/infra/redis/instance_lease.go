@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInstanceRunning means another quiz server holds the Redis database's instance lease.
+var ErrInstanceRunning = errors.New("another quiz server is using this redis database")
+
+// InstanceLease keeps a Redis database to one quiz server at a time. Sessions live in the
+// memory of the server that created them, so a second server sharing the database would hand
+// out join codes for sessions it can't find. The lease is renewed while the server runs and
+// lapses within its TTL of a crash:
+//
+//	SET quiz:instance {token} NX PX {ttl}
+type InstanceLease struct {
+	client *redis.Client
+	token  string
+	ttl    time.Duration
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+const instanceKey = "quiz:instance"
+
+// renewLeaseScript extends the lease in KEYS[1] to ARGV[2] milliseconds if it still holds ARGV[1].
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// AcquireInstanceLease takes the lease, or returns ErrInstanceRunning if another server holds it.
+func AcquireInstanceLease(ctx context.Context, client *redis.Client, ttl time.Duration) (*InstanceLease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := client.SetNX(ctx, instanceKey, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInstanceRunning
+	}
+	l := &InstanceLease{client: client, token: token, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+	go l.renew()
+	return l, nil
+}
+
+// renew extends the lease every third of its TTL until Release.
+func (l *InstanceLease) renew() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			renewed, err := renewLeaseScript.Run(context.Background(), l.client, []string{instanceKey}, l.token, l.ttl.Milliseconds()).Int()
+			if err != nil {
+				log.Printf("renew instance lease: %v", err)
+			} else if renewed == 0 {
+				log.Printf("instance lease lost: another quiz server may be using this redis database")
+			}
+		}
+	}
+}
+
+// Release stops renewing the lease and gives it up, so another server can start straight away.
+func (l *InstanceLease) Release(ctx context.Context) error {
+	close(l.stop)
+	<-l.done
+	return unlockScript.Run(ctx, l.client, []string{instanceKey}, l.token).Err()
+}
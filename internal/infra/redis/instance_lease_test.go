@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+)
+
+func TestInstanceLeaseKeepsOtherServersOut(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	ttl := 300 * time.Millisecond
+	lease, err := AcquireInstanceLease(ctx, newClient(mr), ttl)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := AcquireInstanceLease(ctx, newClient(mr), ttl); !errors.Is(err, ErrInstanceRunning) {
+		t.Fatalf("expected ErrInstanceRunning, got %v", err)
+	}
+
+	mr.FastForward(2 * ttl / 3)
+	waitFor(t, func() bool { return mr.TTL(instanceKey) > ttl/2 })
+
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	next, err := AcquireInstanceLease(ctx, newClient(mr), ttl)
+	if err != nil {
+		t.Fatalf("expected the lease to be free after release, got %v", err)
+	}
+	_ = next.Release(ctx)
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// JoinCodeStore keeps join codes in Redis so every instance resolves the same codes.
+// Codes are stored as: SET quiz:code:{code} {sessionID} NX EX {ttl}
+type JoinCodeStore struct {
+	client *redis.Client
+}
+
+func NewJoinCodeStore(client *redis.Client) *JoinCodeStore {
+	return &JoinCodeStore{client: client}
+}
+
+func (s *JoinCodeStore) Reserve(ctx context.Context, code, sessionID string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.key(code), sessionID, ttl).Result()
+}
+
+func (s *JoinCodeStore) Resolve(ctx context.Context, code string) (string, error) {
+	sessionID, err := s.client.Get(ctx, s.key(code)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrJoinCodeNotFound
+	}
+	return sessionID, err
+}
+
+func (s *JoinCodeStore) key(code string) string {
+	return "quiz:code:" + code
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+	miniredis "github.com/alicebob/miniredis/v2"
+)
+
+func TestJoinCodeStoreReservesOnce(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewJoinCodeStore(newClient(mr))
+
+	if ok, err := store.Reserve(ctx, "ABC234", "s1", time.Minute); err != nil || !ok {
+		t.Fatalf("expected reservation, got ok=%v err=%v", ok, err)
+	}
+	if ok, _ := store.Reserve(ctx, "ABC234", "s2", time.Minute); ok {
+		t.Fatalf("expected collision to be refused")
+	}
+	if sessionID, err := store.Resolve(ctx, "ABC234"); err != nil || sessionID != "s1" {
+		t.Fatalf("expected s1, got %s (%v)", sessionID, err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if _, err := store.Resolve(ctx, "ABC234"); err != domain.ErrJoinCodeNotFound {
+		t.Fatalf("expected expired code, got %v", err)
+	}
+}
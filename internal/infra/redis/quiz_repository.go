@@ -2,8 +2,13 @@ package redis
 
 import (
 	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
 	"math/rand"
-	"strconv"
+	"sync"
 	"time"
 
 	"elsa-quiz-service/internal/domain"
@@ -13,116 +18,291 @@ import (
 
 // QuizLoader fetches quiz content from a backing store (e.g., document DB).
 type QuizLoader interface {
+	// LoadQuiz returns the published version of a quiz.
 	LoadQuiz(ctx context.Context, quizID string) (domain.Quiz, error)
+	// LoadQuizVersion returns one version of a quiz, published or not.
+	LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error)
 }
 
-// QuizRepository caches quiz answers in Redis (hash per quiz) and falls back to a loader on cache miss.
-// Answers are stored as: HSET quiz:{quizID}:answers {questionID} {optionID}
-// Points are stored as:  HSET quiz:{quizID}:points  {questionID} {points}
+// QuizRepository caches quizzes in Redis and falls back to a loader on cache miss.
+// Each quiz is stored whole, options and question types included, so polls and answer stats
+// work the same whether or not the quiz came from the cache. The published version is kept
+// under the quiz ID and pinned versions under domain.QuizKey:
+//
+//	SET quiz:{quizID}:data {"quiz":{json},"freshUntil":...}
+//	SET quiz:{quizID}@{version}:data {"quiz":{json},"freshUntil":...}
+//	INCR quiz:{quizID}:gen
+//	SET quiz:{quizID}:refresh {token} NX PX 30000
+//
+// A quiz outlives its freshUntil by the stale TTL; in between it is served while the instance
+// holding the refresh lock reloads it in the background, and a failed reload leaves it in place.
+// Quizzes the loader doesn't have are stored as {"notFound":true} for the not-found TTL.
+//
+// Invalidate bumps the generation, and a load only caches its result if the generation is
+// still the one it saw before calling the loader, so a slow load can't bring back a quiz
+// invalidated while it ran.
 type QuizRepository struct {
-	client *redis.Client
-	loader QuizLoader
-	ttl    time.Duration
-	sf     singleflight.Group
-	rnd    *rand.Rand
-}
-
-func NewQuizRepository(client *redis.Client, loader QuizLoader, ttl time.Duration) *QuizRepository {
-	return &QuizRepository{
-		client: client,
-		loader: loader,
-		ttl:    ttl,
-		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	client      *redis.Client
+	loader      QuizLoader
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	staleTTL    time.Duration
+	clock       func() time.Time
+	sf          singleflight.Group
+
+	mu  sync.Mutex // guards rnd
+	rnd *rand.Rand
+}
+
+// cacheEntry is what a quiz key holds. Values without a quiz or the not-found mark, such as
+// quizzes cached before entries had an envelope, count as a miss.
+type cacheEntry struct {
+	Quiz       *domain.Quiz `json:"quiz,omitempty"`
+	NotFound   bool         `json:"notFound,omitempty"`
+	FreshUntil time.Time    `json:"freshUntil,omitempty"`
+}
+
+// fresh reports whether the entry can be served without a reload. Entries without a
+// freshUntil stay fresh as long as Redis keeps them.
+func (e cacheEntry) fresh(now time.Time) bool {
+	return e.FreshUntil.IsZero() || e.FreshUntil.After(now)
+}
+
+// result is what the entry answers for its key; missing is the error for a not-found entry.
+func (e cacheEntry) result(missing error) (domain.Quiz, error) {
+	if e.Quiz == nil {
+		return domain.Quiz{}, missing
+	}
+	return *e.Quiz, nil
+}
+
+// QuizRepositoryOption customizes a QuizRepository.
+type QuizRepositoryOption func(*QuizRepository)
+
+// WithNotFoundTTL sets how long a quiz the loader doesn't have is remembered as missing; zero
+// asks the loader every time. The default is DefaultNotFoundTTL.
+func WithNotFoundTTL(ttl time.Duration) QuizRepositoryOption {
+	return func(r *QuizRepository) {
+		r.notFoundTTL = ttl
+	}
+}
+
+// WithStaleTTL sets how long past its TTL a quiz is still served while it is reloaded; zero
+// reloads expired quizzes before answering. The default is the TTL itself.
+func WithStaleTTL(ttl time.Duration) QuizRepositoryOption {
+	return func(r *QuizRepository) {
+		r.staleTTL = ttl
 	}
 }
 
+// refreshLockTTL bounds how long an instance that died mid-reload keeps others from reloading.
+const refreshLockTTL = 30 * time.Second
+
+// DefaultNotFoundTTL is how long unknown quizzes are remembered unless WithNotFoundTTL says otherwise.
+const DefaultNotFoundTTL = 10 * time.Second
+
+func NewQuizRepository(client *redis.Client, loader QuizLoader, ttl time.Duration, opts ...QuizRepositoryOption) *QuizRepository {
+	r := &QuizRepository{
+		client:      client,
+		loader:      loader,
+		ttl:         ttl,
+		notFoundTTL: DefaultNotFoundTTL,
+		staleTTL:    ttl,
+		clock:       time.Now,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
 func (r *QuizRepository) GetQuiz(ctx context.Context, quizID string) (domain.Quiz, error) {
-	answerKey := r.answersKey(quizID)
-	pointKey := r.pointsKey(quizID)
+	return r.get(ctx, quizID, domain.ErrQuizNotFound, func(ctx context.Context) (domain.Quiz, error) {
+		return r.loader.LoadQuiz(ctx, quizID)
+	})
+}
 
-	answers, err := r.client.HGetAll(ctx, answerKey).Result()
-	if err == nil && len(answers) > 0 {
-		pointsMap, _ := r.client.HGetAll(ctx, pointKey).Result()
-		return buildQuizFromCache(quizID, answers, pointsMap), nil
+func (r *QuizRepository) GetQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	return r.get(ctx, domain.QuizKey(quizID, version), domain.ErrQuizVersionNotFound, func(ctx context.Context) (domain.Quiz, error) {
+		return r.loader.LoadQuizVersion(ctx, quizID, version)
+	})
+}
+
+// get serves key from Redis or loads it once per instance. A stale quiz is served as is while a background
+// reload runs, on whichever instance holds the refresh lock.
+func (r *QuizRepository) get(ctx context.Context, key string, missing error, load func(context.Context) (domain.Quiz, error)) (domain.Quiz, error) {
+	if entry, ok := r.fromCache(ctx, key); ok {
+		if entry.fresh(r.clock()) {
+			return entry.result(missing)
+		}
+		if entry.Quiz != nil {
+			// The reload outlives this call, so it mustn't be canceled with it.
+			r.sf.DoChan(key, func() (interface{}, error) {
+				ctx := context.WithoutCancel(ctx)
+				token, ok := r.lockRefresh(ctx, key)
+				if !ok {
+					return *entry.Quiz, nil
+				}
+				defer r.unlockRefresh(ctx, key, token)
+				quiz, err := r.load(ctx, key, load)
+				if err != nil && !notFound(err) {
+					log.Printf("reload quiz %s: %v", key, err)
+				}
+				return quiz, err
+			})
+			return *entry.Quiz, nil
+		}
 	}
 
-	result, err, _ := r.sf.Do(quizID, func() (interface{}, error) {
+	result, err, _ := r.sf.Do(key, func() (interface{}, error) {
 		// Re-check cache in case another goroutine filled it.
-		answers, err := r.client.HGetAll(ctx, answerKey).Result()
-		if err == nil && len(answers) > 0 {
-			pointsMap, _ := r.client.HGetAll(ctx, pointKey).Result()
-			return buildQuizFromCache(quizID, answers, pointsMap), nil
+		if entry, ok := r.fromCache(ctx, key); ok && entry.fresh(r.clock()) {
+			return entry.result(missing)
 		}
+		return r.load(ctx, key, load)
+	})
+	if err != nil {
+		return domain.Quiz{}, err
+	}
+	return result.(domain.Quiz), nil
+}
 
-		quiz, err := r.loader.LoadQuiz(ctx, quizID)
+// load calls the loader and caches what it says. A loaded quiz is also cached under its own
+// version, which never changes. Not-found answers are cached for notFoundTTL; other errors
+// leave the key alone, so a stale copy keeps being served.
+func (r *QuizRepository) load(ctx context.Context, key string, load func(context.Context) (domain.Quiz, error)) (domain.Quiz, error) {
+	gen, genErr := r.generation(ctx, key)
+	quiz, err := load(ctx)
+	if genErr != nil {
+		return quiz, err
+	}
+	switch {
+	case err == nil:
+		entry := cacheEntry{Quiz: &quiz}
+		var expiration time.Duration
+		if ttl := r.ttlWithJitter(); ttl > 0 {
+			entry.FreshUntil = r.clock().Add(ttl)
+			expiration = ttl + r.staleTTL
+		}
+		data, err := json.Marshal(entry)
 		if err != nil {
 			return domain.Quiz{}, err
 		}
-
-		ttl := r.ttlWithJitter()
-		pipe := r.client.Pipeline()
-		for _, q := range quiz.Questions {
-			points := q.Points
-			if points == 0 {
-				points = 1
-			}
-			pipe.HSet(ctx, answerKey, q.ID, firstCorrectOption(q))
-			pipe.HSet(ctx, pointKey, q.ID, points)
-		}
-		if ttl > 0 {
-			pipe.Expire(ctx, answerKey, ttl)
-			pipe.Expire(ctx, pointKey, ttl)
+		_ = r.setIfGeneration(ctx, key, gen, data, expiration)
+		if versioned := domain.QuizKey(quiz.ID, quiz.Version); quiz.Version > 0 && versioned != key {
+			_ = r.client.Set(ctx, r.dataKey(versioned), data, expiration).Err()
 		}
-		_, _ = pipe.Exec(ctx)
+	case notFound(err) && r.notFoundTTL > 0:
+		data, _ := json.Marshal(cacheEntry{NotFound: true})
+		_ = r.setIfGeneration(ctx, key, gen, data, r.notFoundTTL)
+	}
+	return quiz, err
+}
 
-		return quiz, nil
-	})
+// setIfGenerationScript sets KEYS[2] to ARGV[2], expiring after ARGV[3] milliseconds unless
+// that is zero, as long as the generation in KEYS[1] is still ARGV[1].
+var setIfGenerationScript = redis.NewScript(`
+if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// lockRefresh takes key's refresh lock, reporting false if another reload holds it. The token
+// it returns identifies this hold of the lock.
+func (r *QuizRepository) lockRefresh(ctx context.Context, key string) (string, bool) {
+	token, err := newToken()
 	if err != nil {
-		return domain.Quiz{}, err
+		return "", false
 	}
-	return result.(domain.Quiz), nil
+	ok, err := r.client.SetNX(ctx, r.refreshKey(key), token, refreshLockTTL).Result()
+	return token, err == nil && ok
 }
 
-func (r *QuizRepository) answersKey(quizID string) string {
-	return "quiz:" + quizID + ":answers"
+// newToken returns a random value identifying one hold of a lock.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
-func (r *QuizRepository) pointsKey(quizID string) string {
-	return "quiz:" + quizID + ":points"
+// unlockScript deletes the lock in KEYS[1] only if it still holds the token in ARGV[1].
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// unlockRefresh releases key's refresh lock unless it expired during the reload and another
+// instance has taken it since.
+func (r *QuizRepository) unlockRefresh(ctx context.Context, key, token string) {
+	_ = unlockScript.Run(ctx, r.client, []string{r.refreshKey(key)}, token).Err()
 }
 
-func buildQuizFromCache(quizID string, answers map[string]string, pointsMap map[string]string) domain.Quiz {
-	questions := make([]domain.Question, 0, len(answers))
-	for questionID, optionID := range answers {
-		points := 1
-		if pStr, ok := pointsMap[questionID]; ok {
-			if p, err := strconv.Atoi(pStr); err == nil && p > 0 {
-				points = p
-			}
-		}
-		questions = append(questions, domain.Question{
-			ID:     questionID,
-			Prompt: "", // prompt not cached in this lightweight form
-			Options: []domain.Option{
-				{ID: optionID, Correct: true},
-			},
-			Points: points,
-		})
+// generation returns how many times key has been invalidated, "0" if never.
+func (r *QuizRepository) generation(ctx context.Context, key string) (string, error) {
+	gen, err := r.client.Get(ctx, r.genKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "0", nil
 	}
-	return domain.Quiz{ID: quizID, Questions: questions}
+	return gen, err
 }
 
-func firstCorrectOption(q domain.Question) string {
-	for _, opt := range q.Options {
-		if opt.Correct {
-			return opt.ID
-		}
+// setIfGeneration caches data under key unless key was invalidated since gen was read.
+func (r *QuizRepository) setIfGeneration(ctx context.Context, key, gen string, data []byte, expiration time.Duration) error {
+	keys := []string{r.genKey(key), r.dataKey(key)}
+	return setIfGenerationScript.Run(ctx, r.client, keys, gen, data, expiration.Milliseconds()).Err()
+}
+
+// notFound reports whether the loader definitely doesn't have a quiz, as opposed to failing to look.
+func notFound(err error) bool {
+	return errors.Is(err, domain.ErrQuizNotFound) || errors.Is(err, domain.ErrQuizVersionNotFound)
+}
+
+// fromCache reports false on a miss; an unreadable entry counts as one and is reloaded.
+func (r *QuizRepository) fromCache(ctx context.Context, key string) (cacheEntry, bool) {
+	raw, err := r.client.Get(ctx, r.dataKey(key)).Bytes()
+	if err != nil {
+		return cacheEntry{}, false
 	}
-	// Fallback to first option ID if no correct flag is set.
-	if len(q.Options) > 0 {
-		return q.Options[0].ID
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || (entry.Quiz == nil && !entry.NotFound) {
+		return cacheEntry{}, false
 	}
-	return ""
+	return entry, true
+}
+
+// Invalidate deletes the cached copy of a quiz, for every instance sharing the Redis database.
+// Cached versions stay, since a version's content never changes. Loads already running when
+// it is called don't cache what they load.
+func (r *QuizRepository) Invalidate(ctx context.Context, quizID string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, r.genKey(quizID))
+		pipe.Del(ctx, r.dataKey(quizID))
+		return nil
+	})
+	return err
+}
+
+func (r *QuizRepository) dataKey(key string) string {
+	return "quiz:" + key + ":data"
+}
+
+func (r *QuizRepository) refreshKey(key string) string {
+	return "quiz:" + key + ":refresh"
+}
+
+func (r *QuizRepository) genKey(key string) string {
+	return "quiz:" + key + ":gen"
 }
 
 func (r *QuizRepository) ttlWithJitter() time.Duration {
@@ -130,5 +310,7 @@ func (r *QuizRepository) ttlWithJitter() time.Duration {
 		return 0
 	}
 	jitterMax := int64(r.ttl) / 10
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.ttl + time.Duration(r.rnd.Int63n(jitterMax+1))
 }
@@ -2,6 +2,9 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -42,6 +45,376 @@ func TestQuizRepositoryCachesInRedis(t *testing.T) {
 	}
 }
 
+func TestQuizRepositoryCachesSettings(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	quiz := sampleQuiz()
+	quiz.Settings.BroadcastWindowMs = 150
+	repo := NewQuizRepository(newClient(mr), memory.NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": quiz}), time.Minute)
+
+	if _, err := repo.GetQuiz(context.Background(), "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	cached, err := repo.GetQuiz(context.Background(), "quiz-1")
+	if err != nil {
+		t.Fatalf("get cached quiz: %v", err)
+	}
+	if cached.Settings.BroadcastWindowMs != 150 {
+		t.Fatalf("expected cached settings, got %+v", cached.Settings)
+	}
+}
+
+func TestQuizRepositoryKeepsPollQuestions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	quiz := sampleQuiz()
+	quiz.Questions = append(quiz.Questions, domain.Question{
+		ID:      "q2",
+		Type:    domain.QuestionPoll,
+		Prompt:  "How confident are you?",
+		Options: []domain.Option{{ID: "o1", Text: "Very"}, {ID: "o2", Text: "Not really"}},
+	})
+	repo := NewQuizRepository(newClient(mr), memory.NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": quiz}), time.Minute)
+
+	if _, err := repo.GetQuiz(context.Background(), "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	cached, err := repo.GetQuiz(context.Background(), "quiz-1")
+	if err != nil {
+		t.Fatalf("get cached quiz: %v", err)
+	}
+	if len(cached.Questions) != 2 || len(cached.Questions[0].Options) != 2 {
+		t.Fatalf("expected whole questions from the cache, got %+v", cached.Questions)
+	}
+	poll := cached.Questions[1]
+	if poll.Graded() || poll.Options[0].Correct || poll.Options[1].Correct {
+		t.Fatalf("expected the poll to stay ungraded, got %+v", poll)
+	}
+}
+
+func TestQuizRepositoryCachesVersions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	quiz := sampleQuiz()
+	quiz.Version = 3
+	loader := &countingLoader{QuizLoader: memory.NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": quiz})}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute)
+
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	if !mr.Exists("quiz:quiz-1@3:data") {
+		t.Fatalf("expected the published version to be cached under quiz-1@3, keys %v", mr.Keys())
+	}
+	if err := repo.Invalidate(ctx, "quiz-1"); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if got, err := repo.GetQuizVersion(ctx, "quiz-1", 3); err != nil || got.Version != 3 {
+		t.Fatalf("get version: %+v, %v", got, err)
+	}
+	if loader.calls != 1 {
+		t.Fatalf("expected versions to survive invalidation, loader calls=%d", loader.calls)
+	}
+}
+
+func TestQuizRepositoryCachesUnknownQuizzes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	loader := &countingLoader{QuizLoader: memory.NewStaticQuizLoader(map[string]domain.Quiz{})}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute, WithNotFoundTTL(5*time.Second))
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.GetQuiz(ctx, "made-up"); !errors.Is(err, domain.ErrQuizNotFound) {
+			t.Fatalf("expected ErrQuizNotFound, got %v", err)
+		}
+	}
+	if _, err := repo.GetQuizVersion(ctx, "made-up", 2); !errors.Is(err, domain.ErrQuizVersionNotFound) {
+		t.Fatalf("expected ErrQuizVersionNotFound, got %v", err)
+	}
+	if _, err := repo.GetQuizVersion(ctx, "made-up", 2); !errors.Is(err, domain.ErrQuizVersionNotFound) {
+		t.Fatalf("expected the cached miss to keep its error, got %v", err)
+	}
+	if loader.calls != 2 {
+		t.Fatalf("expected the misses to be cached, loader calls=%d", loader.calls)
+	}
+	mr.FastForward(6 * time.Second)
+	_, _ = repo.GetQuiz(ctx, "made-up")
+	if loader.calls != 3 {
+		t.Fatalf("expected the loader to be asked again after the not-found TTL, calls=%d", loader.calls)
+	}
+}
+
+func TestQuizRepositoryServesStaleQuizzesWhileReloading(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	loader := &flakyLoader{quiz: sampleQuiz()}
+	var now atomic.Int64
+	now.Store(time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC).UnixNano())
+	advance := func(d time.Duration) {
+		now.Add(int64(d))
+		mr.FastForward(d)
+	}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute, WithStaleTTL(time.Minute))
+	repo.clock = func() time.Time { return time.Unix(0, now.Load()) }
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+
+	advance(70 * time.Second)
+	updated := sampleQuiz()
+	updated.Questions[0].Points = 2
+	release := loader.set(updated, nil)
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 1 {
+		t.Fatalf("expected the stale quiz, got %+v, %v", quiz, err)
+	}
+	close(release)
+	waitFor(t, func() bool {
+		quiz, _ := repo.GetQuiz(ctx, "quiz-1")
+		return quiz.Questions[0].Points == 2
+	})
+
+	advance(70 * time.Second)
+	close(loader.set(domain.Quiz{}, errors.New("connection refused")))
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 2 {
+		t.Fatalf("expected the stale quiz, got %+v, %v", quiz, err)
+	}
+	waitFor(t, func() bool { return loader.callCount() == 3 })
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 2 {
+		t.Fatalf("expected a loader error not to evict the quiz, got %+v, %v", quiz, err)
+	}
+
+	advance(2 * time.Minute)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err == nil {
+		t.Fatal("expected the loader error once the quiz is too stale to serve")
+	}
+}
+
+func TestQuizRepositoryReloadsOnTheInstanceHoldingTheLock(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	loader := &flakyLoader{quiz: sampleQuiz()}
+	var now atomic.Int64
+	now.Store(time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC).UnixNano())
+	advance := func(d time.Duration) {
+		now.Add(int64(d))
+		mr.FastForward(d)
+	}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute, WithStaleTTL(10*time.Minute))
+	repo.clock = func() time.Time { return time.Unix(0, now.Load()) }
+	// Waits for the background reload GetQuiz may have started.
+	settle := func() { repo.sf.Do("quiz-1", func() (interface{}, error) { return nil, nil }) }
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+
+	advance(70 * time.Second)
+	mr.Set("quiz:quiz-1:refresh", "other-instance")
+	mr.SetTTL("quiz:quiz-1:refresh", refreshLockTTL)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	settle()
+	if calls := loader.callCount(); calls != 1 {
+		t.Fatalf("expected another instance's refresh lock to skip the reload, calls=%d", calls)
+	}
+
+	advance(refreshLockTTL)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	settle()
+	if calls := loader.callCount(); calls != 2 {
+		t.Fatalf("expected a reload once the lock expired, calls=%d", calls)
+	}
+	if mr.Exists("quiz:quiz-1:refresh") {
+		t.Fatal("expected the refresh lock to be released after the reload")
+	}
+}
+
+func TestQuizRepositoryKeepsALockTakenOverDuringAReload(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	loader := &flakyLoader{quiz: sampleQuiz()}
+	var now atomic.Int64
+	now.Store(time.Date(2024, 11, 22, 10, 0, 0, 0, time.UTC).UnixNano())
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute, WithStaleTTL(10*time.Minute))
+	repo.clock = func() time.Time { return time.Unix(0, now.Load()) }
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+
+	now.Add(int64(70 * time.Second))
+	mr.FastForward(70 * time.Second)
+	release := loader.set(sampleQuiz(), nil)
+	if _, err := repo.GetQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	waitFor(t, func() bool { return loader.waitingCount() == 1 })
+
+	// The reload outlasts the lock, and another instance takes it over.
+	mr.FastForward(refreshLockTTL)
+	if mr.Exists("quiz:quiz-1:refresh") {
+		t.Fatal("expected the refresh lock to have expired")
+	}
+	mr.Set("quiz:quiz-1:refresh", "other-instance")
+	close(release)
+	repo.sf.Do("quiz-1", func() (interface{}, error) { return nil, nil })
+	if got, err := mr.Get("quiz:quiz-1:refresh"); err != nil || got != "other-instance" {
+		t.Fatalf("expected the other instance to keep its lock, got %q, %v", got, err)
+	}
+}
+
+func TestQuizRepositoryDropsLoadsInvalidatedMidway(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	ctx := context.Background()
+	loader := &flakyLoader{}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute)
+	release := loader.set(sampleQuiz(), nil)
+	done := make(chan error, 1)
+	go func() {
+		_, err := repo.GetQuiz(ctx, "quiz-1")
+		done <- err
+	}()
+	waitFor(t, func() bool { return loader.waitingCount() == 1 })
+
+	if err := repo.Invalidate(ctx, "quiz-1"); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("get quiz: %v", err)
+	}
+	if mr.Exists("quiz:quiz-1:data") {
+		t.Fatal("expected a load started before Invalidate not to cache its quiz")
+	}
+
+	updated := sampleQuiz()
+	updated.Questions[0].Points = 2
+	close(loader.set(updated, nil))
+	if quiz, err := repo.GetQuiz(ctx, "quiz-1"); err != nil || quiz.Questions[0].Points != 2 || loader.callCount() != 2 {
+		t.Fatalf("expected the quiz to be reloaded, got %+v, %v, calls=%d", quiz, err, loader.callCount())
+	}
+	if !mr.Exists("quiz:quiz-1:data") {
+		t.Fatal("expected loads after Invalidate to be cached")
+	}
+}
+
+func TestQuizRepositoryIgnoresUnwrappedEntries(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	loader := &countingLoader{QuizLoader: memory.NewStaticQuizLoader(map[string]domain.Quiz{"quiz-1": sampleQuiz()})}
+	repo := NewQuizRepository(newClient(mr), loader, time.Minute)
+	if err := mr.Set("quiz:quiz-1:data", `{"id":"quiz-1","questions":[]}`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	quiz, err := repo.GetQuiz(context.Background(), "quiz-1")
+	if err != nil || len(quiz.Questions) != 1 || loader.calls != 1 {
+		t.Fatalf("expected an old-format entry to be reloaded, got %+v, %v, calls=%d", quiz, err, loader.calls)
+	}
+}
+
+// flakyLoader serves one quiz, or an error, once the test lets it.
+type flakyLoader struct {
+	mu      sync.Mutex
+	quiz    domain.Quiz
+	err     error
+	release chan struct{}
+	waiting int
+	calls   int
+}
+
+// set changes what the loader returns; loads wait until the returned channel is closed.
+func (l *flakyLoader) set(quiz domain.Quiz, err error) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quiz, l.err, l.release = quiz, err, make(chan struct{})
+	return l.release
+}
+
+func (l *flakyLoader) LoadQuiz(_ context.Context, _ string) (domain.Quiz, error) {
+	l.mu.Lock()
+	release := l.release
+	l.waiting++
+	l.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting--
+	l.calls++
+	return l.quiz, l.err
+}
+
+func (l *flakyLoader) LoadQuizVersion(ctx context.Context, quizID string, _ int) (domain.Quiz, error) {
+	return l.LoadQuiz(ctx, quizID)
+}
+
+func (l *flakyLoader) callCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+func (l *flakyLoader) waitingCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiting
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the background reload")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 type countingLoader struct {
 	memory.QuizLoader
 	calls int
@@ -52,6 +425,11 @@ func (l *countingLoader) LoadQuiz(ctx context.Context, quizID string) (domain.Qu
 	return l.QuizLoader.LoadQuiz(ctx, quizID)
 }
 
+func (l *countingLoader) LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	l.calls++
+	return l.QuizLoader.LoadQuizVersion(ctx, quizID, version)
+}
+
 func sampleQuiz() domain.Quiz {
 	return domain.Quiz{
 		ID: "quiz-1",
@@ -15,6 +15,8 @@ import (
 //     in-process broadcast logic.
 //   - Redis is used to mark session liveness (and could be extended to share
 //     snapshots or route cross-instance pub/sub).
+//   - Sessions and the join codes pointing at them only work on the instance that created them,
+//     so one server uses a Redis database at a time; see InstanceLease.
 //   - For true distribution you'd pair this with a pub/sub projector that fans out updates.
 type SessionStore struct {
 	client   *redis.Client
@@ -31,39 +33,51 @@ func NewSessionStore(client *redis.Client, ttl time.Duration) *SessionStore {
 	}
 }
 
-func (s *SessionStore) GetOrCreate(quizID string) *app.Session {
+func (s *SessionStore) Create(sessionID, quizID string, expiresAt time.Time) *app.Session {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if session, ok := s.sessions[quizID]; ok {
-		return session
+	var swept []string
+	for id, session := range s.sessions {
+		if session.Idle() {
+			delete(s.sessions, id)
+			swept = append(swept, s.key(id))
+		}
 	}
-	session := app.NewSession(quizID)
-	s.sessions[quizID] = session
-	// best-effort liveness marker
-	_ = s.client.Set(context.Background(), s.key(quizID), "1", s.ttl).Err()
+	if len(swept) > 0 {
+		_ = s.client.Del(context.Background(), swept...).Err()
+	}
+	session := app.NewSession(sessionID, quizID)
+	session.SetExpiry(expiresAt)
+	s.sessions[sessionID] = session
+	// best-effort liveness marker, kept for as long as the session may be joined
+	ttl := s.ttl
+	if untilExpiry := time.Until(expiresAt); untilExpiry > ttl {
+		ttl = untilExpiry
+	}
+	_ = s.client.Set(context.Background(), s.key(sessionID), quizID, ttl).Err()
 	return session
 }
 
-func (s *SessionStore) Get(quizID string) (*app.Session, bool) {
+func (s *SessionStore) Get(sessionID string) (*app.Session, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	session, ok := s.sessions[quizID]
+	session, ok := s.sessions[sessionID]
 	return session, ok
 }
 
-func (s *SessionStore) DeleteIfEmpty(quizID string) {
+func (s *SessionStore) DeleteIfEmpty(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	session, ok := s.sessions[quizID]
+	session, ok := s.sessions[sessionID]
 	if !ok {
 		return
 	}
-	if session.IsEmpty() {
-		delete(s.sessions, quizID)
-		_ = s.client.Del(context.Background(), s.key(quizID)).Err()
+	if session.Idle() {
+		delete(s.sessions, sessionID)
+		_ = s.client.Del(context.Background(), s.key(sessionID)).Err()
 	}
 }
 
-func (s *SessionStore) key(quizID string) string {
-	return "quiz:session:" + quizID
+func (s *SessionStore) key(sessionID string) string {
+	return "quiz:session:" + sessionID
 }
@@ -18,13 +18,34 @@ func TestSessionStoreSetsAndClearsKeys(t *testing.T) {
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	store := NewSessionStore(client, time.Minute)
 
-	_ = store.GetOrCreate("quiz-1")
-	if !mr.Exists("quiz:session:quiz-1") {
+	_ = store.Create("session-1", "quiz-1", time.Now())
+	if !mr.Exists("quiz:session:session-1") {
 		t.Fatalf("expected redis key to be set")
 	}
 
-	store.DeleteIfEmpty("quiz-1")
-	if mr.Exists("quiz:session:quiz-1") {
+	store.DeleteIfEmpty("session-1")
+	if mr.Exists("quiz:session:session-1") {
 		t.Fatalf("expected redis key to be removed")
 	}
 }
+
+func TestSessionStoreSweepsMarkersOfIdleSessions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	store := NewSessionStore(newClient(mr), time.Minute)
+	_ = store.Create("session-1", "quiz-1", time.Now())
+	_ = store.Create("session-2", "quiz-1", time.Now().Add(time.Hour))
+	if _, ok := store.Get("session-1"); ok {
+		t.Fatal("expected the idle session to be swept")
+	}
+	if mr.Exists("quiz:session:session-1") {
+		t.Fatal("expected the swept session's redis key to be removed")
+	}
+	if !mr.Exists("quiz:session:session-2") {
+		t.Fatal("expected the new session's redis key to be set")
+	}
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// AnswerStore keeps every session's latest answer per participant and question in SQLite.
+type AnswerStore struct {
+	db *sql.DB
+}
+
+func NewAnswerStore(db *sql.DB) *AnswerStore {
+	return &AnswerStore{db: db}
+}
+
+func (s *AnswerStore) Record(ctx context.Context, r domain.AnswerRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO answers (session_id, user_id, question_id, quiz_id, quiz_version, option_id, correct, awarded, response_ms, answered_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (session_id, user_id, question_id) DO UPDATE SET
+    quiz_version = excluded.quiz_version,
+    option_id = excluded.option_id,
+    correct = excluded.correct,
+    awarded = excluded.awarded,
+    response_ms = excluded.response_ms,
+    answered_at = excluded.answered_at`,
+		r.SessionID, r.UserID, r.QuestionID, r.QuizID, r.QuizVersion, r.OptionID, r.Correct, r.Awarded, r.ResponseMs, r.AnsweredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record answer: %w", err)
+	}
+	return nil
+}
+
+func (s *AnswerStore) Answers(ctx context.Context, quizID string, version int) ([]domain.AnswerRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT session_id, user_id, question_id, quiz_id, quiz_version, option_id, correct, awarded, response_ms, answered_at
+FROM answers WHERE quiz_id=? AND quiz_version=? ORDER BY answered_at`, quizID, version)
+	if err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+	defer rows.Close()
+	var records []domain.AnswerRecord
+	for rows.Next() {
+		var r domain.AnswerRecord
+		if err := rows.Scan(&r.SessionID, &r.UserID, &r.QuestionID, &r.QuizID, &r.QuizVersion, &r.OptionID, &r.Correct, &r.Awarded, &r.ResponseMs, &r.AnsweredAt); err != nil {
+			return nil, fmt.Errorf("scan answer: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+	return records, nil
+}
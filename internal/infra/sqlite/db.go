@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqlitemigrations "elsa-quiz-service/migrations/sqlite"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/migrate"
+	_ "modernc.org/sqlite" // pure-Go driver, registered as "sqlite"
+)
+
+// Open opens the database file at path, creating it if needed. Writers wait for each other
+// instead of failing with SQLITE_BUSY, and WAL lets readers carry on while one writes.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	return db, nil
+}
+
+// Migrate applies the SQLite migrations that haven't run yet.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	migrator := migrate.NewMigrator(bun.NewDB(db, sqlitedialect.New()), sqlitemigrations.Migrations)
+	if err := migrator.Init(ctx); err != nil {
+		return err
+	}
+	_, err := migrator.Migrate(ctx)
+	return err
+}
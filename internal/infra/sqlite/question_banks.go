@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// QuestionBanks loads question bank JSON from SQLite.
+type QuestionBanks struct {
+	db *sql.DB
+}
+
+func NewQuestionBanks(db *sql.DB) *QuestionBanks {
+	return &QuestionBanks{db: db}
+}
+
+func (b *QuestionBanks) GetBank(ctx context.Context, bankID string) (domain.QuestionBank, error) {
+	var raw []byte
+	err := b.db.QueryRowContext(ctx, `SELECT data FROM question_banks WHERE id=?`, bankID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.QuestionBank{}, domain.ErrBankNotFound
+	}
+	if err != nil {
+		return domain.QuestionBank{}, fmt.Errorf("load question bank: %w", err)
+	}
+	var bank domain.QuestionBank
+	if err := json.Unmarshal(raw, &bank); err != nil {
+		return domain.QuestionBank{}, fmt.Errorf("unmarshal question bank: %w", err)
+	}
+	return bank, nil
+}
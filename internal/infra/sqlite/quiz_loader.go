@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+	msqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// QuizLoader loads quiz JSON from SQLite. Every save is a new immutable version in
+// quiz_versions; LoadQuiz serves the published one.
+type QuizLoader struct {
+	db *sql.DB
+}
+
+func NewQuizLoader(db *sql.DB) *QuizLoader {
+	return &QuizLoader{db: db}
+}
+
+func (l *QuizLoader) LoadQuiz(ctx context.Context, quizID string) (domain.Quiz, error) {
+	var version int
+	var raw []byte
+	err := l.db.QueryRowContext(ctx, `SELECT version, data FROM quiz_versions WHERE quiz_id=? AND status='published'`, quizID).Scan(&version, &raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Quiz{}, domain.ErrQuizNotFound
+	}
+	if err != nil {
+		return domain.Quiz{}, fmt.Errorf("load quiz: %w", err)
+	}
+	return unmarshalQuiz(raw, version)
+}
+
+func (l *QuizLoader) LoadQuizVersion(ctx context.Context, quizID string, version int) (domain.Quiz, error) {
+	var raw []byte
+	err := l.db.QueryRowContext(ctx, `SELECT data FROM quiz_versions WHERE quiz_id=? AND version=?`, quizID, version).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Quiz{}, domain.ErrQuizVersionNotFound
+	}
+	if err != nil {
+		return domain.Quiz{}, fmt.Errorf("load quiz version: %w", err)
+	}
+	return unmarshalQuiz(raw, version)
+}
+
+func unmarshalQuiz(raw []byte, version int) (domain.Quiz, error) {
+	var quiz domain.Quiz
+	if err := json.Unmarshal(raw, &quiz); err != nil {
+		return domain.Quiz{}, fmt.Errorf("unmarshal quiz: %w", err)
+	}
+	quiz.Version = version
+	return quiz, nil
+}
+
+// SaveQuiz stores the quiz as a new draft version, numbered after the latest one, whatever
+// version the quiz itself carries. PublishQuiz makes it the version new sessions run.
+func (l *QuizLoader) SaveQuiz(ctx context.Context, quiz domain.Quiz) (domain.QuizVersion, error) {
+	quiz.Version = 0
+	raw, err := json.Marshal(quiz)
+	if err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("marshal quiz: %w", err)
+	}
+	saved := domain.QuizVersion{QuizID: quiz.ID, Status: domain.QuizDraft, CreatedAt: time.Now().UTC()}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("save quiz: %w", err)
+	}
+	defer conn.Close()
+	// BEGIN IMMEDIATE takes the write lock before the latest version is read, so concurrent saves
+	// wait their turn instead of numbering themselves alike.
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return domain.QuizVersion{}, fmt.Errorf("save quiz: %w", err)
+	}
+	err = conn.QueryRowContext(ctx, `
+INSERT INTO quiz_versions (quiz_id, version, status, data, created_at)
+SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ? FROM quiz_versions WHERE quiz_id = ?
+RETURNING version`,
+		quiz.ID, domain.QuizDraft, string(raw), saved.CreatedAt, quiz.ID).Scan(&saved.Version)
+	if err == nil {
+		_, err = conn.ExecContext(ctx, `COMMIT`)
+	}
+	if err != nil {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `ROLLBACK`)
+		return domain.QuizVersion{}, saveError(err)
+	}
+	return saved, nil
+}
+
+// saveError reports a version number another save took first as domain.ErrQuizVersionConflict.
+func saveError(err error) error {
+	var sqliteErr *msqlite.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return fmt.Errorf("save quiz: %w", domain.ErrQuizVersionConflict)
+	}
+	return fmt.Errorf("save quiz: %w", err)
+}
+
+// PublishQuiz makes a version the one new sessions run, archiving the version published before
+// it. Publishing an archived version rolls the quiz back to it.
+func (l *QuizLoader) PublishQuiz(ctx context.Context, quizID string, version int) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var raw []byte
+	err = tx.QueryRowContext(ctx, `SELECT status, data FROM quiz_versions WHERE quiz_id=? AND version=?`, quizID, version).Scan(&status, &raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrQuizVersionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if status == domain.QuizPublished {
+		return nil
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `UPDATE quiz_versions SET status=? WHERE quiz_id=? AND status=?`,
+		domain.QuizArchived, quizID, domain.QuizPublished); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE quiz_versions SET status=?, published_at=? WHERE quiz_id=? AND version=?`,
+		domain.QuizPublished, now, quizID, version); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO quizzes (id, data, updated_at) VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		quizID, string(raw), now); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("publish quiz: %w", err)
+	}
+	return nil
+}
+
+// QuizVersions lists a quiz's versions, oldest first.
+func (l *QuizLoader) QuizVersions(ctx context.Context, quizID string) ([]domain.QuizVersion, error) {
+	rows, err := l.db.QueryContext(ctx, `
+SELECT version, status, created_at, published_at FROM quiz_versions WHERE quiz_id=? ORDER BY version`, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("list quiz versions: %w", err)
+	}
+	defer rows.Close()
+	var versions []domain.QuizVersion
+	for rows.Next() {
+		v := domain.QuizVersion{QuizID: quizID}
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&v.Version, &v.Status, &v.CreatedAt, &publishedAt); err != nil {
+			return nil, fmt.Errorf("scan quiz version: %w", err)
+		}
+		if publishedAt.Valid {
+			v.PublishedAt = &publishedAt.Time
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list quiz versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, domain.ErrQuizNotFound
+	}
+	return versions, nil
+}
@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+func openTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quiz.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(context.Background(), db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db, path
+}
+
+func TestQuizzesAndBanksSurviveReopening(t *testing.T) {
+	ctx := context.Background()
+	db, path := openTestDB(t)
+	quiz := domain.Quiz{ID: "quiz-1", Questions: []domain.Question{{
+		ID:      "q1",
+		Prompt:  "What is 2 + 2?",
+		Options: []domain.Option{{ID: "o1", Text: "3"}, {ID: "o2", Text: "4", Correct: true}},
+		Points:  1,
+	}}}
+	saveAndPublish(t, NewQuizLoader(db), quiz)
+	first := quiz
+	first.Version = 1
+	quiz.Questions = slices.Clone(quiz.Questions)
+	quiz.Questions[0].Points = 2
+	saveAndPublish(t, NewQuizLoader(db), quiz)
+	quiz.Version = 2
+	if _, err := db.ExecContext(ctx, `INSERT INTO question_banks (id, data) VALUES ('english', '{"id":"english","questions":[]}')`); err != nil {
+		t.Fatalf("insert bank: %v", err)
+	}
+	db.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if err := Migrate(ctx, reopened); err != nil {
+		t.Fatalf("migrating twice should be a no-op: %v", err)
+	}
+	got, err := NewQuizLoader(reopened).LoadQuiz(ctx, "quiz-1")
+	if err != nil || !reflect.DeepEqual(got, quiz) {
+		t.Fatalf("expected the saved quiz back, got %+v, %v", got, err)
+	}
+	if _, err := NewQuizLoader(reopened).LoadQuiz(ctx, "missing"); !errors.Is(err, domain.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound, got %v", err)
+	}
+	if got, err := NewQuizLoader(reopened).LoadQuizVersion(ctx, "quiz-1", 1); err != nil || !reflect.DeepEqual(got, first) {
+		t.Fatalf("expected version 1 to be kept, got %+v, %v", got, err)
+	}
+	if bank, err := NewQuestionBanks(reopened).GetBank(ctx, "english"); err != nil || bank.ID != "english" {
+		t.Fatalf("expected the english bank, got %+v, %v", bank, err)
+	}
+	if _, err := NewQuestionBanks(reopened).GetBank(ctx, "missing"); !errors.Is(err, domain.ErrBankNotFound) {
+		t.Fatalf("expected ErrBankNotFound, got %v", err)
+	}
+}
+
+func TestDraftsAreServedOnlyOncePublished(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openTestDB(t)
+	loader := NewQuizLoader(db)
+	quiz := domain.Quiz{ID: "quiz-1", Questions: []domain.Question{{
+		ID:      "q1",
+		Prompt:  "Yes?",
+		Options: []domain.Option{{ID: "y", Text: "yes", Correct: true}, {ID: "n", Text: "no"}},
+	}}}
+	saveAndPublish(t, loader, quiz)
+	quiz.Questions = append(quiz.Questions, domain.Question{ID: "q2", Prompt: "No?", Options: quiz.Questions[0].Options})
+	draft, err := loader.SaveQuiz(ctx, quiz)
+	if err != nil || draft.Version != 2 || draft.Status != domain.QuizDraft {
+		t.Fatalf("expected draft version 2, got %+v, %v", draft, err)
+	}
+	if got, _ := loader.LoadQuiz(ctx, "quiz-1"); got.Version != 1 || len(got.Questions) != 1 {
+		t.Fatalf("expected the published version 1 to be served, got %+v", got)
+	}
+
+	if err := loader.PublishQuiz(ctx, "quiz-1", 2); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got, _ := loader.LoadQuiz(ctx, "quiz-1"); got.Version != 2 || len(got.Questions) != 2 {
+		t.Fatalf("expected version 2 to be served, got %+v", got)
+	}
+	versions, err := loader.QuizVersions(ctx, "quiz-1")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("expected two versions, got %+v, %v", versions, err)
+	}
+	if versions[0].Status != domain.QuizArchived || versions[1].Status != domain.QuizPublished || versions[1].PublishedAt == nil {
+		t.Fatalf("expected version 1 archived and version 2 published, got %+v", versions)
+	}
+
+	// Publishing an archived version rolls back to it.
+	if err := loader.PublishQuiz(ctx, "quiz-1", 1); err != nil {
+		t.Fatalf("roll back: %v", err)
+	}
+	if got, _ := loader.LoadQuiz(ctx, "quiz-1"); got.Version != 1 {
+		t.Fatalf("expected version 1 to be served again, got %+v", got)
+	}
+	if err := loader.PublishQuiz(ctx, "quiz-1", 3); !errors.Is(err, domain.ErrQuizVersionNotFound) {
+		t.Fatalf("expected ErrQuizVersionNotFound, got %v", err)
+	}
+	if _, err := loader.QuizVersions(ctx, "missing"); !errors.Is(err, domain.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound, got %v", err)
+	}
+}
+
+func TestConcurrentSavesGetTheirOwnVersions(t *testing.T) {
+	db, _ := openTestDB(t)
+	loader := NewQuizLoader(db)
+	quiz := domain.Quiz{ID: "quiz-1", Questions: []domain.Question{{
+		ID:      "q1",
+		Prompt:  "What is 2 + 2?",
+		Options: []domain.Option{{ID: "o1", Text: "3"}, {ID: "o2", Text: "4", Correct: true}},
+		Points:  1,
+	}}}
+	const saves = 8
+	versions := make(chan int, saves)
+	errs := make(chan error, saves)
+	var wg sync.WaitGroup
+	for i := 0; i < saves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved, err := loader.SaveQuiz(context.Background(), quiz)
+			if err != nil {
+				errs <- err
+				return
+			}
+			versions <- saved.Version
+		}()
+	}
+	wg.Wait()
+	close(versions)
+	close(errs)
+	for err := range errs {
+		t.Fatalf("save: %v", err)
+	}
+	var got []int
+	for version := range versions {
+		got = append(got, version)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("expected versions 1 to %d, got %v", saves, got)
+	}
+}
+
+func saveAndPublish(t *testing.T, loader *QuizLoader, quiz domain.Quiz) {
+	t.Helper()
+	saved, err := loader.SaveQuiz(context.Background(), quiz)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := loader.PublishQuiz(context.Background(), quiz.ID, saved.Version); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+func TestAnswerStoreKeepsTheLatestAnswer(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openTestDB(t)
+	store := NewAnswerStore(db)
+	at := time.Date(2024, 11, 22, 10, 0, 0, 500_000_000, time.UTC)
+	first := domain.AnswerRecord{SessionID: "s1", QuizID: "quiz-1", QuizVersion: 2, QuestionID: "q1", UserID: "alice", OptionID: "o1", ResponseMs: 1200, AnsweredAt: at}
+	changed := first
+	changed.OptionID, changed.Correct, changed.Awarded, changed.AnsweredAt = "o2", true, 100, at.Add(time.Second)
+	bob := domain.AnswerRecord{SessionID: "s1", QuizID: "quiz-1", QuizVersion: 2, QuestionID: "q1", UserID: "bob", OptionID: "o2", Correct: true, Awarded: 80, AnsweredAt: at.Add(time.Millisecond)}
+	other := domain.AnswerRecord{SessionID: "s2", QuizID: "quiz-2", QuestionID: "q1", UserID: "alice", OptionID: "o1", AnsweredAt: at}
+	older := domain.AnswerRecord{SessionID: "s0", QuizID: "quiz-1", QuizVersion: 1, QuestionID: "q1", UserID: "carol", OptionID: "o1", AnsweredAt: at}
+	for _, r := range []domain.AnswerRecord{first, bob, changed, other, older} {
+		if err := store.Record(ctx, r); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	got, err := store.Answers(ctx, "quiz-1", 2)
+	if err != nil {
+		t.Fatalf("answers: %v", err)
+	}
+	if !reflect.DeepEqual(got, []domain.AnswerRecord{bob, changed}) {
+		t.Fatalf("expected bob's answer and alice's changed one, got %+v", got)
+	}
+}
@@ -50,23 +50,30 @@ func TestSubmitAnswerEndToEnd(t *testing.T) {
 	quizRepo := infraredis.NewQuizRepository(redisClient, loader, 5*time.Minute)
 	sessionStore := infraredis.NewSessionStore(redisClient, 5*time.Minute)
 	service := app.NewQuizService(sessionStore, quizRepo)
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if info.QuizVersion != 1 {
+		t.Fatalf("expected the session pinned to version 1, got %d", info.QuizVersion)
+	}
 
-	if _, err := service.Join(ctx, "quiz-1", "u1", "Alice"); err != nil {
+	if _, err := service.Join(ctx, info.SessionID, "u1", "Alice"); err != nil {
 		t.Fatalf("join: %v", err)
 	}
-	if _, err := service.Join(ctx, "quiz-1", "u2", "Bob"); err != nil {
+	if _, err := service.Join(ctx, info.SessionID, "u2", "Bob"); err != nil {
 		t.Fatalf("join: %v", err)
 	}
 
-	lb, total, awarded, correct, err := service.SubmitAnswer(ctx, "quiz-1", "u2", domain.AnswerSubmission{
+	lb, result, err := service.SubmitAnswer(ctx, info.SessionID, "u2", domain.AnswerSubmission{
 		QuestionID: "q1",
 		OptionID:   "o2",
 	})
 	if err != nil {
 		t.Fatalf("submit: %v", err)
 	}
-	if !correct || awarded != 1 || total != 1 {
-		t.Fatalf("expected correct answer with 1 point, got correct=%v awarded=%d total=%d", correct, awarded, total)
+	if !result.Correct || result.Awarded != 1 || result.TotalScore != 1 {
+		t.Fatalf("expected correct answer with 1 point, got %+v", result)
 	}
 	if len(lb.Entries) != 2 || lb.Entries[0].UserID != "u2" {
 		t.Fatalf("expected bob leading, got %+v", lb.Entries)
@@ -153,7 +160,7 @@ func seedQuiz(t *testing.T, ctx context.Context, dsn string, quiz domain.Quiz) {
 	if err != nil {
 		t.Fatalf("marshal quiz: %v", err)
 	}
-	if _, err := db.ExecContext(ctx, `INSERT INTO quizzes (id, data) VALUES (? , ?::jsonb) ON CONFLICT (id) DO UPDATE SET data=EXCLUDED.data`, quiz.ID, string(data)); err != nil {
+	if _, err := db.ExecContext(ctx, `INSERT INTO quiz_versions (quiz_id, version, status, data, published_at) VALUES (?, 1, 'published', ?::jsonb, NOW())`, quiz.ID, string(data)); err != nil {
 		t.Fatalf("insert quiz: %v", err)
 	}
 }
@@ -0,0 +1,193 @@
+package quizfmt
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// CSV files have a header row and one question per row. Options go in option_1, option_2, ...
+// columns, as many as the widest question needs; correct lists the numbers of the correct ones.
+// Lists (correct, hints, tags) are separated by |, and scale is written min-max.
+var csvColumns = []string{"id", "type", "prompt", "points", "correct", "explanation", "hints", "tags", "difficulty", "scale", "irt_a", "irt_b"}
+
+const csvOptionPrefix = "option_"
+
+var csvScale = regexp.MustCompile(`^(-?\d+)-(-?\d+)$`)
+
+func csvList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func decodeCSV(r io.Reader, d *decoded) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read csv: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	var options []int // column of option_1, option_2, ...
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if n, ok := strings.CutPrefix(name, csvOptionPrefix); ok {
+			if k, err := strconv.Atoi(n); err == nil && k >= 1 {
+				for len(options) < k {
+					options = append(options, -1)
+				}
+				options[k-1] = i
+				continue
+			}
+		}
+		if !slices.Contains(csvColumns, name) {
+			d.issue(1, "", "unknown column %q; ignored", name)
+			continue
+		}
+		columns[name] = i
+	}
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		q := domain.Question{
+			ID:          field("id"),
+			Type:        field("type"),
+			Prompt:      field("prompt"),
+			Explanation: field("explanation"),
+			Tags:        csvList(field("tags")),
+			Difficulty:  field("difficulty"),
+		}
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q%d", n)
+		}
+		if q.Type == domain.QuestionChoice {
+			q.Type = ""
+		}
+		if s := field("points"); s != "" {
+			if q.Points, err = strconv.Atoi(s); err != nil {
+				d.issue(line, q.ID, "points %q isn't a whole number; ignored", s)
+			}
+		}
+		for _, hint := range csvList(field("hints")) {
+			q.Hints = append(q.Hints, domain.Hint{Text: hint})
+		}
+		if s := field("scale"); s != "" {
+			m := csvScale.FindStringSubmatch(s)
+			if m == nil {
+				d.issue(line, q.ID, "scale %q isn't min-max; ignored", s)
+			} else {
+				lo, _ := strconv.Atoi(m[1])
+				hi, _ := strconv.Atoi(m[2])
+				q.Scale = &domain.RatingScale{Min: lo, Max: hi}
+			}
+		}
+		if a, b := field("irt_a"), field("irt_b"); a != "" || b != "" {
+			q.IRT = &domain.ItemParams{}
+			for _, p := range []struct {
+				name, value string
+				dst         *float64
+			}{{"irt_a", a, &q.IRT.Discrimination}, {"irt_b", b, &q.IRT.Difficulty}} {
+				if p.value == "" {
+					continue
+				}
+				if *p.dst, err = strconv.ParseFloat(p.value, 64); err != nil {
+					d.issue(line, q.ID, "%s %q isn't a number; ignored", p.name, p.value)
+				}
+			}
+		}
+		for _, col := range options {
+			if col >= 0 && col < len(record) && strings.TrimSpace(record[col]) != "" {
+				q.Options = append(q.Options, domain.Option{ID: optionID(len(q.Options)), Text: strings.TrimSpace(record[col])})
+			}
+		}
+		for _, s := range csvList(field("correct")) {
+			k, err := strconv.Atoi(s)
+			if err != nil || k < 1 || k > len(q.Options) {
+				d.issue(line, q.ID, "correct option %q doesn't name an option; ignored", s)
+				continue
+			}
+			q.Options[k-1].Correct = true
+		}
+		d.add(line, q)
+	}
+}
+
+func encodeCSV(w io.Writer, quiz domain.Quiz, e *encoder) error {
+	e.dropped(quiz, "CSV")
+	width := 0
+	for _, q := range quiz.Questions {
+		width = max(width, len(q.Options))
+	}
+	header := append([]string(nil), csvColumns...)
+	for i := 1; i <= width; i++ {
+		header = append(header, csvOptionPrefix+strconv.Itoa(i))
+	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write(header)
+	for _, q := range quiz.Questions {
+		e.checkOptionIDs(q)
+		var correct, hints []string
+		for i, o := range q.Options {
+			if o.Correct {
+				correct = append(correct, strconv.Itoa(i+1))
+			}
+		}
+		costs := false
+		for _, h := range q.Hints {
+			costs = costs || h.Cost > 0
+			hints = append(hints, h.Text)
+		}
+		if costs {
+			e.issue(q.ID, "hint costs can't be written as CSV; dropped")
+		}
+		var scale, irtA, irtB string
+		if q.Scale != nil {
+			scale = fmt.Sprintf("%d-%d", q.Scale.Min, q.Scale.Max)
+		}
+		if q.IRT != nil {
+			irtA = strconv.FormatFloat(q.IRT.Discrimination, 'g', -1, 64)
+			irtB = strconv.FormatFloat(q.IRT.Difficulty, 'g', -1, 64)
+		}
+		row := []string{
+			q.ID, q.Type, q.Prompt, strconv.Itoa(q.Points), strings.Join(correct, "|"), q.Explanation,
+			strings.Join(hints, "|"), strings.Join(q.Tags, "|"), q.Difficulty, scale, irtA, irtB,
+		}
+		for _, o := range q.Options {
+			row = append(row, o.Text)
+		}
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		_ = cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
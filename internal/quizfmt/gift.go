@@ -0,0 +1,320 @@
+package quizfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// giftBlock is one question's text, joined across lines, and the line it starts on.
+type giftBlock struct {
+	text string
+	line int
+}
+
+// lineOf returns the line offset off of the block falls on.
+func (b giftBlock) lineOf(off int) int {
+	return b.line + strings.Count(b.text[:off], "\n")
+}
+
+// giftBlocks splits a GIFT file into questions: runs of lines separated by blank lines outside
+// braces, with // comment lines left out.
+func giftBlocks(r io.Reader) ([]giftBlock, error) {
+	var blocks []giftBlock
+	var cur []string
+	start, depth := 0, 0
+	flush := func() {
+		if len(cur) > 0 {
+			blocks = append(blocks, giftBlock{text: strings.Join(cur, "\n"), line: start})
+		}
+		cur = nil
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if trimmed == "" {
+			if depth == 0 {
+				flush()
+			}
+			continue
+		}
+		if len(cur) == 0 {
+			start = n
+		}
+		cur = append(cur, line)
+		for i := 0; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+	}
+	flush()
+	return blocks, scanner.Err()
+}
+
+// indexUnescaped finds sep in s outside backslash escapes, from off onwards.
+func indexUnescaped(s, sep string, off int) int {
+	for i := off; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+var giftFormatTag = regexp.MustCompile(`^\[(html|moodle|plain|markdown)\]`)
+
+// giftText unescapes and trims a piece of GIFT text; line breaks in the file become spaces.
+func giftText(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// giftAnswer is one =, ~ or # entry in a question's answer block.
+type giftAnswer struct {
+	mark byte // '=', '~', '#' (feedback on the previous answer) or 'G' (general feedback)
+	text string
+	off  int // offset of the mark within the block
+}
+
+// giftAnswers splits the inside of an answer block, which starts at offset base of its block.
+func giftAnswers(body string, base int) []giftAnswer {
+	var answers []giftAnswer
+	startAt := func(i int, mark byte) {
+		answers = append(answers, giftAnswer{mark: mark, off: base + i})
+	}
+	last := 0
+	closeLast := func(end int) {
+		if n := len(answers); n > 0 {
+			answers[n-1].text = body[last:end]
+		}
+	}
+	for i := 0; i < len(body); i++ {
+		switch {
+		case body[i] == '\\':
+			i++
+		case strings.HasPrefix(body[i:], "####"):
+			closeLast(i)
+			startAt(i, 'G')
+			i += 3
+			last = i + 1
+		case body[i] == '=' || body[i] == '~' || body[i] == '#':
+			closeLast(i)
+			startAt(i, body[i])
+			last = i + 1
+		}
+	}
+	closeLast(len(body))
+	return answers
+}
+
+var giftWeight = regexp.MustCompile(`^%(-?[0-9.]+)%`)
+
+func decodeGIFT(r io.Reader, d *decoded) error {
+	blocks, err := giftBlocks(r)
+	if err != nil {
+		return fmt.Errorf("read gift: %w", err)
+	}
+	for n, block := range blocks {
+		off := len(block.text) - len(strings.TrimLeft(block.text, " \t"))
+		text := strings.TrimSpace(block.text)
+		if strings.HasPrefix(text, "$CATEGORY:") {
+			d.issue(block.line, "", "categories aren't supported; ignored")
+			continue
+		}
+		id := fmt.Sprintf("q%d", n+1)
+		if strings.HasPrefix(text, "::") {
+			if end := indexUnescaped(text, "::", 2); end >= 0 {
+				id = giftText(text[2:end])
+				text = text[end+2:]
+				off += end + 2
+			}
+		}
+		open := indexUnescaped(text, "{", 0)
+		if open < 0 {
+			d.issue(block.line, id, "descriptions without answers aren't supported; skipped")
+			continue
+		}
+		end := indexUnescaped(text, "}", open)
+		if end < 0 {
+			d.issue(block.line, id, "answer block isn't closed; skipped")
+			continue
+		}
+		prompt := strings.TrimSpace(text[:open])
+		if rest := giftText(text[end+1:]); rest != "" {
+			prompt += " _____ " + rest
+		}
+		q := domain.Question{ID: id, Prompt: giftText(giftFormatTag.ReplaceAllString(prompt, ""))}
+		if ok := giftQuestion(d, block, off+open+1, text[open+1:end], &q); ok {
+			d.add(block.line, q)
+		}
+	}
+	return nil
+}
+
+// giftQuestion fills in q's options from its answer block, which starts at offset base of the
+// block, and reports whether the question can be imported.
+func giftQuestion(d *decoded, block giftBlock, base int, body string, q *domain.Question) bool {
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed == "":
+		d.issue(block.line, q.ID, "essay questions aren't supported; skipped")
+		return false
+	case strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "####"):
+		d.issue(block.line, q.ID, "numerical questions aren't supported; skipped")
+		return false
+	}
+	answers := giftAnswers(body, base)
+	if len(answers) == 0 || answers[0].mark != '=' && answers[0].mark != '~' {
+		head, _, _ := strings.Cut(trimmed, "#")
+		switch strings.ToUpper(strings.TrimSpace(head)) {
+		case "T", "TRUE", "F", "FALSE":
+			truth := strings.HasPrefix(strings.ToUpper(strings.TrimSpace(head)), "T")
+			q.Options = []domain.Option{{ID: "true", Text: "True", Correct: truth}, {ID: "false", Text: "False", Correct: !truth}}
+			giftFeedback(d, block, answers, q)
+			return true
+		}
+		d.issue(block.line, q.ID, "unrecognised answer block; skipped")
+		return false
+	}
+	wrong := 0
+	for _, a := range answers {
+		if a.mark == '~' {
+			wrong++
+		}
+		if a.mark == '=' && strings.Contains(a.text, "->") {
+			d.issue(block.lineOf(a.off), q.ID, "matching questions aren't supported; skipped")
+			return false
+		}
+	}
+	if wrong == 0 {
+		d.issue(block.line, q.ID, "short answer questions aren't supported; skipped")
+		return false
+	}
+	for _, a := range answers {
+		if a.mark != '=' && a.mark != '~' {
+			continue
+		}
+		text, correct := a.text, a.mark == '='
+		if m := giftWeight.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+			text = strings.TrimSpace(text)[len(m[0]):]
+			weight, _ := strconv.ParseFloat(m[1], 64)
+			switch {
+			case weight >= 100:
+				correct = true
+			case weight > 0:
+				correct = false
+				d.issue(block.lineOf(a.off), q.ID, "partial credit isn't supported; option %q counts as wrong", giftText(text))
+			}
+		}
+		q.Options = append(q.Options, domain.Option{ID: optionID(len(q.Options)), Text: giftText(text), Correct: correct})
+	}
+	giftFeedback(d, block, answers, q)
+	return true
+}
+
+// giftFeedback keeps general feedback as the explanation and reports per-answer feedback.
+func giftFeedback(d *decoded, block giftBlock, answers []giftAnswer, q *domain.Question) {
+	for _, a := range answers {
+		switch a.mark {
+		case 'G':
+			q.Explanation = giftText(a.text)
+		case '#':
+			if giftText(a.text) != "" {
+				d.issue(block.lineOf(a.off), q.ID, "per-answer feedback isn't supported; dropped")
+			}
+		}
+	}
+}
+
+var giftSpecial = strings.NewReplacer(`\`, `\\`, `~`, `\~`, `=`, `\=`, `#`, `\#`, `{`, `\{`, `}`, `\}`, `:`, `\:`, "\n", `\n`)
+
+func isTrueFalse(q domain.Question) bool {
+	return len(q.Options) == 2 && q.Options[0].ID == "true" && q.Options[1].ID == "false"
+}
+
+func encodeGIFT(w io.Writer, quiz domain.Quiz, e *encoder) error {
+	e.dropped(quiz, "GIFT")
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// quiz %s\n", quiz.ID)
+	for _, q := range quiz.Questions {
+		if !q.Graded() {
+			e.issue(q.ID, "%s questions can't be written as GIFT; skipped", q.Type)
+			continue
+		}
+		e.droppedFields(q, "GIFT", q.Points > 1, len(q.Hints) > 0, len(q.Tags) > 0 || q.Difficulty != "")
+		fmt.Fprintf(bw, "\n::%s:: %s {", giftSpecial.Replace(q.ID), giftSpecial.Replace(q.Prompt))
+		if isTrueFalse(q) {
+			if q.Options[0].Correct {
+				bw.WriteString("T")
+			} else {
+				bw.WriteString("F")
+			}
+		} else {
+			e.checkOptionIDs(q)
+			for _, o := range q.Options {
+				mark := "~"
+				if o.Correct {
+					mark = "="
+				}
+				fmt.Fprintf(bw, "\n\t%s%s", mark, giftSpecial.Replace(o.Text))
+			}
+		}
+		if q.Explanation != "" {
+			fmt.Fprintf(bw, "\n\t####%s", giftSpecial.Replace(q.Explanation))
+		}
+		if !isTrueFalse(q) {
+			bw.WriteString("\n")
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
+
+// droppedFields reports question fields a format has no room for; the flags say which of points,
+// hints and tags the format drops for this question.
+func (e *encoder) droppedFields(q domain.Question, format string, points, hints, tags bool) {
+	if points {
+		e.issue(q.ID, "points can't be written as %s; dropped", format)
+	}
+	if hints {
+		e.issue(q.ID, "hints can't be written as %s; dropped", format)
+	}
+	if tags {
+		e.issue(q.ID, "tags and difficulty can't be written as %s; dropped", format)
+	}
+	if q.IRT != nil {
+		e.issue(q.ID, "IRT parameters can't be written as %s; dropped", format)
+	}
+}
@@ -0,0 +1,202 @@
+package quizfmt
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+type moodleQuiz struct {
+	XMLName   xml.Name         `xml:"quiz"`
+	Questions []moodleQuestion `xml:"question"`
+}
+
+type moodleText struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+type moodleQuestion struct {
+	Type            string         `xml:"type,attr"`
+	Name            moodleText     `xml:"name"`
+	QuestionText    moodleText     `xml:"questiontext"`
+	GeneralFeedback *moodleText    `xml:"generalfeedback,omitempty"`
+	DefaultGrade    string         `xml:"defaultgrade,omitempty"`
+	Single          string         `xml:"single,omitempty"`
+	Answers         []moodleAnswer `xml:"answer"`
+	Hints           []moodleText   `xml:"hint"`
+	Tags            []moodleText   `xml:"tags>tag"`
+}
+
+type moodleAnswer struct {
+	Fraction string      `xml:"fraction,attr"`
+	Format   string      `xml:"format,attr,omitempty"`
+	Text     string      `xml:"text"`
+	Feedback *moodleText `xml:"feedback,omitempty"`
+}
+
+// difficultyTag carries a question's difficulty through Moodle's tags.
+const difficultyTag = "difficulty:"
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// moodleString turns Moodle text into plain text, dropping the markup of HTML-formatted text.
+func moodleString(t moodleText) string {
+	if t.Format == "html" {
+		return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(t.Text, "")))
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func decodeMoodle(r io.Reader, d *decoded) error {
+	dec := xml.NewDecoder(r)
+	for n := 1; ; {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read moodle xml: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "question" {
+			continue
+		}
+		line, _ := dec.InputPos()
+		var mq moodleQuestion
+		if err := dec.DecodeElement(&mq, &start); err != nil {
+			return fmt.Errorf("read moodle xml: line %d: %w", line, err)
+		}
+		if mq.Type == "category" {
+			d.issue(line, "", "categories aren't supported; ignored")
+			continue
+		}
+		id := moodleString(mq.Name)
+		if id == "" {
+			id = fmt.Sprintf("q%d", n)
+		}
+		n++
+		if mq.Type != "multichoice" && mq.Type != "truefalse" {
+			d.issue(line, id, "%s questions aren't supported; skipped", mq.Type)
+			continue
+		}
+		d.add(line, moodleQuestionOf(d, line, id, mq))
+	}
+}
+
+func moodleQuestionOf(d *decoded, line int, id string, mq moodleQuestion) domain.Question {
+	q := domain.Question{ID: id, Prompt: moodleString(mq.QuestionText)}
+	if mq.GeneralFeedback != nil {
+		q.Explanation = moodleString(*mq.GeneralFeedback)
+	}
+	if mq.DefaultGrade != "" {
+		grade, err := strconv.ParseFloat(mq.DefaultGrade, 64)
+		switch {
+		case err != nil:
+			d.issue(line, id, "defaultgrade %q isn't a number; ignored", mq.DefaultGrade)
+		case grade != math.Trunc(grade):
+			d.issue(line, id, "fractional grades aren't supported; rounded")
+			fallthrough
+		default:
+			q.Points = int(math.Round(grade))
+		}
+	}
+	for _, h := range mq.Hints {
+		q.Hints = append(q.Hints, domain.Hint{Text: moodleString(h)})
+	}
+	for _, t := range mq.Tags {
+		tag := moodleString(t)
+		if difficulty, ok := strings.CutPrefix(tag, difficultyTag); ok {
+			q.Difficulty = difficulty
+		} else {
+			q.Tags = append(q.Tags, tag)
+		}
+	}
+	feedback := false
+	for i, a := range mq.Answers {
+		text := moodleString(moodleText{Format: a.Format, Text: a.Text})
+		fraction, _ := strconv.ParseFloat(a.Fraction, 64)
+		if fraction > 0 && fraction < 100 {
+			d.issue(line, id, "partial credit isn't supported; option %q counts as wrong", text)
+		}
+		if a.Feedback != nil && moodleString(*a.Feedback) != "" {
+			feedback = true
+		}
+		option := domain.Option{ID: optionID(i), Text: text, Correct: fraction >= 100}
+		if mq.Type == "truefalse" {
+			option.ID = strings.ToLower(text)
+		}
+		q.Options = append(q.Options, option)
+	}
+	if feedback {
+		d.issue(line, id, "per-answer feedback isn't supported; dropped")
+	}
+	return q
+}
+
+func encodeMoodle(w io.Writer, quiz domain.Quiz, e *encoder) error {
+	e.dropped(quiz, "Moodle XML")
+	out := moodleQuiz{}
+	for _, q := range quiz.Questions {
+		if !q.Graded() {
+			e.issue(q.ID, "%s questions can't be written as Moodle XML; skipped", q.Type)
+			continue
+		}
+		e.droppedFields(q, "Moodle XML", false, false, false)
+		mq := moodleQuestion{
+			Type:         "multichoice",
+			Name:         moodleText{Text: q.ID},
+			QuestionText: moodleText{Format: "plain_text", Text: q.Prompt},
+			DefaultGrade: strconv.Itoa(max(q.Points, 1)),
+			Single:       "true",
+		}
+		if isTrueFalse(q) {
+			mq.Type, mq.Single = "truefalse", ""
+		} else {
+			e.checkOptionIDs(q)
+		}
+		if q.Explanation != "" {
+			mq.GeneralFeedback = &moodleText{Format: "plain_text", Text: q.Explanation}
+		}
+		for _, o := range q.Options {
+			fraction := "0"
+			if o.Correct {
+				fraction = "100"
+			}
+			mq.Answers = append(mq.Answers, moodleAnswer{Fraction: fraction, Format: "plain_text", Text: o.Text})
+		}
+		costs := false
+		for _, h := range q.Hints {
+			costs = costs || h.Cost > 0
+			mq.Hints = append(mq.Hints, moodleText{Format: "plain_text", Text: h.Text})
+		}
+		if costs {
+			e.issue(q.ID, "hint costs can't be written as Moodle XML; dropped")
+		}
+		for _, t := range q.Tags {
+			mq.Tags = append(mq.Tags, moodleText{Text: t})
+		}
+		if q.Difficulty != "" {
+			mq.Tags = append(mq.Tags, moodleText{Text: difficultyTag + q.Difficulty})
+		}
+		out.Questions = append(out.Questions, mq)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("write moodle xml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,170 @@
+// Package quizfmt converts quizzes to and from the formats authoring tools and LMSs use: GIFT,
+// Moodle XML, CSV and YAML.
+package quizfmt
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+// Supported formats.
+const (
+	FormatGIFT   = "gift"
+	FormatMoodle = "moodle"
+	FormatCSV    = "csv"
+	FormatYAML   = "yaml"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{FormatGIFT, FormatMoodle, FormatCSV, FormatYAML}
+
+// Issue is something in a file that couldn't be carried over, or, with Invalid set, a reason
+// the resulting quiz fails validation.
+type Issue struct {
+	Line     int    `json:"line,omitempty"` // 1-based; zero when the issue isn't tied to a line
+	Question string `json:"question,omitempty"`
+	Message  string `json:"message"`
+	Invalid  bool   `json:"invalid,omitempty"`
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Question != "" {
+		fmt.Fprintf(&b, "question %s: ", i.Question)
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// Invalid reports whether any of issues makes the quiz fail validation.
+func Invalid(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Invalid {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatOf picks a format from a file's extension.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gift", ".txt":
+		return FormatGIFT, nil
+	case ".xml":
+		return FormatMoodle, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("can't tell the format of %s: use one of %v", path, Formats)
+}
+
+// decoded is a quiz read from a file, with the line each question started on.
+type decoded struct {
+	quiz   domain.Quiz
+	lines  map[string]int
+	issues []Issue
+}
+
+func (d *decoded) issue(line int, question, format string, args ...any) {
+	d.issues = append(d.issues, Issue{Line: line, Question: question, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *decoded) add(line int, q domain.Question) {
+	d.quiz.Questions = append(d.quiz.Questions, q)
+	d.lines[q.ID] = line
+}
+
+// Decode reads a quiz in the given format. quizID, when set, names the quiz; GIFT, Moodle XML
+// and CSV files don't carry a quiz ID of their own. The issues list every construct that was
+// skipped, in file order, followed by the quiz's validation problems, marked Invalid.
+func Decode(format string, r io.Reader, quizID string) (domain.Quiz, []Issue, error) {
+	d := &decoded{lines: make(map[string]int)}
+	var err error
+	switch format {
+	case FormatGIFT:
+		err = decodeGIFT(r, d)
+	case FormatMoodle:
+		err = decodeMoodle(r, d)
+	case FormatCSV:
+		err = decodeCSV(r, d)
+	case FormatYAML:
+		err = decodeYAML(r, d)
+	default:
+		return domain.Quiz{}, nil, fmt.Errorf("unknown format %q: use one of %v", format, Formats)
+	}
+	if err != nil {
+		return domain.Quiz{}, nil, err
+	}
+	if quizID != "" {
+		d.quiz.ID = quizID
+	}
+	slices.SortStableFunc(d.issues, func(a, b Issue) int { return a.Line - b.Line })
+	for _, p := range d.quiz.Problems() {
+		d.issues = append(d.issues, Issue{Line: d.lines[p.Question], Question: p.Question, Message: p.Message, Invalid: true})
+	}
+	return d.quiz, d.issues, nil
+}
+
+// encoder collects what a format couldn't represent while writing a quiz.
+type encoder struct {
+	issues []Issue
+}
+
+func (e *encoder) issue(question, format string, args ...any) {
+	e.issues = append(e.issues, Issue{Question: question, Message: fmt.Sprintf(format, args...)})
+}
+
+// dropped reports the quiz-level features none of the question-only formats can hold.
+func (e *encoder) dropped(quiz domain.Quiz, format string) {
+	if len(quiz.Draws) > 0 {
+		e.issue("", "question bank draws can't be written as %s", format)
+	}
+	if quiz.Settings != (domain.QuizSettings{}) {
+		e.issue("", "quiz settings can't be written as %s", format)
+	}
+}
+
+// Encode writes quiz in the given format and returns everything the format couldn't represent.
+func Encode(format string, w io.Writer, quiz domain.Quiz) ([]Issue, error) {
+	e := &encoder{}
+	var err error
+	switch format {
+	case FormatGIFT:
+		err = encodeGIFT(w, quiz, e)
+	case FormatMoodle:
+		err = encodeMoodle(w, quiz, e)
+	case FormatCSV:
+		err = encodeCSV(w, quiz, e)
+	case FormatYAML:
+		err = encodeYAML(w, quiz)
+	default:
+		return nil, fmt.Errorf("unknown format %q: use one of %v", format, Formats)
+	}
+	return e.issues, err
+}
+
+// optionID names the i-th (0-based) option of a question read from a format without option IDs.
+func optionID(i int) string {
+	return fmt.Sprintf("o%d", i+1)
+}
+
+// checkOptionIDs reports options whose IDs a format that numbers options won't bring back.
+func (e *encoder) checkOptionIDs(q domain.Question) {
+	for i, o := range q.Options {
+		if o.ID != optionID(i) {
+			e.issue(q.ID, "option ids aren't kept; they are numbered o1, o2, ... on import")
+			return
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package quizfmt
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"elsa-quiz-service/internal/domain"
+)
+
+func sampleQuiz() domain.Quiz {
+	return domain.Quiz{
+		ID: "capitals",
+		Questions: []domain.Question{
+			{
+				ID:     "france",
+				Prompt: "Capital of France?",
+				Options: []domain.Option{
+					{ID: "o1", Text: "Lyon"},
+					{ID: "o2", Text: "Paris", Correct: true},
+					{ID: "o3", Text: "Nice"},
+				},
+				Points:      2,
+				Explanation: "Paris has been the capital since 987.",
+				Hints:       []domain.Hint{{Text: "It's on the Seine"}},
+				Tags:        []string{"geography"},
+				Difficulty:  domain.DifficultyEasy,
+			},
+			{
+				ID:      "flat",
+				Prompt:  "The earth is flat: {not really}",
+				Options: []domain.Option{{ID: "true", Text: "True"}, {ID: "false", Text: "False", Correct: true}},
+				Points:  1,
+			},
+		},
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	want := sampleQuiz()
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := Encode(format, &buf, want); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got, issues, err := Decode(format, &buf, "capitals")
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if Invalid(issues) {
+				t.Fatalf("expected a valid quiz, got %v", issues)
+			}
+			expected := want
+			if format == FormatGIFT {
+				// GIFT has no points, hints or tags.
+				expected = sampleQuiz()
+				q := &expected.Questions[0]
+				q.Points, q.Hints, q.Tags, q.Difficulty = 0, nil, nil, ""
+				expected.Questions[1].Points = 0
+			}
+			if format == FormatCSV {
+				// CSV numbers options, true/false ones included.
+				expected = sampleQuiz()
+				expected.Questions[1].Options[0].ID, expected.Questions[1].Options[1].ID = "o1", "o2"
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Fatalf("round trip changed the quiz:\n got %+v\nwant %+v\n%s", got, expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestEncodeReportsWhatItDrops(t *testing.T) {
+	quiz := sampleQuiz()
+	quiz.Questions = append(quiz.Questions, domain.Question{ID: "mood", Type: domain.QuestionRating, Prompt: "How was it?"})
+	quiz.Draws = []domain.QuestionDraw{{Bank: "english", Count: 2}}
+
+	issues, err := Encode(FormatGIFT, &bytes.Buffer{}, quiz)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var messages []string
+	for _, i := range issues {
+		messages = append(messages, i.String())
+	}
+	want := []string{
+		"question bank draws can't be written as GIFT",
+		"question france: points can't be written as GIFT; dropped",
+		"question france: hints can't be written as GIFT; dropped",
+		"question france: tags and difficulty can't be written as GIFT; dropped",
+		"question mood: rating questions can't be written as GIFT; skipped",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(messages, "\n"))
+	}
+
+	issues, _ = Encode(FormatYAML, &bytes.Buffer{}, quiz)
+	if len(issues) != 0 {
+		t.Fatalf("expected YAML to keep everything, got %v", issues)
+	}
+}
+
+func TestDecodeGIFTReportsUnsupportedConstructsByLine(t *testing.T) {
+	gift := `// capitals
+$CATEGORY: geography
+
+::france:: Capital of France? {
+	=Paris#Correct!
+	~Lyon
+	~%50%Marseille
+	####Paris has been the capital since 987.
+}
+
+::pi:: What is pi? {#3.14:0.01}
+
+::pairs:: Match them {
+	=France -> Paris
+	=Italy -> Rome
+}
+
+Write an essay. {}
+
+Two plus two is {=4 =four}.
+
+The sun is a star. {T}
+`
+	quiz, issues, err := Decode(FormatGIFT, strings.NewReader(gift), "gift-quiz")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.String())
+	}
+	want := []string{
+		"line 2: categories aren't supported; ignored",
+		"line 5: question france: per-answer feedback isn't supported; dropped",
+		`line 7: question france: partial credit isn't supported; option "Marseille" counts as wrong`,
+		"line 11: question pi: numerical questions aren't supported; skipped",
+		"line 14: question pairs: matching questions aren't supported; skipped",
+		"line 18: question q5: essay questions aren't supported; skipped",
+		"line 20: question q6: short answer questions aren't supported; skipped",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+	if quiz.ID != "gift-quiz" || len(quiz.Questions) != 2 {
+		t.Fatalf("expected france and the true/false question, got %+v", quiz)
+	}
+	france := quiz.Questions[0]
+	if france.Explanation != "Paris has been the capital since 987." || len(france.Options) != 3 || !france.Options[0].Correct {
+		t.Fatalf("unexpected france question %+v", france)
+	}
+	if sun := quiz.Questions[1]; sun.ID != "q7" || !sun.Options[0].Correct {
+		t.Fatalf("unexpected true/false question %+v", sun)
+	}
+}
+
+func TestDecodeMoodleXML(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<quiz>
+  <question type="category">
+    <category><text>$course$/Geography</text></category>
+  </question>
+  <question type="multichoice">
+    <name><text>france</text></name>
+    <questiontext format="html"><text><![CDATA[<p>Capital of <b>France</b>?</p>]]></text></questiontext>
+    <generalfeedback format="html"><text>Paris &amp;amp; nowhere else.</text></generalfeedback>
+    <defaultgrade>2.0000000</defaultgrade>
+    <answer fraction="100"><text>Paris</text><feedback><text>Yes!</text></feedback></answer>
+    <answer fraction="0"><text>Lyon</text></answer>
+    <hint format="html"><text>On the Seine</text></hint>
+    <tags><tag><text>europe</text></tag><tag><text>difficulty:easy</text></tag></tags>
+  </question>
+  <question type="shortanswer">
+    <name><text>river</text></name>
+    <questiontext format="html"><text>Longest river?</text></questiontext>
+    <answer fraction="100"><text>Nile</text></answer>
+  </question>
+</quiz>
+`
+	quiz, issues, err := Decode(FormatMoodle, strings.NewReader(xml), "moodle-quiz")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.String())
+	}
+	want := []string{
+		"line 3: categories aren't supported; ignored",
+		"line 6: question france: per-answer feedback isn't supported; dropped",
+		"line 16: question river: shortanswer questions aren't supported; skipped",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+	france := quiz.Questions[0]
+	if france.Prompt != "Capital of France?" || france.Explanation != "Paris & nowhere else." || france.Points != 2 {
+		t.Fatalf("unexpected question %+v", france)
+	}
+	if france.Difficulty != domain.DifficultyEasy || !reflect.DeepEqual(france.Tags, []string{"europe"}) || len(france.Hints) != 1 {
+		t.Fatalf("unexpected tags or hints %+v", france)
+	}
+}
+
+func TestDecodeReportsInvalidQuestionsOnTheirLine(t *testing.T) {
+	csv := `id,prompt,correct,option_1,option_2,colour
+q1,Two plus two?,2,3,4,red
+q2,Pick one,,yes,no,blue
+q3,Pick,3,a,b,
+`
+	_, issues, err := Decode(FormatCSV, strings.NewReader(csv), "csv-quiz")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.String())
+	}
+	want := []string{
+		`line 1: unknown column "colour"; ignored`,
+		`line 4: question q3: correct option "3" doesn't name an option; ignored`,
+		"line 3: question q2: needs exactly one correct option, has 0",
+		"line 4: question q3: needs exactly one correct option, has 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+	if !Invalid(issues) {
+		t.Fatalf("expected the quiz to be invalid")
+	}
+
+	yaml := `id: yaml-quiz
+questions:
+  - id: q1
+    prompt: Two plus two?
+    colour: red
+    options:
+      - {id: a, text: "3"}
+      - {id: b, text: "4", correct: true}
+  - id: q1
+    prompt: Again?
+    options: [{id: a, text: "yes", correct: true}, {id: b, text: "no"}]
+`
+	_, issues, err = Decode(FormatYAML, strings.NewReader(yaml), "")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got = nil
+	for _, i := range issues {
+		got = append(got, i.String())
+	}
+	want = []string{
+		`line 5: question q1: unknown field "colour"; ignored`,
+		"line 9: question q1: duplicate question id",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,156 @@
+package quizfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"elsa-quiz-service/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeYAML reads a quiz written exactly as the JSON API has it, with the same field names.
+func decodeYAML(r io.Reader, d *decoded) error {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("read yaml: %w", err)
+	}
+	checkYAMLKeys(d, &doc, reflect.TypeOf(domain.Quiz{}), "")
+	var v any
+	if err := doc.Decode(&v); err != nil {
+		return fmt.Errorf("read yaml: %w", err)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("read yaml: %w", err)
+	}
+	if err := json.Unmarshal(raw, &d.quiz); err != nil {
+		return fmt.Errorf("read yaml: %w", err)
+	}
+	if questions := yamlField(&doc, "questions"); questions != nil && questions.Kind == yaml.SequenceNode {
+		for i, node := range questions.Content {
+			if i < len(d.quiz.Questions) {
+				d.lines[d.quiz.Questions[i].ID] = node.Line
+			}
+		}
+	}
+	return nil
+}
+
+// yamlField returns the value of key in a mapping node, looking through the document node.
+func yamlField(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkYAMLKeys reports mapping keys that t, a type from the domain package, has no JSON field
+// for; they would otherwise be dropped without a word.
+func checkYAMLKeys(d *decoded, node *yaml.Node, t reflect.Type, question string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			checkYAMLKeys(d, n, t, question)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for _, n := range node.Content {
+			if t.Elem() == reflect.TypeOf(domain.Question{}) {
+				if id := yamlField(n, "id"); id != nil {
+					question = id.Value
+				}
+			}
+			checkYAMLKeys(d, n, t.Elem(), question)
+		}
+	case yaml.MappingNode:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fields[key.Value]
+			if !ok {
+				d.issue(key.Line, question, "unknown field %q; ignored", key.Value)
+				continue
+			}
+			checkYAMLKeys(d, node.Content[i+1], field, question)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of t's fields to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func encodeYAML(w io.Writer, quiz domain.Quiz) error {
+	raw, err := json.Marshal(quiz)
+	if err != nil {
+		return fmt.Errorf("write yaml: %w", err)
+	}
+	// JSON is YAML, so decoding it keeps the field order and names; only the styles need resetting.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("write yaml: %w", err)
+	}
+	plainStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("write yaml: %w", err)
+	}
+	return enc.Close()
+}
+
+// plainStyle resets the JSON styles to YAML's block style, leaving out null and empty values
+// such as a quiz without settings.
+func plainStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.MappingNode {
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			if value.Tag == "!!null" || value.Kind == yaml.MappingNode && len(value.Content) == 0 {
+				continue
+			}
+			content = append(content, node.Content[i], value)
+		}
+		node.Content = content
+	}
+	for _, n := range node.Content {
+		plainStyle(n)
+	}
+}
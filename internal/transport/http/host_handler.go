@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/domain"
+	"github.com/gorilla/websocket"
+)
+
+var errUnsupportedCommand = errors.New("unsupported host command")
+
+// hostCommand is the payload of a host command, sent over the host socket or REST.
+// Each command reads only the fields it needs.
+type hostCommand struct {
+	UserID     string `json:"userId"`
+	Reason     string `json:"reason"`
+	Name       string `json:"name"`
+	Delta      int    `json:"delta"`
+	Note       string `json:"note"`
+	QuestionID string `json:"questionId"`
+	Team       string `json:"team"`
+}
+
+type hostAck struct {
+	Command string `json:"command"`
+	UserID  string `json:"userId,omitempty"`
+	Score   *int   `json:"score,omitempty"`
+}
+
+// runHostCommand dispatches one host command to the matching use case.
+func runHostCommand(ctx context.Context, service *app.QuizService, sessionID, hostToken, command string, cmd hostCommand) (hostAck, error) {
+	ack := hostAck{Command: command, UserID: cmd.UserID}
+	var err error
+	switch command {
+	case domain.ModerationKick:
+		err = service.Kick(ctx, sessionID, hostToken, cmd.UserID, cmd.Reason)
+	case domain.ModerationBan:
+		err = service.Ban(ctx, sessionID, hostToken, cmd.UserID, cmd.Reason)
+	case domain.ModerationRename:
+		err = service.Rename(ctx, sessionID, hostToken, cmd.UserID, cmd.Name)
+	case domain.ModerationAdjustScore, domain.ModerationResetScore:
+		var score int
+		if command == domain.ModerationResetScore {
+			score, err = service.ResetScore(ctx, sessionID, hostToken, cmd.UserID, cmd.Note)
+		} else {
+			score, err = service.AdjustScore(ctx, sessionID, hostToken, cmd.UserID, cmd.Delta, cmd.Note)
+		}
+		ack.Score = &score
+	case "admit":
+		err = service.Admit(ctx, sessionID, hostToken, cmd.UserID)
+	case "deny":
+		err = service.Deny(ctx, sessionID, hostToken, cmd.UserID)
+	case "openQuestion":
+		err = service.OpenQuestion(ctx, sessionID, hostToken, cmd.QuestionID)
+	case "closeQuestion":
+		err = service.CloseQuestion(ctx, sessionID, hostToken, cmd.QuestionID)
+	case "assignTeam":
+		err = service.AssignTeam(ctx, sessionID, hostToken, cmd.UserID, cmd.Team)
+	case "balanceTeams":
+		err = service.BalanceTeams(ctx, sessionID, hostToken)
+	default:
+		err = errUnsupportedCommand
+	}
+	return ack, err
+}
+
+// serveHost runs a host connection: the full leaderboard whatever the session's visibility,
+// live answerStats for each question, plus host commands such as kick, ban, rename, adjustScore,
+// admit, closeQuestion, assignTeam and balanceTeams. Replies are "ack" or "error".
+func (h *WSHandler) serveHost(w http.ResponseWriter, r *http.Request) {
+	hostToken := r.URL.Query().Get("hostToken")
+	sessionID, err := h.resolveSession(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrJoinCodeNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err := h.service.AuthorizeHost(r.Context(), sessionID, hostToken); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("ws upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	messages := make(chan inboundMessage)
+	stopReading := make(chan struct{})
+	defer close(stopReading)
+	go readMessages(conn, messages, stopReading)
+
+	updates, cancel, err := h.service.Subscribe(r.Context(), sessionID)
+	if err != nil {
+		_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorPayload{Message: err.Error()}})
+		return
+	}
+	defer cancel()
+
+	send := make(chan frame, 16)
+	closeSignals := make(chan struct{})
+	writerDone := make(chan struct{})
+	updatesDone := make(chan struct{})
+	go writeFrames(conn, send, writerDone)
+
+	go func() {
+		defer close(updatesDone)
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				prepared, err := update.Prepare(prepareBroadcast)
+				if err != nil {
+					log.Printf("ws encode broadcast: %v", err)
+					continue
+				}
+				select {
+				case send <- frame{prepared: prepared.(*websocket.PreparedMessage)}:
+				case <-closeSignals:
+					return
+				}
+			case <-closeSignals:
+				return
+			}
+		}
+	}()
+
+	for inbound := range messages {
+		var cmd hostCommand
+		if len(inbound.Payload) > 0 {
+			if err := json.Unmarshal(inbound.Payload, &cmd); err != nil {
+				send <- jsonFrame("error", errorPayload{Message: "invalid command payload"})
+				continue
+			}
+		}
+		ack, err := runHostCommand(r.Context(), h.service, sessionID, hostToken, inbound.Type, cmd)
+		if err != nil {
+			send <- jsonFrame("error", errorBody(err))
+			continue
+		}
+		send <- jsonFrame("ack", ack)
+	}
+
+	close(closeSignals)
+	<-updatesDone
+	close(send)
+	<-writerDone
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"elsa-quiz-service/internal/app"
+)
+
+var errAdminOnly = errors.New("admin token required")
+
+// QuizHandler exposes quiz content and reporting over REST. Its routes reveal correct answers,
+// so they need the admin token as a bearer token; with no admin token configured they are off.
+type QuizHandler struct {
+	service    *app.QuizService
+	adminToken string
+}
+
+func NewQuizHandler(service *app.QuizService, adminToken string) *QuizHandler {
+	return &QuizHandler{service: service, adminToken: adminToken}
+}
+
+// authorize writes a 403 and reports false unless the request carries the admin token.
+func (h *QuizHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if h.adminToken == "" || subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(h.adminToken)) != 1 {
+		writeJSON(w, http.StatusForbidden, errorPayload{Message: errAdminOnly.Error()})
+		return false
+	}
+	return true
+}
+
+// Analytics handles GET /quizzes/{id}/analytics[?version={version}], item statistics across
+// every session recorded against the version, the published one by default.
+func (h *QuizHandler) Analytics(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	version := 0
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		var err error
+		if version, err = strconv.Atoi(raw); err != nil || version < 1 {
+			writeJSON(w, http.StatusBadRequest, errorPayload{Message: "version must be a quiz version"})
+			return
+		}
+	}
+	analytics, err := h.service.ItemAnalytics(r.Context(), r.PathValue("id"), version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, analytics)
+}
+
+// Diff handles GET /quizzes/{id}/diff?from={version}&to={version}, the changes between two
+// versions of a quiz.
+func (h *QuizHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		writeJSON(w, http.StatusBadRequest, errorPayload{Message: "from and to must be quiz versions"})
+		return
+	}
+	diff, err := h.service.DiffQuiz(r.Context(), r.PathValue("id"), from, to)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"elsa-quiz-service/internal/app"
+	"elsa-quiz-service/internal/domain"
+)
+
+// SessionHandler exposes host-facing session management over REST.
+type SessionHandler struct {
+	service *app.QuizService
+}
+
+func NewSessionHandler(service *app.QuizService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+type createSessionRequest struct {
+	QuizID   string                 `json:"quizId"`
+	Settings domain.SessionSettings `json:"settings"`
+}
+
+// CreateSession handles POST /sessions and returns the new session with its join code and host token.
+func (h *SessionHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.QuizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorPayload{Message: "body must be JSON with a quizId"})
+		return
+	}
+	info, err := h.service.CreateSession(r.Context(), req.QuizID, req.Settings)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+// OpenQuestion handles POST /sessions/{id}/questions/{questionId}/open for the host.
+func (h *SessionHandler) OpenQuestion(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.OpenQuestion(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("questionId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CloseQuestion handles POST /sessions/{id}/questions/{questionId}/close.
+func (h *SessionHandler) CloseQuestion(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.CloseQuestion(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("questionId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AnswerStats handles GET /sessions/{id}/questions/{questionId}/stats, the question's answer histogram.
+func (h *SessionHandler) AnswerStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.AnswerStats(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("questionId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// Leaderboard handles GET /sessions/{id}/leaderboard, giving the host the full board even when players can't see it.
+func (h *SessionHandler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	lb, err := h.service.HostLeaderboard(r.Context(), r.PathValue("id"), bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lb)
+}
+
+// WaitingRoom handles GET /sessions/{id}/waiting, listing queued players for the host.
+func (h *SessionHandler) WaitingRoom(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.WaitingRoom(r.Context(), r.PathValue("id"), bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// Admit handles POST /sessions/{id}/waiting/{userId}/admit.
+func (h *SessionHandler) Admit(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Admit(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("userId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deny handles POST /sessions/{id}/waiting/{userId}/deny.
+func (h *SessionHandler) Deny(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Deny(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("userId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Moderate handles POST /sessions/{id}/participants/{userId}/{command}, where command is one of
+// kick, ban, rename, adjustScore or resetScore and the body carries its reason, name, delta or note.
+func (h *SessionHandler) Moderate(w http.ResponseWriter, r *http.Request) {
+	var cmd hostCommand
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorPayload{Message: "body must be JSON"})
+			return
+		}
+	}
+	cmd.UserID = r.PathValue("userId")
+	command := r.PathValue("command")
+	switch command {
+	case domain.ModerationKick, domain.ModerationBan, domain.ModerationRename, domain.ModerationAdjustScore, domain.ModerationResetScore:
+	default:
+		writeJSON(w, http.StatusNotFound, errorPayload{Message: errUnsupportedCommand.Error()})
+		return
+	}
+	ack, err := runHostCommand(r.Context(), h.service, r.PathValue("id"), bearerToken(r), command, cmd)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ack)
+}
+
+// AssignTeam handles POST /sessions/{id}/participants/{userId}/team with a {"team": ...} body.
+func (h *SessionHandler) AssignTeam(w http.ResponseWriter, r *http.Request) {
+	var cmd hostCommand
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil || cmd.Team == "" {
+		writeJSON(w, http.StatusBadRequest, errorPayload{Message: "body must be JSON with a team"})
+		return
+	}
+	cmd.UserID = r.PathValue("userId")
+	ack, err := runHostCommand(r.Context(), h.service, r.PathValue("id"), bearerToken(r), "assignTeam", cmd)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ack)
+}
+
+// BalanceTeams handles POST /sessions/{id}/teams/balance, evening out team sizes.
+func (h *SessionHandler) BalanceTeams(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.BalanceTeams(r.Context(), r.PathValue("id"), bearerToken(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Layout handles GET /sessions/{id}/participants/{userId}/layout, the question and option order
+// the participant was shown.
+func (h *SessionHandler) Layout(w http.ResponseWriter, r *http.Request) {
+	layout, err := h.service.Layout(r.Context(), r.PathValue("id"), bearerToken(r), r.PathValue("userId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, layout)
+}
+
+// AuditLog handles GET /sessions/{id}/audit, listing the host's moderation actions.
+func (h *SessionHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	actions, err := h.service.AuditLog(r.Context(), r.PathValue("id"), bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, actions)
+}
+
+// bearerToken reads the host token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError maps domain errors onto HTTP status codes.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrQuizNotFound),
+		errors.Is(err, domain.ErrQuizVersionNotFound),
+		errors.Is(err, domain.ErrSessionNotFound),
+		errors.Is(err, domain.ErrJoinCodeNotFound),
+		errors.Is(err, domain.ErrQuestionNotFound),
+		errors.Is(err, domain.ErrBankNotFound),
+		errors.Is(err, domain.ErrTeamNotFound),
+		errors.Is(err, domain.ErrNotWaiting),
+		errors.Is(err, domain.ErrParticipantNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrSessionFull),
+		errors.Is(err, domain.ErrQuestionClosed),
+		errors.Is(err, domain.ErrNotEnoughQuestions):
+		status = http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidSettings),
+		errors.Is(err, domain.ErrInvalidName),
+		errors.Is(err, domain.ErrAuditNoteRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrHostOnly),
+		errors.Is(err, domain.ErrBanned):
+		status = http.StatusForbidden
+	}
+	writeJSON(w, status, errorBody(err))
+}
@@ -2,8 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"elsa-quiz-service/internal/app"
 	"elsa-quiz-service/internal/domain"
@@ -31,16 +35,29 @@ type inboundMessage struct {
 	Payload json.RawMessage `json:"payload"`
 }
 
+// answerPayload carries an "answer" to a graded question, or a "respond" to a poll using the
+// field that fits the poll's type. Options can be picked by ID or by the position they were shown in.
 type answerPayload struct {
+	QuestionID string   `json:"questionId"`
+	OptionID   string   `json:"optionId"`
+	OptionIDs  []string `json:"optionIds,omitempty"`
+	Position   int      `json:"position,omitempty"`
+	Positions  []int    `json:"positions,omitempty"`
+	Rating     *int     `json:"rating,omitempty"`
+	Text       string   `json:"text,omitempty"`
+}
+
+type questionRequest struct {
+	QuestionID string `json:"questionId"`
+}
+
+type responseRecorded struct {
 	QuestionID string `json:"questionId"`
-	OptionID   string `json:"optionId"`
 }
 
-type answerResult struct {
+type lifelinePayload struct {
 	QuestionID string `json:"questionId"`
-	Correct    bool   `json:"correct"`
-	Awarded    int    `json:"awarded"`
-	TotalScore int    `json:"totalScore"`
+	Lifeline   string `json:"lifeline"`
 }
 
 type outboundMessage[T any] struct {
@@ -50,15 +67,137 @@ type outboundMessage[T any] struct {
 
 type errorPayload struct {
 	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// errorBody describes err, adding structured details clients can act on, such as why a
+// display name was rejected.
+func errorBody(err error) errorPayload {
+	body := errorPayload{Message: err.Error()}
+	var nameErr *domain.NameError
+	if errors.As(err, &nameErr) {
+		body.Details = nameErr
+	}
+	return body
+}
+
+// Close codes for sockets the host removes from a session, or that a newer connection for the
+// same user replaced (4000-4999 is reserved for applications).
+const (
+	CloseKicked    = 4001
+	CloseTakenOver = 4002
+	CloseBanned    = 4003
+)
+
+// frame is a queued socket write: a per-connection JSON message, a shared prepared broadcast,
+// or a close frame that ends the connection.
+type frame struct {
+	msg      any
+	prepared *websocket.PreparedMessage
+	close    *closeFrame
+}
+
+type closeFrame struct {
+	code int
+	text string
+}
+
+func jsonFrame(msgType string, payload any) frame {
+	return frame{msg: outboundMessage[any]{Type: msgType, Payload: payload}}
+}
+
+// prepareBroadcast serializes a session broadcast once; every socket reuses the resulting frame.
+func prepareBroadcast(b *app.Broadcast) (any, error) {
+	data, err := json.Marshal(outboundMessage[any]{Type: b.Type, Payload: b.Payload})
+	if err != nil {
+		return nil, err
+	}
+	return websocket.NewPreparedMessage(websocket.TextMessage, data)
+}
+
+// removalFrame closes a removed participant's socket with a code that says whether they may come back.
+func removalFrame(removal domain.Removal) frame {
+	code := CloseKicked
+	switch {
+	case removal.Banned:
+		code = CloseBanned
+	case removal.TakenOver:
+		code = CloseTakenOver
+	}
+	return frame{close: &closeFrame{code: code, text: removal.Reason}}
+}
+
+// writeFrames is the connection's only writer. After a failed write or a close frame it keeps
+// draining send, so producers never block on a dead socket.
+func writeFrames(conn *websocket.Conn, send <-chan frame, done chan<- struct{}) {
+	defer close(done)
+	// AI-assisted implementation per your direction: read/write wiring adapted from Gorilla patterns with ChatGPT; verified via reasoning and tests to prevent concurrent writes.
+	closed := false
+	for f := range send {
+		if closed {
+			continue
+		}
+		var err error
+		switch {
+		case f.close != nil:
+			closed = true
+			err = writeClose(conn, f.close.code, f.close.text)
+			// Closing the socket also ends the reader, which tears the connection down.
+			_ = conn.Close()
+		case f.prepared != nil:
+			err = conn.WritePreparedMessage(f.prepared)
+		default:
+			err = conn.WriteJSON(f.msg)
+		}
+		if err != nil {
+			log.Printf("ws write error: %v", err)
+			closed = true
+		}
+	}
+}
+
+// writeClose sends a close frame; control frames cap the reason at 123 bytes.
+func writeClose(conn *websocket.Conn, code int, text string) error {
+	if len(text) > 123 {
+		text = text[:123]
+	}
+	return conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
 }
 
 // ServeWS upgrades HTTP requests to websockets and wires them into the quiz use cases.
+// Requests carrying a hostToken open a host connection instead of joining as a player.
 func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
-	quizID := r.URL.Query().Get("quizId")
+	if r.URL.Query().Get("hostToken") != "" {
+		h.serveHost(w, r)
+		return
+	}
 	userID := r.URL.Query().Get("userId")
 	displayName := r.URL.Query().Get("name")
-	if quizID == "" || userID == "" || displayName == "" {
-		http.Error(w, "missing quizId, userId, or name", http.StatusBadRequest)
+	if userID == "" || displayName == "" {
+		http.Error(w, "missing userId or name", http.StatusBadRequest)
+		return
+	}
+	sessionID, err := h.resolveSession(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrJoinCodeNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	view, err := parseView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	since, err := parseSince(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if view.Patch && !view.Full() {
+		http.Error(w, domain.ErrInvalidView.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -69,86 +208,217 @@ func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	joined, err := h.service.Join(r.Context(), quizID, userID, displayName)
+	// A single reader serves the whole connection, so a socket in the waiting room still notices when it closes.
+	messages := make(chan inboundMessage)
+	stopReading := make(chan struct{})
+	defer close(stopReading)
+	go readMessages(conn, messages, stopReading)
+
+	// The connection is registered before joining, so another device closing can't remove the player.
+	connection, err := h.service.Connect(r.Context(), sessionID, userID)
+	if err != nil {
+		_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorBody(err)})
+		return
+	}
+	defer connection.Close()
+
+	team := r.URL.Query().Get("team")
+	waiter, err := h.service.Enter(r.Context(), sessionID, userID, displayName, team)
+	if err != nil {
+		_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorBody(err)})
+		if errors.Is(err, domain.ErrBanned) {
+			_ = writeClose(conn, CloseBanned, err.Error())
+		}
+		return
+	}
+	if waiter != nil && !awaitAdmission(conn, waiter, messages) {
+		return
+	}
+
+	joined, err := h.service.LeaderboardView(r.Context(), sessionID, userID, view)
 	if err != nil {
 		_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorPayload{Message: err.Error()}})
 		return
 	}
 
-	updates, cancel, err := h.service.Subscribe(r.Context(), quizID)
+	updates, cancel, err := h.service.SubscribeView(r.Context(), sessionID, userID, view)
 	if err != nil {
 		_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorPayload{Message: err.Error()}})
+		// Removed between joining and subscribing, so the removal broadcast never reached this socket.
+		switch {
+		case errors.Is(err, domain.ErrBanned):
+			_ = writeClose(conn, CloseBanned, err.Error())
+		case errors.Is(err, domain.ErrParticipantNotFound):
+			_ = writeClose(conn, CloseKicked, err.Error())
+		}
 		return
 	}
 	defer cancel()
-	defer h.service.Leave(r.Context(), quizID, userID)
 
-	send := make(chan outboundMessage[any], 16)
+	send := make(chan frame, 16)
 	closeSignals := make(chan struct{})
 	writerDone := make(chan struct{})
 	updatesDone := make(chan struct{})
 
-	// AI-assisted implementation per your direction: read/write wiring adapted from Gorilla patterns with ChatGPT; verified via reasoning and tests to prevent concurrent writes.
+	go writeFrames(conn, send, writerDone)
+
+	send <- jsonFrame("joined", joined)
+
 	go func() {
-		defer close(writerDone)
-		for msg := range send {
-			if err := conn.WriteJSON(msg); err != nil {
-				log.Printf("ws write error: %v", err)
-				return
+		defer close(updatesDone)
+		forward := func(f frame) bool {
+			select {
+			case send <- f:
+				return true
+			case <-closeSignals:
+				return false
 			}
 		}
-	}()
 
-	go func() {
-		defer close(updatesDone)
+		// Patch subscribers start from the joined snapshot, or from the version they reconnect with.
+		version := joined.Version
+		if since != nil {
+			version = *since
+		}
+		catchUp := func() bool {
+			patches, snapshot, err := h.service.LeaderboardSince(r.Context(), sessionID, version)
+			if err != nil {
+				return forward(jsonFrame("error", errorPayload{Message: err.Error()}))
+			}
+			if snapshot != nil {
+				version = snapshot.Version
+				return forward(jsonFrame(app.EventLeaderboard, *snapshot))
+			}
+			for _, patch := range patches {
+				version = patch.Version
+				if !forward(jsonFrame(app.EventLeaderboardPatch, patch)) {
+					return false
+				}
+			}
+			return true
+		}
+		if view.Patch && !catchUp() {
+			return
+		}
+
 		for {
 			select {
 			case update, ok := <-updates:
 				if !ok {
 					return
 				}
-				select {
-				case send <- outboundMessage[any]{Type: "leaderboard", Payload: update}:
-				case <-closeSignals:
+				if removal, ok := update.Payload.(domain.Removal); ok {
+					// The host removed this participant; nothing follows the removal.
+					if forward(jsonFrame(app.EventRemoved, removal)) {
+						forward(removalFrame(removal))
+					}
+					return
+				}
+				if patch, ok := update.Payload.(domain.LeaderboardPatch); ok {
+					if patch.Version <= version {
+						continue // already delivered by a catch-up
+					}
+					if patch.BaseVersion != version {
+						// A patch was dropped for this slow socket; resync before moving on.
+						if !catchUp() {
+							return
+						}
+						continue
+					}
+					version = patch.Version
+				}
+				prepared, err := update.Prepare(prepareBroadcast)
+				if err != nil {
+					log.Printf("ws encode broadcast: %v", err)
+					continue
+				}
+				if !forward(frame{prepared: prepared.(*websocket.PreparedMessage)}) {
 					return
 				}
+			case <-connection.TakenOver:
+				removal := domain.Removal{Reason: "session taken over", TakenOver: true}
+				if forward(jsonFrame(app.EventRemoved, removal)) {
+					forward(removalFrame(removal))
+				}
+				return
 			case <-closeSignals:
 				return
 			}
 		}
 	}()
 
-	send <- outboundMessage[any]{Type: "joined", Payload: joined}
-
-	for {
-		var inbound inboundMessage
-		if err := conn.ReadJSON(&inbound); err != nil {
-			break
-		}
+	for inbound := range messages {
 		switch inbound.Type {
 		case "answer":
 			var payload answerPayload
 			if err := json.Unmarshal(inbound.Payload, &payload); err != nil {
-				send <- outboundMessage[any]{Type: "error", Payload: errorPayload{Message: "invalid answer payload"}}
+				send <- jsonFrame("error", errorPayload{Message: "invalid answer payload"})
 				continue
 			}
-			lb, total, awarded, correct, err := h.service.SubmitAnswer(r.Context(), quizID, userID, domain.AnswerSubmission{
+			_, result, err := h.service.SubmitAnswer(r.Context(), sessionID, userID, domain.AnswerSubmission{
 				QuestionID: payload.QuestionID,
 				OptionID:   payload.OptionID,
+				Position:   payload.Position,
 			})
 			if err != nil {
-				send <- outboundMessage[any]{Type: "error", Payload: errorPayload{Message: err.Error()}}
+				send <- jsonFrame("error", errorPayload{Message: err.Error()})
+				continue
+			}
+			// The updated leaderboard reaches this socket through the shared session broadcast.
+			send <- jsonFrame("answerResult", result)
+		case "next":
+			step, err := h.service.NextQuestion(r.Context(), sessionID, userID)
+			if err != nil {
+				send <- jsonFrame("error", errorPayload{Message: err.Error()})
+				continue
+			}
+			send <- jsonFrame("step", step)
+		case "question":
+			var payload questionRequest
+			if err := json.Unmarshal(inbound.Payload, &payload); err != nil {
+				send <- jsonFrame("error", errorPayload{Message: "invalid question payload"})
+				continue
+			}
+			question, err := h.service.PlayerQuestion(r.Context(), sessionID, userID, payload.QuestionID)
+			if err != nil {
+				send <- jsonFrame("error", errorPayload{Message: err.Error()})
+				continue
+			}
+			send <- jsonFrame("question", question)
+		case "lifeline":
+			var payload lifelinePayload
+			if err := json.Unmarshal(inbound.Payload, &payload); err != nil {
+				send <- jsonFrame("error", errorPayload{Message: "invalid lifeline payload"})
+				continue
+			}
+			result, err := h.service.UseLifeline(r.Context(), sessionID, userID, payload.QuestionID, payload.Lifeline)
+			if err != nil {
+				send <- jsonFrame("error", errorPayload{Message: err.Error()})
+				continue
+			}
+			send <- jsonFrame("lifelineResult", result)
+		case "respond":
+			var payload answerPayload
+			if err := json.Unmarshal(inbound.Payload, &payload); err != nil {
+				send <- jsonFrame("error", errorPayload{Message: "invalid response payload"})
 				continue
 			}
-			send <- outboundMessage[any]{Type: "answerResult", Payload: answerResult{
+			err := h.service.SubmitResponse(r.Context(), sessionID, userID, domain.AnswerSubmission{
 				QuestionID: payload.QuestionID,
-				Correct:    correct,
-				Awarded:    awarded,
-				TotalScore: total,
-			}}
-			send <- outboundMessage[any]{Type: "leaderboard", Payload: lb}
+				OptionID:   payload.OptionID,
+				OptionIDs:  payload.OptionIDs,
+				Position:   payload.Position,
+				Positions:  payload.Positions,
+				Rating:     payload.Rating,
+				Text:       payload.Text,
+			})
+			if err != nil {
+				send <- jsonFrame("error", errorPayload{Message: err.Error()})
+				continue
+			}
+			send <- jsonFrame("responseRecorded", responseRecorded{QuestionID: payload.QuestionID})
 		default:
-			send <- outboundMessage[any]{Type: "error", Payload: errorPayload{Message: "unsupported message type"}}
+			send <- jsonFrame("error", errorPayload{Message: "unsupported message type"})
 		}
 	}
 
@@ -158,6 +428,92 @@ func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	<-writerDone
 }
 
+// readMessages feeds inbound messages to the handler until the socket closes or the handler stops.
+func readMessages(conn *websocket.Conn, out chan<- inboundMessage, stop <-chan struct{}) {
+	defer close(out)
+	for {
+		var inbound inboundMessage
+		if err := conn.ReadJSON(&inbound); err != nil {
+			return
+		}
+		select {
+		case out <- inbound:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// awaitAdmission relays waiting-room positions until the player is let in. It reports false when
+// they are denied, replaced by another connection, or the socket closes first.
+func awaitAdmission(conn *websocket.Conn, waiter *app.Waiter, messages <-chan inboundMessage) bool {
+	for {
+		select {
+		case status, ok := <-waiter.Updates:
+			if !ok {
+				_ = conn.WriteJSON(outboundMessage[errorPayload]{Type: "error", Payload: errorPayload{Message: "replaced by another connection"}})
+				return false
+			}
+			if err := conn.WriteJSON(outboundMessage[domain.WaitingStatus]{Type: "waiting", Payload: status}); err != nil {
+				waiter.Cancel()
+				return false
+			}
+			if status.Admitted || status.Denied {
+				return status.Admitted
+			}
+		case _, ok := <-messages:
+			// Nothing but waiting happens until admission.
+			if !ok {
+				waiter.Cancel()
+				return false
+			}
+		}
+	}
+}
+
+// resolveSession picks the session to join: a join code (pin) or an explicit sessionId.
+func (h *WSHandler) resolveSession(r *http.Request) (string, error) {
+	q := r.URL.Query()
+	switch {
+	case q.Get("pin") != "":
+		return h.service.ResolveJoinCode(r.Context(), q.Get("pin"))
+	case q.Get("sessionId") != "":
+		return q.Get("sessionId"), nil
+	}
+	return "", errors.New("missing pin or sessionId")
+}
+
+// parseView reads the optional top/around/patch query params; omitting them subscribes to full snapshots.
+func parseView(r *http.Request) (domain.LeaderboardView, error) {
+	var view domain.LeaderboardView
+	for name, dst := range map[string]*int{"top": &view.Top, "around": &view.Around} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return view, fmt.Errorf("invalid %s", name)
+		}
+		*dst = n
+	}
+	view.Patch = r.URL.Query().Get("patch") == "1"
+	return view, nil
+}
+
+// parseSince reads the leaderboard version a reconnecting patch client already holds.
+func parseSince(r *http.Request) (*uint64, error) {
+	raw := r.URL.Query().Get("since")
+	if raw == "" {
+		return nil, nil
+	}
+	version, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid since")
+	}
+	return &version, nil
+}
+
 func scoreAwarded(correct bool, _ domain.Leaderboard, _ string, total int) int {
 	if !correct {
 		return 0
@@ -1,8 +1,13 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,13 +22,17 @@ func TestWebSocketAnswerFlow(t *testing.T) {
 	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
 	service := app.NewQuizService(store, quizRepo)
 	wsHandler := NewWSHandler(service)
+	info, err := service.CreateSession(context.Background(), "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ws", wsHandler.ServeWS)
 	server := httptest.NewServer(mux)
 	defer server.Close()
 
-	u := "ws" + server.URL[len("http"):] + "/ws?quizId=quiz-1&userId=u1&name=Alice"
+	u := "ws" + server.URL[len("http"):] + "/ws?sessionId=" + info.SessionID + "&userId=u1&name=Alice"
 	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
 	if err != nil {
 		t.Fatalf("dial: %v", err)
@@ -71,6 +80,338 @@ func TestWebSocketAnswerFlow(t *testing.T) {
 	}
 }
 
+func TestWebSocketPatchMode(t *testing.T) {
+	store := memory.NewSessionStore()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(store, quizRepo)
+	info, err := service.CreateSession(context.Background(), "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(NewWSHandler(service).ServeWS))
+	defer server.Close()
+
+	u := "ws" + server.URL[len("http"):] + "/ws?sessionId=" + info.SessionID + "&userId=u1&name=Alice&patch=1"
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, joined := readNext(conn, t, "joined")
+	base := joined["version"].(float64)
+
+	if err := conn.WriteJSON(map[string]any{
+		"type":    "answer",
+		"payload": map[string]any{"questionId": "q1", "optionId": "o2"},
+	}); err != nil {
+		t.Fatalf("write answer: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		typ, payload := readNext(conn, t, "")
+		if typ != app.EventLeaderboardPatch {
+			continue
+		}
+		if payload["baseVersion"].(float64) != base {
+			t.Fatalf("expected patch from version %v, got %v", base, payload["baseVersion"])
+		}
+		ops := payload["ops"].([]any)
+		if len(ops) != 1 || ops[0].(map[string]any)["op"] != domain.PatchUpsert {
+			t.Fatalf("expected a single upsert, got %v", ops)
+		}
+		return
+	}
+	t.Fatalf("expected a leaderboard patch")
+}
+
+func TestCreateSessionAndJoinByPin(t *testing.T) {
+	store := memory.NewSessionStore()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(store, quizRepo, app.WithJoinCodes(memory.NewJoinCodeStore(), time.Hour))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", NewWSHandler(service).ServeWS)
+	mux.HandleFunc("POST /sessions", NewSessionHandler(service).CreateSession)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/sessions", "application/json", strings.NewReader(`{"quizId":"quiz-1","settings":{"maxParticipants":1}}`))
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	var info domain.SessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	if info.HostToken == "" || info.Settings.MaxParticipants != 1 || info.Settings.AnswerChange != domain.AnswerChangeNone {
+		t.Fatalf("expected host token and resolved settings, got %+v", info)
+	}
+
+	u := "ws" + server.URL[len("http"):] + "/ws?pin=" + info.JoinCode + "&userId=u1&name=Alice"
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, joined := readNext(conn, t, "joined"); joined["sessionId"] != info.SessionID {
+		t.Fatalf("expected to join %s, got %v", info.SessionID, joined["sessionId"])
+	}
+
+	if _, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[len("http"):]+"/ws?pin=NOPE22&userId=u2&name=Bob", nil); err == nil {
+		t.Fatalf("expected unknown pin to be rejected")
+	}
+}
+
+func TestQuizReportsNeedAdminToken(t *testing.T) {
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(memory.NewSessionStore(), quizRepo)
+
+	get := func(handler *QuizHandler, path, token string) int {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /quizzes/{id}/analytics", handler.Analytics)
+		mux.HandleFunc("GET /quizzes/{id}/diff", handler.Diff)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	handler := NewQuizHandler(service, "s3cret")
+	// The sample quiz has no version 1, so an authorized diff is a 404 rather than a 403.
+	routes := map[string]int{"/quizzes/quiz-1/analytics": http.StatusOK, "/quizzes/quiz-1/diff?from=1&to=2": http.StatusNotFound}
+	for path, authorized := range routes {
+		for token, want := range map[string]int{"": http.StatusForbidden, "guess": http.StatusForbidden, "s3cret": authorized} {
+			if got := get(handler, path, token); got != want {
+				t.Fatalf("%s with token %q: expected %d, got %d", path, token, want, got)
+			}
+		}
+		if got := get(NewQuizHandler(service, ""), path, ""); got != http.StatusForbidden {
+			t.Fatalf("%s: expected reports to be off without an admin token, got %d", path, got)
+		}
+	}
+}
+
+func TestWebSocketWaitingRoom(t *testing.T) {
+	store := memory.NewSessionStore()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(store, quizRepo)
+	info, err := service.CreateSession(context.Background(), "quiz-1", domain.SessionSettings{MaxParticipants: 1, WaitingRoom: true})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(NewWSHandler(service).ServeWS))
+	defer server.Close()
+
+	u := "ws" + server.URL[len("http"):] + "/ws?sessionId=" + info.SessionID
+	first, _, err := websocket.DefaultDialer.Dial(u+"&userId=u1&name=Alice", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	readNext(first, t, "joined")
+
+	second, _, err := websocket.DefaultDialer.Dial(u+"&userId=u2&name=Bob", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer second.Close()
+	if _, status := readNext(second, t, "waiting"); status["position"].(float64) != 1 {
+		t.Fatalf("expected first place in line, got %v", status)
+	}
+
+	first.Close()
+	if _, status := readNext(second, t, "waiting"); status["admitted"] != true {
+		t.Fatalf("expected admission, got %v", status)
+	}
+	readNext(second, t, "joined")
+}
+
+func TestHostSocketBansPlayer(t *testing.T) {
+	store := memory.NewSessionStore()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(store, quizRepo)
+	info, err := service.CreateSession(context.Background(), "quiz-1", domain.SessionSettings{})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(NewWSHandler(service).ServeWS))
+	defer server.Close()
+
+	u := "ws" + server.URL[len("http"):] + "/ws?sessionId=" + info.SessionID
+	if _, _, err := websocket.DefaultDialer.Dial(u+"&hostToken=guess", nil); err == nil {
+		t.Fatalf("expected a wrong host token to be rejected")
+	}
+	player, _, err := websocket.DefaultDialer.Dial(u+"&userId=u1&name=Troll", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer player.Close()
+	readNext(player, t, "joined")
+
+	host, _, err := websocket.DefaultDialer.Dial(u+"&hostToken="+info.HostToken, nil)
+	if err != nil {
+		t.Fatalf("dial host: %v", err)
+	}
+	defer host.Close()
+	if err := host.WriteJSON(map[string]any{"type": "ban", "payload": map[string]any{"userId": "u1", "reason": "trolling"}}); err != nil {
+		t.Fatalf("write ban: %v", err)
+	}
+	for {
+		if typ, _ := readNext(host, t, ""); typ == "ack" {
+			break
+		}
+	}
+
+	for {
+		var msg map[string]any
+		err := player.ReadJSON(&msg)
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != CloseBanned || closeErr.Text != "trolling" {
+			t.Fatalf("expected ban close frame, got %v", err)
+		}
+		break
+	}
+
+	again, _, err := websocket.DefaultDialer.Dial(u+"&userId=u1&name=Troll", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer again.Close()
+	readNext(again, t, "error")
+}
+
+func TestHostAssignsAndBalancesTeams(t *testing.T) {
+	ctx := context.Background()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(memory.NewSessionStore(), quizRepo)
+	teams := &domain.TeamSettings{Teams: []string{"red", "blue"}, AutoAssign: true}
+	info, err := service.CreateSession(ctx, "quiz-1", domain.SessionSettings{Teams: teams})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2", "u3"} {
+		if _, err := service.Join(ctx, info.SessionID, userID, userID); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	sessionHandler := NewSessionHandler(service)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", NewWSHandler(service).ServeWS)
+	mux.HandleFunc("POST /sessions/{id}/participants/{userId}/{command}", sessionHandler.Moderate)
+	mux.HandleFunc("POST /sessions/{id}/participants/{userId}/team", sessionHandler.AssignTeam)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	assign := func(userID, token, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/sessions/"+info.SessionID+"/participants/"+userID+"/team", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("assign team: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := assign("u2", "guess", `{"team":"red"}`); status != http.StatusForbidden {
+		t.Fatalf("expected a player's token to be refused, got %d", status)
+	}
+	if status := assign("u2", info.HostToken, `{"team":"green"}`); status != http.StatusNotFound {
+		t.Fatalf("expected an unknown team to be a 404, got %d", status)
+	}
+	if status := assign("u2", info.HostToken, `{"team":"red"}`); status != http.StatusOK {
+		t.Fatalf("expected the host to move u2, got %d", status)
+	}
+	members := func() map[string]int {
+		lb, err := service.HostLeaderboard(ctx, info.SessionID, info.HostToken)
+		if err != nil {
+			t.Fatalf("leaderboard: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, team := range lb.Teams {
+			counts[team.Team] = team.Members
+		}
+		return counts
+	}
+	if got := members(); got["red"] != 3 || got["blue"] != 0 {
+		t.Fatalf("expected everyone on red, got %v", got)
+	}
+
+	host, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[len("http"):]+"/ws?sessionId="+info.SessionID+"&hostToken="+info.HostToken, nil)
+	if err != nil {
+		t.Fatalf("dial host: %v", err)
+	}
+	defer host.Close()
+	if err := host.WriteJSON(map[string]any{"type": "balanceTeams"}); err != nil {
+		t.Fatalf("write balanceTeams: %v", err)
+	}
+	for {
+		if typ, ack := readNext(host, t, ""); typ == "ack" {
+			if ack["command"] != "balanceTeams" {
+				t.Fatalf("expected a balanceTeams ack, got %v", ack)
+			}
+			break
+		}
+	}
+	if got := members(); got["red"] != 2 || got["blue"] != 1 {
+		t.Fatalf("expected balanced teams, got %v", got)
+	}
+}
+
+func TestWebSocketTakeover(t *testing.T) {
+	store := memory.NewSessionStore()
+	quizRepo := memory.NewQuizRepository(memory.NewStaticQuizLoader(sampleQuiz()), time.Minute)
+	service := app.NewQuizService(store, quizRepo)
+	info, err := service.CreateSession(context.Background(), "quiz-1", domain.SessionSettings{Devices: domain.DevicesTakeover})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(NewWSHandler(service).ServeWS))
+	defer server.Close()
+
+	u := "ws" + server.URL[len("http"):] + "/ws?sessionId=" + info.SessionID + "&userId=u1&name=Alice"
+	phone, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer phone.Close()
+	readNext(phone, t, "joined")
+
+	laptop, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer laptop.Close()
+	readNext(laptop, t, "joined")
+
+	for {
+		var msg map[string]any
+		err := phone.ReadJSON(&msg)
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != CloseTakenOver {
+			t.Fatalf("expected takeover close frame, got %v", err)
+		}
+		break
+	}
+
+	// The replaced socket closing must not remove the player from the session.
+	lb, err := service.LeaderboardView(context.Background(), info.SessionID, "u1", domain.LeaderboardView{})
+	if err != nil || len(lb.Entries) != 1 {
+		t.Fatalf("expected the player to stay in the session, got %+v (%v)", lb, err)
+	}
+}
+
 func readNext(conn *websocket.Conn, t *testing.T, expect string) (string, map[string]any) {
 	t.Helper()
 	var msg struct {
@@ -106,3 +447,86 @@ func sampleQuiz() map[string]domain.Quiz {
 		},
 	}
 }
+
+// BenchmarkBroadcastPerConnection encodes the leaderboard separately for every socket (the old fan-out path).
+func BenchmarkBroadcastPerConnection(b *testing.B) {
+	conns := benchConns(b, 100)
+	update := &app.Broadcast{Type: app.EventLeaderboard, Payload: benchLeaderboard(1000)}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, conn := range conns {
+			if err := conn.WriteJSON(outboundMessage[any]{Type: update.Type, Payload: update.Payload}); err != nil {
+				b.Fatalf("write: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkBroadcastPrepared encodes the leaderboard once and reuses the prepared frame for every socket.
+func BenchmarkBroadcastPrepared(b *testing.B) {
+	conns := benchConns(b, 100)
+	lb := benchLeaderboard(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		update := &app.Broadcast{Type: app.EventLeaderboard, Payload: lb}
+		for _, conn := range conns {
+			prepared, err := update.Prepare(prepareBroadcast)
+			if err != nil {
+				b.Fatalf("prepare: %v", err)
+			}
+			if err := conn.WritePreparedMessage(prepared.(*websocket.PreparedMessage)); err != nil {
+				b.Fatalf("write: %v", err)
+			}
+		}
+	}
+}
+
+// benchConns returns n server-side sockets whose clients discard everything they read.
+func benchConns(b *testing.B, n int) []*websocket.Conn {
+	b.Helper()
+	upgrader := websocket.Upgrader{}
+	accepted := make(chan *websocket.Conn, n)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}))
+	b.Cleanup(server.Close)
+
+	u := "ws" + server.URL[len("http"):]
+	conns := make([]*websocket.Conn, 0, n)
+	for i := 0; i < n; i++ {
+		client, _, err := websocket.DefaultDialer.Dial(u, nil)
+		if err != nil {
+			b.Fatalf("dial: %v", err)
+		}
+		b.Cleanup(func() { client.Close() })
+		go func() {
+			for {
+				if _, _, err := client.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		conn := <-accepted
+		b.Cleanup(func() { conn.Close() })
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func benchLeaderboard(n int) domain.Leaderboard {
+	entries := make([]domain.LeaderboardEntry, n)
+	for i := range entries {
+		entries[i] = domain.LeaderboardEntry{
+			UserID:      fmt.Sprintf("u%d", i),
+			DisplayName: fmt.Sprintf("Player %d", i),
+			Score:       n - i,
+		}
+	}
+	return domain.Leaderboard{QuizID: "quiz-1", Entries: entries, UpdatedAt: time.Now()}
+}
@@ -0,0 +1,6 @@
+-- Creates the question_banks table that quizzes draw questions from, stored as JSONB like quizzes.
+CREATE TABLE IF NOT EXISTS question_banks (
+    id TEXT PRIMARY KEY,
+    data JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
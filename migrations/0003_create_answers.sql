@@ -0,0 +1,15 @@
+-- Creates the answers table: each participant's latest answer to each graded question, for item analytics.
+CREATE TABLE IF NOT EXISTS answers (
+    session_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    question_id TEXT NOT NULL,
+    quiz_id TEXT NOT NULL,
+    option_id TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    awarded INTEGER NOT NULL,
+    response_ms BIGINT NOT NULL DEFAULT 0,
+    answered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, user_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_answers_quiz ON answers (quiz_id);
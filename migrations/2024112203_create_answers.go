@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/uptrace/bun"
+)
+
+//go:embed 0003_create_answers.sql
+var createAnswersSQL string
+
+func init() {
+	Migrations.MustRegister(
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(createAnswersSQL)
+			return err
+		},
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(`DROP TABLE IF EXISTS answers`)
+			return err
+		},
+	)
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/uptrace/bun"
+)
+
+//go:embed 0005_add_answer_quiz_version.sql
+var addAnswerQuizVersionSQL string
+
+func init() {
+	Migrations.MustRegister(
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(addAnswerQuizVersionSQL)
+			return err
+		},
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(`ALTER TABLE answers DROP COLUMN IF EXISTS quiz_version`)
+			return err
+		},
+	)
+}
@@ -0,0 +1,6 @@
+-- Creates the question_banks table that quizzes draw questions from, stored as JSON text like quizzes.
+CREATE TABLE IF NOT EXISTS question_banks (
+    id TEXT PRIMARY KEY,
+    data TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- Creates the quiz_versions table: every revision of a quiz, immutable once written. New sessions
+-- run the published version and stay pinned to it; quizzes keeps a copy of the published data.
+CREATE TABLE IF NOT EXISTS quiz_versions (
+    quiz_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    data TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    PRIMARY KEY (quiz_id, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quiz_versions_published ON quiz_versions (quiz_id) WHERE status = 'published';
+
+-- Quizzes stored before versioning become their version 1.
+INSERT OR IGNORE INTO quiz_versions (quiz_id, version, status, data, created_at, published_at)
+SELECT id, 1, 'published', data, updated_at, updated_at FROM quizzes;
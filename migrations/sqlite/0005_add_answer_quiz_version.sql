@@ -0,0 +1,9 @@
+-- Records which quiz version each answer was given to, so analytics don't mix versions.
+ALTER TABLE answers ADD COLUMN quiz_version INTEGER NOT NULL DEFAULT 0;
+
+-- Answers recorded before this are taken to belong to the version published now.
+UPDATE answers SET quiz_version = COALESCE(
+    (SELECT v.version FROM quiz_versions v WHERE v.quiz_id = answers.quiz_id AND v.status = 'published'), 0)
+WHERE quiz_version = 0;
+
+CREATE INDEX IF NOT EXISTS idx_answers_quiz_version ON answers (quiz_id, quiz_version);
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/uptrace/bun"
+)
+
+//go:embed 0002_create_question_banks.sql
+var createQuestionBanksSQL string
+
+func init() {
+	Migrations.MustRegister(
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(createQuestionBanksSQL)
+			return err
+		},
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(`DROP TABLE IF EXISTS question_banks`)
+			return err
+		},
+	)
+}
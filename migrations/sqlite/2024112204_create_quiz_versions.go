@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/uptrace/bun"
+)
+
+//go:embed 0004_create_quiz_versions.sql
+var createQuizVersionsSQL string
+
+func init() {
+	Migrations.MustRegister(
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(createQuizVersionsSQL)
+			return err
+		},
+		func(ctx context.Context, db *bun.DB) error {
+			_, err := db.Exec(`DROP TABLE IF EXISTS quiz_versions`)
+			return err
+		},
+	)
+}